@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks
+    ADD COLUMN parent_id UUID REFERENCES tasks(id) ON DELETE CASCADE,
+    ADD COLUMN story_points INTEGER CHECK (story_points >= 0),
+    ADD COLUMN estimate_seconds BIGINT CHECK (estimate_seconds >= 0),
+    ADD COLUMN remaining_seconds BIGINT CHECK (remaining_seconds >= 0),
+    ADD CONSTRAINT tasks_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX tasks_parent_id_idx ON tasks (parent_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tasks
+    DROP CONSTRAINT tasks_parent_not_self,
+    DROP COLUMN remaining_seconds,
+    DROP COLUMN estimate_seconds,
+    DROP COLUMN story_points,
+    DROP COLUMN parent_id;
+-- +goose StatementEnd
@@ -0,0 +1,101 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE workspaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    personal BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Every user gets exactly one personal workspace.
+CREATE UNIQUE INDEX workspaces_personal_idx ON workspaces (created_by) WHERE personal;
+
+CREATE TABLE workspace_members (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'guest')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX workspace_members_user_id_idx ON workspace_members (user_id);
+
+CREATE TABLE workspace_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'member', 'guest')),
+    token_hash TEXT NOT NULL UNIQUE,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX workspace_invitations_workspace_id_idx ON workspace_invitations (workspace_id);
+
+CREATE TABLE projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX projects_workspace_id_idx ON projects (workspace_id);
+
+-- Backfill a personal workspace for every existing user and move their tasks into it.
+INSERT INTO workspaces (name, personal, created_by)
+SELECT username || '''s workspace', TRUE, id FROM users;
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT id, created_by, 'owner' FROM workspaces WHERE personal;
+
+ALTER TABLE tasks
+    ADD COLUMN workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE,
+    ADD COLUMN project_id UUID REFERENCES projects(id) ON DELETE SET NULL;
+
+UPDATE tasks t SET workspace_id = w.id
+FROM workspaces w
+WHERE w.personal AND w.created_by = t.user_id;
+
+ALTER TABLE tasks ALTER COLUMN workspace_id SET NOT NULL;
+
+CREATE INDEX tasks_workspace_id_idx ON tasks (workspace_id);
+CREATE INDEX tasks_project_id_idx ON tasks (project_id);
+
+CREATE FUNCTION create_personal_workspace() RETURNS TRIGGER AS $$
+DECLARE
+    ws_id UUID;
+BEGIN
+    INSERT INTO workspaces (name, personal, created_by)
+    VALUES (NEW.username || '''s workspace', TRUE, NEW.id)
+    RETURNING id INTO ws_id;
+
+    INSERT INTO workspace_members (workspace_id, user_id, role)
+    VALUES (ws_id, NEW.id, 'owner');
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_personal_workspace
+AFTER INSERT ON users
+FOR EACH ROW EXECUTE FUNCTION create_personal_workspace();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER users_personal_workspace ON users;
+DROP FUNCTION create_personal_workspace();
+ALTER TABLE tasks DROP COLUMN project_id, DROP COLUMN workspace_id;
+DROP TABLE projects;
+DROP TABLE workspace_invitations;
+DROP TABLE workspace_members;
+DROP TABLE workspaces;
+-- +goose StatementEnd
@@ -0,0 +1,133 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Row-level security keyed off per-transaction settings that the repository
+-- layer sets from the authenticated request:
+--   app.user_id       the signed-in user
+--   app.workspace_id  the workspace from the route, if any
+--   app.bypass_rls    'on' for trusted background work only
+-- Policies are FORCEd so they also apply to the table owner. The application
+-- role must not be a superuser or have BYPASSRLS, or none of this applies.
+
+CREATE FUNCTION app_current_user() RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.user_id', TRUE), '')::UUID
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION app_current_workspace() RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.workspace_id', TRUE), '')::UUID
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION app_rls_bypass() RETURNS BOOLEAN AS $$
+    SELECT COALESCE(current_setting('app.bypass_rls', TRUE), '') = 'on'
+$$ LANGUAGE sql STABLE;
+
+-- The helpers below look across tables that are themselves protected, so they
+-- switch the bypass on for their own duration to avoid recursive policies.
+CREATE FUNCTION app_is_member(ws UUID) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM workspace_members
+        WHERE workspace_id = ws AND user_id = app_current_user()
+    )
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+
+CREATE FUNCTION app_is_assignee(tid UUID) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM task_assignees
+        WHERE task_id = tid AND user_id = app_current_user()
+    )
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+
+-- The tasks policy is written against the row's own columns so it also holds
+-- for rows being inserted; the related tables reuse it through a lookup.
+CREATE FUNCTION app_task_visible(tid UUID, ws UUID, owner UUID) RETURNS BOOLEAN AS $$
+    SELECT (app_current_workspace() IS NULL OR ws = app_current_workspace())
+        AND (owner = app_current_user() OR app_is_member(ws) OR app_is_assignee(tid))
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION app_can_access_task(tid UUID) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM tasks t
+        WHERE t.id = tid AND app_task_visible(t.id, t.workspace_id, t.user_id)
+    )
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+
+ALTER TABLE tasks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tasks FORCE ROW LEVEL SECURITY;
+CREATE POLICY tasks_tenant ON tasks
+    USING (app_rls_bypass() OR app_task_visible(id, workspace_id, user_id))
+    WITH CHECK (
+        app_rls_bypass()
+        OR (
+            (app_current_workspace() IS NULL OR workspace_id = app_current_workspace())
+            AND (user_id = app_current_user() OR app_is_member(workspace_id))
+        )
+    );
+
+ALTER TABLE projects ENABLE ROW LEVEL SECURITY;
+ALTER TABLE projects FORCE ROW LEVEL SECURITY;
+CREATE POLICY projects_tenant ON projects
+    USING (
+        app_rls_bypass()
+        OR (
+            (app_current_workspace() IS NULL OR workspace_id = app_current_workspace())
+            AND app_is_member(workspace_id)
+        )
+    );
+
+ALTER TABLE task_attachments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_attachments FORCE ROW LEVEL SECURITY;
+-- Uploaders keep seeing their own attachments so quota usage stays accurate.
+CREATE POLICY task_attachments_tenant ON task_attachments
+    USING (app_rls_bypass() OR user_id = app_current_user() OR app_can_access_task(task_id))
+    WITH CHECK (app_rls_bypass() OR app_can_access_task(task_id));
+
+ALTER TABLE time_entries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE time_entries FORCE ROW LEVEL SECURITY;
+CREATE POLICY time_entries_tenant ON time_entries
+    USING (app_rls_bypass() OR user_id = app_current_user() OR app_can_access_task(task_id))
+    WITH CHECK (app_rls_bypass() OR app_can_access_task(task_id));
+
+ALTER TABLE task_assignees ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_assignees FORCE ROW LEVEL SECURITY;
+CREATE POLICY task_assignees_tenant ON task_assignees
+    USING (app_rls_bypass() OR app_can_access_task(task_id));
+
+ALTER TABLE task_watchers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_watchers FORCE ROW LEVEL SECURITY;
+CREATE POLICY task_watchers_tenant ON task_watchers
+    USING (app_rls_bypass() OR app_can_access_task(task_id));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY task_watchers_tenant ON task_watchers;
+ALTER TABLE task_watchers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE task_watchers DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY task_assignees_tenant ON task_assignees;
+ALTER TABLE task_assignees NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE task_assignees DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY time_entries_tenant ON time_entries;
+ALTER TABLE time_entries NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE time_entries DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY task_attachments_tenant ON task_attachments;
+ALTER TABLE task_attachments NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE task_attachments DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY projects_tenant ON projects;
+ALTER TABLE projects NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE projects DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY tasks_tenant ON tasks;
+ALTER TABLE tasks NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tasks DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION app_can_access_task(UUID);
+DROP FUNCTION app_task_visible(UUID, UUID, UUID);
+DROP FUNCTION app_is_assignee(UUID);
+DROP FUNCTION app_is_member(UUID);
+DROP FUNCTION app_rls_bypass();
+DROP FUNCTION app_current_workspace();
+DROP FUNCTION app_current_user();
+-- +goose StatementEnd
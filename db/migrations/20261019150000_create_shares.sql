@@ -0,0 +1,145 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_shares (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL CHECK (permission IN ('viewer', 'editor')),
+    shared_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE TABLE project_shares (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL CHECK (permission IN ('viewer', 'editor')),
+    shared_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE INDEX task_shares_user_id_idx ON task_shares (user_id);
+CREATE INDEX project_shares_user_id_idx ON project_shares (user_id);
+
+-- Public read-only links to a single task or project. Only a hash of the
+-- token is stored.
+CREATE TABLE share_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID REFERENCES tasks(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    password_hash TEXT,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (num_nonnulls(task_id, project_id) = 1)
+);
+
+CREATE INDEX share_links_task_id_idx ON share_links (task_id);
+CREATE INDEX share_links_project_id_idx ON share_links (project_id);
+
+-- The strongest permission userID was given on the task, directly or through
+-- its project: 'editor', 'viewer' or NULL.
+CREATE FUNCTION app_share_permission(tid UUID, pid UUID, uid UUID) RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN bool_or(permission = 'editor') THEN 'editor'
+        WHEN COUNT(*) > 0 THEN 'viewer'
+    END
+    FROM (
+        SELECT permission FROM task_shares WHERE task_id = tid AND user_id = uid
+        UNION ALL
+        SELECT permission FROM project_shares WHERE project_id = pid AND user_id = uid
+    ) s
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+
+CREATE FUNCTION app_task_visible(tid UUID, ws UUID, owner UUID, pid UUID) RETURNS BOOLEAN AS $$
+    SELECT (app_current_workspace() IS NULL OR ws = app_current_workspace())
+        AND (
+            owner = app_current_user()
+            OR app_is_member(ws)
+            OR app_is_assignee(tid)
+            OR app_share_permission(tid, pid, app_current_user()) IS NOT NULL
+        )
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION app_can_access_task(tid UUID) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM tasks t
+        WHERE t.id = tid AND app_task_visible(t.id, t.workspace_id, t.user_id, t.project_id)
+    )
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+
+DROP POLICY tasks_tenant ON tasks;
+DROP FUNCTION app_task_visible(UUID, UUID, UUID);
+
+CREATE POLICY tasks_tenant ON tasks
+    USING (app_rls_bypass() OR app_task_visible(id, workspace_id, user_id, project_id))
+    WITH CHECK (
+        app_rls_bypass()
+        OR (
+            (app_current_workspace() IS NULL OR workspace_id = app_current_workspace())
+            AND (
+                user_id = app_current_user()
+                OR app_is_member(workspace_id)
+                OR app_share_permission(id, project_id, app_current_user()) = 'editor'
+            )
+        )
+    );
+
+DROP POLICY projects_tenant ON projects;
+CREATE POLICY projects_tenant ON projects
+    USING (
+        app_rls_bypass()
+        OR (
+            (app_current_workspace() IS NULL OR workspace_id = app_current_workspace())
+            AND (
+                app_is_member(workspace_id)
+                OR EXISTS (SELECT 1 FROM project_shares s WHERE s.project_id = projects.id AND s.user_id = app_current_user())
+            )
+        )
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY projects_tenant ON projects;
+CREATE POLICY projects_tenant ON projects
+    USING (
+        app_rls_bypass()
+        OR (
+            (app_current_workspace() IS NULL OR workspace_id = app_current_workspace())
+            AND app_is_member(workspace_id)
+        )
+    );
+
+CREATE FUNCTION app_task_visible(tid UUID, ws UUID, owner UUID) RETURNS BOOLEAN AS $$
+    SELECT (app_current_workspace() IS NULL OR ws = app_current_workspace())
+        AND (owner = app_current_user() OR app_is_member(ws) OR app_is_assignee(tid))
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION app_can_access_task(tid UUID) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM tasks t
+        WHERE t.id = tid AND app_task_visible(t.id, t.workspace_id, t.user_id)
+    )
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+
+DROP POLICY tasks_tenant ON tasks;
+DROP FUNCTION app_task_visible(UUID, UUID, UUID, UUID);
+
+CREATE POLICY tasks_tenant ON tasks
+    USING (app_rls_bypass() OR app_task_visible(id, workspace_id, user_id))
+    WITH CHECK (
+        app_rls_bypass()
+        OR (
+            (app_current_workspace() IS NULL OR workspace_id = app_current_workspace())
+            AND (user_id = app_current_user() OR app_is_member(workspace_id))
+        )
+    );
+
+DROP FUNCTION app_share_permission(UUID, UUID, UUID);
+DROP TABLE share_links;
+DROP TABLE project_shares;
+DROP TABLE task_shares;
+-- +goose StatementEnd
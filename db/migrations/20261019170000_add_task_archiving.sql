@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_archived_at_idx ON tasks (archived_at) WHERE archived_at IS NOT NULL;
+
+-- NULL leaves automatic archival off for the user.
+ALTER TABLE users ADD COLUMN auto_archive_days INTEGER CHECK (auto_archive_days > 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN auto_archive_days;
+
+DROP INDEX tasks_archived_at_idx;
+
+ALTER TABLE tasks DROP COLUMN archived_at;
+-- +goose StatementEnd
@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_events (
+    id BIGSERIAL PRIMARY KEY,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    -- NULL when the change was made by a background job.
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    field TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX task_events_task_id_idx ON task_events (task_id, id);
+
+-- Events are written by a trigger so they land in the same transaction as the
+-- change itself, however the row was touched. The actor is whoever the
+-- repository layer set in app.user_id.
+CREATE FUNCTION record_task_events() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO task_events (task_id, actor_id, field, new_value)
+        VALUES (NEW.id, app_current_user(), 'created', NEW.name);
+
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO task_events (task_id, actor_id, field, old_value, new_value)
+    SELECT NEW.id, app_current_user(), c.field, c.old_value, c.new_value
+    FROM (VALUES
+        ('name', OLD.name, NEW.name),
+        ('description', OLD.description, NEW.description),
+        ('status', OLD.status, NEW.status),
+        ('project_id', OLD.project_id::TEXT, NEW.project_id::TEXT),
+        ('parent_id', OLD.parent_id::TEXT, NEW.parent_id::TEXT),
+        ('story_points', OLD.story_points::TEXT, NEW.story_points::TEXT),
+        ('estimate_seconds', OLD.estimate_seconds::TEXT, NEW.estimate_seconds::TEXT),
+        ('remaining_seconds', OLD.remaining_seconds::TEXT, NEW.remaining_seconds::TEXT),
+        ('archived_at', to_json(OLD.archived_at) #>> '{}', to_json(NEW.archived_at) #>> '{}'),
+        ('deleted_at', to_json(OLD.deleted_at) #>> '{}', to_json(NEW.deleted_at) #>> '{}')
+    ) AS c (field, old_value, new_value)
+    WHERE c.old_value IS DISTINCT FROM c.new_value;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql SET app.bypass_rls = 'on';
+
+CREATE TRIGGER tasks_record_events
+AFTER INSERT OR UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION record_task_events();
+
+ALTER TABLE task_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY task_events_tenant ON task_events
+    USING (app_rls_bypass() OR app_can_access_task(task_id));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER tasks_record_events ON tasks;
+DROP FUNCTION record_task_events();
+DROP TABLE task_events;
+-- +goose StatementEnd
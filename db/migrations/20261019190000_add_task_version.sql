@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- Every write moves the version on, so clients holding an older one (as an
+-- ETag) can be told their copy is stale.
+CREATE FUNCTION bump_task_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_bump_version
+BEFORE UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION bump_task_version();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER tasks_bump_version ON tasks;
+DROP FUNCTION bump_task_version();
+ALTER TABLE tasks DROP COLUMN version;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_labels (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label TEXT NOT NULL CHECK (label <> ''),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, label)
+);
+
+CREATE INDEX task_labels_label_idx ON task_labels (label);
+
+ALTER TABLE task_labels ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_labels FORCE ROW LEVEL SECURITY;
+CREATE POLICY task_labels_tenant ON task_labels
+    USING (app_rls_bypass() OR app_can_access_task(task_id));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE task_labels;
+-- +goose StatementEnd
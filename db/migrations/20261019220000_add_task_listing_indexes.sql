@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Task listings page through (created_at, id).
+CREATE INDEX tasks_created_at_id_idx ON tasks (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX tasks_user_id_created_at_id_idx ON tasks (user_id, created_at, id) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX tasks_user_id_created_at_id_idx;
+DROP INDEX tasks_created_at_id_idx;
+-- +goose StatementEnd
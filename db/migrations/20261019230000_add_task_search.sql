@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Names weigh more than descriptions when ranking. Tasks have no comments
+-- yet; when they do, their text belongs in here too.
+ALTER TABLE tasks ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(name, '')), 'A')
+    || setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX tasks_search_vector_idx ON tasks USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX tasks_search_vector_idx;
+ALTER TABLE tasks DROP COLUMN search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,71 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMPTZ;
+
+CREATE INDEX tasks_due_at_idx ON tasks (due_at) WHERE due_at IS NOT NULL AND deleted_at IS NULL;
+
+CREATE OR REPLACE FUNCTION record_task_events() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO task_events (task_id, actor_id, field, new_value)
+        VALUES (NEW.id, app_current_user(), 'created', NEW.name);
+
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO task_events (task_id, actor_id, field, old_value, new_value)
+    SELECT NEW.id, app_current_user(), c.field, c.old_value, c.new_value
+    FROM (VALUES
+        ('name', OLD.name, NEW.name),
+        ('description', OLD.description, NEW.description),
+        ('status', OLD.status, NEW.status),
+        ('project_id', OLD.project_id::TEXT, NEW.project_id::TEXT),
+        ('parent_id', OLD.parent_id::TEXT, NEW.parent_id::TEXT),
+        ('story_points', OLD.story_points::TEXT, NEW.story_points::TEXT),
+        ('estimate_seconds', OLD.estimate_seconds::TEXT, NEW.estimate_seconds::TEXT),
+        ('remaining_seconds', OLD.remaining_seconds::TEXT, NEW.remaining_seconds::TEXT),
+        ('due_at', to_json(OLD.due_at) #>> '{}', to_json(NEW.due_at) #>> '{}'),
+        ('archived_at', to_json(OLD.archived_at) #>> '{}', to_json(NEW.archived_at) #>> '{}'),
+        ('deleted_at', to_json(OLD.deleted_at) #>> '{}', to_json(NEW.deleted_at) #>> '{}')
+    ) AS c (field, old_value, new_value)
+    WHERE c.old_value IS DISTINCT FROM c.new_value;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql SET app.bypass_rls = 'on';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_task_events() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO task_events (task_id, actor_id, field, new_value)
+        VALUES (NEW.id, app_current_user(), 'created', NEW.name);
+
+        RETURN NEW;
+    END IF;
+
+    INSERT INTO task_events (task_id, actor_id, field, old_value, new_value)
+    SELECT NEW.id, app_current_user(), c.field, c.old_value, c.new_value
+    FROM (VALUES
+        ('name', OLD.name, NEW.name),
+        ('description', OLD.description, NEW.description),
+        ('status', OLD.status, NEW.status),
+        ('project_id', OLD.project_id::TEXT, NEW.project_id::TEXT),
+        ('parent_id', OLD.parent_id::TEXT, NEW.parent_id::TEXT),
+        ('story_points', OLD.story_points::TEXT, NEW.story_points::TEXT),
+        ('estimate_seconds', OLD.estimate_seconds::TEXT, NEW.estimate_seconds::TEXT),
+        ('remaining_seconds', OLD.remaining_seconds::TEXT, NEW.remaining_seconds::TEXT),
+        ('archived_at', to_json(OLD.archived_at) #>> '{}', to_json(NEW.archived_at) #>> '{}'),
+        ('deleted_at', to_json(OLD.deleted_at) #>> '{}', to_json(NEW.deleted_at) #>> '{}')
+    ) AS c (field, old_value, new_value)
+    WHERE c.old_value IS DISTINCT FROM c.new_value;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql SET app.bypass_rls = 'on';
+
+DROP INDEX tasks_due_at_idx;
+ALTER TABLE tasks DROP COLUMN due_at;
+-- +goose StatementEnd
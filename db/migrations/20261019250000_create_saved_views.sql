@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A saved view is a named task filter. Shared views belong to a workspace and
+-- every member of it can use them; only the owner can change them.
+CREATE TABLE saved_views (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (name <> ''),
+    query TEXT NOT NULL,
+    shared BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name),
+    CHECK (NOT shared OR workspace_id IS NOT NULL)
+);
+
+CREATE INDEX saved_views_workspace_id_idx ON saved_views (workspace_id) WHERE shared;
+
+ALTER TABLE saved_views ENABLE ROW LEVEL SECURITY;
+ALTER TABLE saved_views FORCE ROW LEVEL SECURITY;
+CREATE POLICY saved_views_tenant ON saved_views
+    USING (app_rls_bypass() OR user_id = app_current_user() OR (shared AND app_is_member(workspace_id)))
+    WITH CHECK (app_rls_bypass() OR user_id = app_current_user());
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE saved_views;
+-- +goose StatementEnd
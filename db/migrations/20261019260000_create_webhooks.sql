@@ -0,0 +1,90 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A webhook with a workspace gets events for every task in it; a personal one
+-- only for tasks its owner created. The secret signs deliveries, so it has to
+-- be kept as is rather than hashed.
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id UUID REFERENCES workspaces(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    disabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX webhooks_user_id_idx ON webhooks (user_id);
+CREATE INDEX webhooks_workspace_id_idx ON webhooks (workspace_id);
+
+-- Deliveries are both the retry queue and the delivery log. Every delivery of
+-- one event shares its event_id.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    response_status INTEGER,
+    response_body TEXT,
+    error TEXT,
+    duration_ms INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, created_at);
+
+-- Queues an event for every active webhook that should see it: webhooks of
+-- workspace ws, personal webhooks of owner, and webhooks of the workspaces
+-- member belongs to other than member's own. Runs with the bypass on since
+-- the webhooks belong to other users.
+CREATE FUNCTION enqueue_webhook_deliveries(ev TEXT, body JSONB, ws UUID, owner UUID, member UUID) RETURNS INTEGER AS $$
+    WITH event AS (SELECT gen_random_uuid() AS id),
+    queued AS (
+        INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+        SELECT w.id, event.id, ev,
+            jsonb_build_object('id', event.id, 'event', ev, 'created_at', NOW(), 'data', body)
+        FROM webhooks w, event
+        WHERE w.active AND ev = ANY(w.events)
+        AND (
+            w.workspace_id = ws
+            OR (w.workspace_id IS NULL AND w.user_id = owner)
+            OR (
+                w.user_id <> member
+                AND w.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = member)
+            )
+        )
+        RETURNING 1
+    )
+    SELECT COUNT(*)::INTEGER FROM queued
+$$ LANGUAGE sql VOLATILE SET app.bypass_rls = 'on';
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhooks_tenant ON webhooks
+    USING (app_rls_bypass() OR user_id = app_current_user());
+
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhook_deliveries_tenant ON webhook_deliveries
+    USING (
+        app_rls_bypass()
+        OR EXISTS (SELECT 1 FROM webhooks w WHERE w.id = webhook_id AND w.user_id = app_current_user())
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION enqueue_webhook_deliveries(TEXT, JSONB, UUID, UUID, UUID);
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+-- +goose StatementEnd
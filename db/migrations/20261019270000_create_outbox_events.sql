@@ -0,0 +1,90 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Domain events are written here in the same transaction as the change they
+-- describe, and published from here by the dispatcher. seq gives the order
+-- they are published in; two changes to the same row can't commit out of seq
+-- order since the second waits on the first's row lock before it writes its
+-- event.
+CREATE TABLE outbox_events (
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    workspace_ids UUID[] NOT NULL DEFAULT '{}',
+    user_id UUID,
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (seq) WHERE published_at IS NULL;
+CREATE INDEX outbox_events_aggregate_idx ON outbox_events (aggregate_id, seq) WHERE published_at IS NULL;
+CREATE INDEX outbox_events_published_at_idx ON outbox_events (published_at);
+
+-- Anyone can record an event; only the dispatcher reads them back.
+ALTER TABLE outbox_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY outbox_events_record ON outbox_events FOR INSERT
+    WITH CHECK (TRUE);
+CREATE POLICY outbox_events_system ON outbox_events
+    USING (app_rls_bypass());
+
+-- Webhooks now take their events from the outbox, which decides the event ID
+-- and which workspaces it concerns.
+DROP FUNCTION enqueue_webhook_deliveries(TEXT, JSONB, UUID, UUID, UUID);
+
+CREATE INDEX webhook_deliveries_event_id_idx ON webhook_deliveries (event_id);
+
+CREATE FUNCTION enqueue_webhook_deliveries(eid UUID, ev TEXT, body JSONB, created TIMESTAMPTZ, workspaces UUID[], owner UUID) RETURNS INTEGER AS $$
+    WITH queued AS (
+        INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+        SELECT w.id, eid, ev,
+            jsonb_build_object('id', eid, 'event', ev, 'created_at', created, 'data', body)
+        FROM webhooks w
+        WHERE w.active AND ev = ANY(w.events)
+        AND (
+            w.workspace_id = ANY(workspaces)
+            OR (w.workspace_id IS NULL AND w.user_id = owner)
+        )
+        -- The outbox may hand over the same event twice.
+        AND NOT EXISTS (
+            SELECT 1 FROM webhook_deliveries d
+            WHERE d.event_id = eid AND d.webhook_id = w.id
+        )
+        RETURNING 1
+    )
+    SELECT COUNT(*)::INTEGER FROM queued
+$$ LANGUAGE sql VOLATILE SET app.bypass_rls = 'on';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION enqueue_webhook_deliveries(UUID, TEXT, JSONB, TIMESTAMPTZ, UUID[], UUID);
+DROP INDEX webhook_deliveries_event_id_idx;
+
+CREATE FUNCTION enqueue_webhook_deliveries(ev TEXT, body JSONB, ws UUID, owner UUID, member UUID) RETURNS INTEGER AS $$
+    WITH event AS (SELECT gen_random_uuid() AS id),
+    queued AS (
+        INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+        SELECT w.id, event.id, ev,
+            jsonb_build_object('id', event.id, 'event', ev, 'created_at', NOW(), 'data', body)
+        FROM webhooks w, event
+        WHERE w.active AND ev = ANY(w.events)
+        AND (
+            w.workspace_id = ws
+            OR (w.workspace_id IS NULL AND w.user_id = owner)
+            OR (
+                w.user_id <> member
+                AND w.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = member)
+            )
+        )
+        RETURNING 1
+    )
+    SELECT COUNT(*)::INTEGER FROM queued
+$$ LANGUAGE sql VOLATILE SET app.bypass_rls = 'on';
+
+DROP TABLE outbox_events;
+-- +goose StatementEnd
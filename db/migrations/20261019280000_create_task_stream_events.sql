@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A short log of task events for the live stream, written by the outbox
+-- dispatcher. Only one dispatcher writes at a time, so ids commit in order and
+-- a client can resume after the last id it saw. audience is everyone who could
+-- see the task when the event happened.
+CREATE TABLE task_stream_events (
+    id BIGSERIAL PRIMARY KEY,
+    event TEXT NOT NULL,
+    task_id UUID NOT NULL,
+    workspace_id UUID,
+    audience UUID[] NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX task_stream_events_audience_idx ON task_stream_events USING GIN (audience);
+CREATE INDEX task_stream_events_created_at_idx ON task_stream_events (created_at);
+
+ALTER TABLE task_stream_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_stream_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY task_stream_events_system ON task_stream_events
+    USING (app_rls_bypass());
+
+-- Logs the event and wakes every server listening on task_stream once the
+-- transaction commits.
+CREATE FUNCTION record_task_stream_event(ev TEXT, tid UUID, ws UUID, owner UUID, body JSONB) RETURNS BIGINT AS $$
+    WITH logged AS (
+        INSERT INTO task_stream_events (event, task_id, workspace_id, audience, data)
+        SELECT ev, tid, ws, ARRAY(
+            SELECT owner WHERE owner IS NOT NULL
+            UNION SELECT user_id FROM workspace_members WHERE workspace_id = ws
+            UNION SELECT user_id FROM task_assignees WHERE task_id = tid
+            UNION SELECT user_id FROM task_shares WHERE task_id = tid
+            UNION SELECT s.user_id FROM project_shares s JOIN tasks t ON t.project_id = s.project_id WHERE t.id = tid
+        ), body
+        RETURNING id
+    )
+    SELECT id FROM logged, pg_notify('task_stream', id::TEXT)
+$$ LANGUAGE sql VOLATILE SET app.bypass_rls = 'on';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION record_task_stream_event(TEXT, UUID, UUID, UUID, JSONB);
+DROP TABLE task_stream_events;
+-- +goose StatementEnd
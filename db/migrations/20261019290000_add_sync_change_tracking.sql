@@ -0,0 +1,84 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Delta sync finds what changed through task_events. Each event carries the
+-- id of the transaction that wrote it: unlike timestamps or serial ids, a
+-- client token taken as the oldest transaction still running can't skip over
+-- a change that commits late.
+ALTER TABLE task_events ADD COLUMN xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+CREATE INDEX task_events_xid_idx ON task_events (xid);
+
+-- Labels are part of a task's history too.
+CREATE FUNCTION record_task_label_events() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO task_events (task_id, actor_id, field, new_value)
+        VALUES (NEW.task_id, app_current_user(), 'labels', NEW.label);
+
+        RETURN NEW;
+    END IF;
+
+    -- Labels that go because their task is being deleted take no event with
+    -- them; the task's events are going too.
+    PERFORM 1 FROM tasks WHERE id = OLD.task_id;
+    IF FOUND THEN
+        INSERT INTO task_events (task_id, actor_id, field, old_value)
+        VALUES (OLD.task_id, app_current_user(), 'labels', OLD.label);
+    END IF;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql SET app.bypass_rls = 'on';
+
+CREATE TRIGGER task_labels_record_events
+AFTER INSERT OR DELETE ON task_labels
+FOR EACH ROW EXECUTE FUNCTION record_task_label_events();
+
+-- Tasks deleted for good (purged from the trash, or with their workspace or
+-- creator) leave a tombstone behind, since their events go with them.
+CREATE TABLE task_tombstones (
+    task_id UUID PRIMARY KEY,
+    workspace_id UUID NOT NULL,
+    user_id UUID,
+    xid xid8 NOT NULL DEFAULT pg_current_xact_id(),
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX task_tombstones_xid_idx ON task_tombstones (xid);
+
+CREATE FUNCTION record_task_tombstone() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO task_tombstones (task_id, workspace_id, user_id)
+    VALUES (OLD.id, OLD.workspace_id, OLD.user_id)
+    ON CONFLICT (task_id) DO UPDATE SET xid = EXCLUDED.xid, deleted_at = EXCLUDED.deleted_at;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql SET app.bypass_rls = 'on';
+
+CREATE TRIGGER tasks_record_tombstone
+AFTER DELETE ON tasks
+FOR EACH ROW EXECUTE FUNCTION record_task_tombstone();
+
+ALTER TABLE task_tombstones ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_tombstones FORCE ROW LEVEL SECURITY;
+CREATE POLICY task_tombstones_tenant ON task_tombstones
+    USING (
+        app_rls_bypass()
+        OR (
+            (app_current_workspace() IS NULL OR workspace_id = app_current_workspace())
+            AND (user_id = app_current_user() OR app_is_member(workspace_id))
+        )
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER tasks_record_tombstone ON tasks;
+DROP FUNCTION record_task_tombstone();
+DROP TABLE task_tombstones;
+DROP TRIGGER task_labels_record_events ON task_labels;
+DROP FUNCTION record_task_label_events();
+DELETE FROM task_events WHERE field = 'labels';
+DROP INDEX task_events_xid_idx;
+ALTER TABLE task_events DROP COLUMN xid;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Each user has at most one calendar feed, reached through a secret URL.
+-- Only a hash of the token is stored; regenerating the feed replaces it.
+CREATE TABLE calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE calendar_feeds;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Imports too large to run within a request are queued here. tasks holds the
+-- rows that passed validation, ready to insert, and is cleared once the job
+-- is done. A job whose worker died is picked up again when its lease runs
+-- out; the import is a single transaction, so a retry starts from scratch.
+CREATE TABLE import_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    format TEXT NOT NULL,
+    tasks JSONB,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    total INTEGER NOT NULL,
+    processed INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX import_jobs_queue_idx ON import_jobs (created_at) WHERE status IN ('pending', 'running');
+CREATE INDEX import_jobs_finished_at_idx ON import_jobs (finished_at);
+
+ALTER TABLE import_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE import_jobs FORCE ROW LEVEL SECURITY;
+CREATE POLICY import_jobs_tenant ON import_jobs
+    USING (app_rls_bypass() OR user_id = app_current_user());
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE import_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Shares and public links were left out of row-level security when they were
+-- added. A share is visible to the user it was given to and to anyone who can
+-- see what was shared; links only to the latter. Opening a public link runs
+-- as the system, the token being the only authorization.
+
+CREATE FUNCTION app_can_access_project(pid UUID) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM projects p
+        WHERE p.id = pid
+        AND (app_current_workspace() IS NULL OR p.workspace_id = app_current_workspace())
+        AND app_is_member(p.workspace_id)
+    )
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+
+ALTER TABLE task_shares ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_shares FORCE ROW LEVEL SECURITY;
+CREATE POLICY task_shares_tenant ON task_shares
+    USING (app_rls_bypass() OR user_id = app_current_user() OR app_can_access_task(task_id))
+    WITH CHECK (app_rls_bypass() OR app_can_access_task(task_id));
+
+ALTER TABLE project_shares ENABLE ROW LEVEL SECURITY;
+ALTER TABLE project_shares FORCE ROW LEVEL SECURITY;
+CREATE POLICY project_shares_tenant ON project_shares
+    USING (app_rls_bypass() OR user_id = app_current_user() OR app_can_access_project(project_id))
+    WITH CHECK (app_rls_bypass() OR app_can_access_project(project_id));
+
+ALTER TABLE share_links ENABLE ROW LEVEL SECURITY;
+ALTER TABLE share_links FORCE ROW LEVEL SECURITY;
+CREATE POLICY share_links_tenant ON share_links
+    USING (
+        app_rls_bypass()
+        OR (task_id IS NOT NULL AND app_can_access_task(task_id))
+        OR (project_id IS NOT NULL AND app_can_access_project(project_id))
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY share_links_tenant ON share_links;
+ALTER TABLE share_links NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE share_links DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY project_shares_tenant ON project_shares;
+ALTER TABLE project_shares NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE project_shares DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY task_shares_tenant ON task_shares;
+ALTER TABLE task_shares NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE task_shares DISABLE ROW LEVEL SECURITY;
+
+DROP FUNCTION app_can_access_project(UUID);
+-- +goose StatementEnd
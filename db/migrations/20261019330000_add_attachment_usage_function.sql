@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A user's storage quota covers every attachment they uploaded, whichever
+-- workspace it is in, so usage is summed with the bypass on rather than
+-- through whatever the request is scoped to.
+CREATE FUNCTION app_attachment_usage(uid UUID) RETURNS BIGINT AS $$
+    SELECT COALESCE(SUM(size_bytes), 0)::BIGINT FROM task_attachments WHERE user_id = uid
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION app_attachment_usage(UUID);
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- App passwords let clients that sign in on every request, such as CalDAV
+-- apps, do so without the account's own password. Each is a random token
+-- shown once; only its hash is stored, so it can be checked without bcrypt.
+CREATE TABLE app_passwords (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX app_passwords_user_id_idx ON app_passwords (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE app_passwords;
+-- +goose StatementEnd
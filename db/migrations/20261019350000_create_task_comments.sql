@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL CHECK (body <> ''),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX task_comments_task_id_idx ON task_comments (task_id, created_at);
+
+ALTER TABLE task_comments ENABLE ROW LEVEL SECURITY;
+ALTER TABLE task_comments FORCE ROW LEVEL SECURITY;
+CREATE POLICY task_comments_tenant ON task_comments
+    USING (app_rls_bypass() OR app_can_access_task(task_id))
+    WITH CHECK (app_rls_bypass() OR (user_id = app_current_user() AND app_can_access_task(task_id)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE task_comments;
+-- +goose StatementEnd
@@ -0,0 +1,79 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A generated column can't look at other tables, so the search vector becomes
+-- a plain one kept up to date by triggers: on tasks when the name or
+-- description changes, and on comments whenever one is written or removed.
+-- Comments weigh least when ranking.
+CREATE FUNCTION task_search_vector(tid UUID, name TEXT, description TEXT) RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('english', COALESCE(name, '')), 'A')
+        || setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+        || setweight(to_tsvector('english', COALESCE(
+            (SELECT string_agg(body, E'\n') FROM task_comments WHERE task_id = tid), ''
+        )), 'C')
+$$ LANGUAGE sql STABLE SET app.bypass_rls = 'on';
+
+ALTER TABLE tasks ALTER COLUMN search_vector DROP EXPRESSION;
+
+CREATE FUNCTION update_task_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := task_search_vector(NEW.id, NEW.name, NEW.description);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_update_search_vector
+BEFORE INSERT OR UPDATE OF name, description ON tasks
+FOR EACH ROW EXECUTE FUNCTION update_task_search_vector();
+
+-- Commenters may only be able to read the task, hence the bypass.
+CREATE FUNCTION update_comment_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE tasks SET search_vector = task_search_vector(id, name, description)
+    WHERE id = COALESCE(NEW.task_id, OLD.task_id);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql SET app.bypass_rls = 'on';
+
+CREATE TRIGGER task_comments_update_search_vector
+AFTER INSERT OR UPDATE OF body OR DELETE ON task_comments
+FOR EACH ROW EXECUTE FUNCTION update_comment_search_vector();
+
+-- Reindexing a task for a comment isn't a change to the task itself, so it
+-- leaves the version, and with it the task's ETag, alone.
+CREATE OR REPLACE FUNCTION bump_task_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF to_jsonb(NEW) - 'search_vector' = to_jsonb(OLD) - 'search_vector' THEN
+        RETURN NEW;
+    END IF;
+
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION bump_task_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER task_comments_update_search_vector ON task_comments;
+DROP FUNCTION update_comment_search_vector();
+
+DROP TRIGGER tasks_update_search_vector ON tasks;
+DROP FUNCTION update_task_search_vector();
+DROP FUNCTION task_search_vector(UUID, TEXT, TEXT);
+
+DROP INDEX tasks_search_vector_idx;
+ALTER TABLE tasks DROP COLUMN search_vector;
+ALTER TABLE tasks ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(name, '')), 'A')
+    || setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+) STORED;
+CREATE INDEX tasks_search_vector_idx ON tasks USING GIN (search_vector);
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Replays need the headers that describe the result, such as ETag and
+-- Location, not just its content type.
+ALTER TABLE idempotency_keys ADD COLUMN headers JSONB;
+UPDATE idempotency_keys SET headers = jsonb_build_object('Content-Type', jsonb_build_array(content_type))
+WHERE content_type IS NOT NULL;
+ALTER TABLE idempotency_keys DROP COLUMN content_type;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys ADD COLUMN content_type TEXT;
+UPDATE idempotency_keys SET content_type = headers -> 'Content-Type' ->> 0;
+ALTER TABLE idempotency_keys DROP COLUMN headers;
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Handler reacts to an event published on a Bus.
+type Handler func(c context.Context, ev Event) error
+
+// Bus hands events to subscribers in the same process. It is a Sink, so it
+// sees exactly what the outbox dispatcher publishes, in the same order.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: map[string][]Handler{}}
+}
+
+// Subscribe registers h for events of the given type, or for every event
+// when eventType is "*".
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subs[eventType] = append(b.subs[eventType], h)
+}
+
+func (b *Bus) Name() string {
+	return "bus"
+}
+
+// Publish runs every matching handler, even after one fails, and reports all
+// their errors together.
+func (b *Bus) Publish(c context.Context, ev Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.subs[ev.Type]...), b.subs["*"]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h(c, ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,38 @@
+// Package events holds the domain events services record when they change
+// something, and the sinks the outbox dispatcher publishes them to.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Event is one recorded change. Events about the same aggregate (a task or a
+// user) are published in the order they happened; across aggregates there is
+// no ordering.
+//
+// Delivery is at least once: a sink can see an event again after a failure or
+// a restart, and should use ID to drop repeats.
+type Event struct {
+	ID          string `json:"id"`
+	Seq         int64  `json:"seq"`
+	Type        string `json:"type"`
+	AggregateID string `json:"aggregate_id"`
+	// WorkspaceIDs are the workspaces the event concerns and UserID the user
+	// it belongs to: a task's workspace and creator, or the user themselves.
+	WorkspaceIDs []string        `json:"workspace_ids"`
+	UserID       string          `json:"user_id"`
+	Data         json.RawMessage `json:"data"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// Sink is somewhere events get published. Publish returning an error means
+// the event will be offered again later, to every sink.
+//
+// A message broker such as NATS or Kafka plugs in here, publishing each event
+// under its Type with AggregateID as the partition or ordering key.
+type Sink interface {
+	Name() string
+	Publish(c context.Context, ev Event) error
+}
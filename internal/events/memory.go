@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemorySink keeps the most recent events it was given. It stands in for a
+// message broker in development and when trying the dispatcher out.
+type MemorySink struct {
+	mu     sync.Mutex
+	events []Event
+	limit  int
+}
+
+func NewMemorySink(limit int) *MemorySink {
+	return &MemorySink{limit: limit}
+}
+
+func (s *MemorySink) Name() string {
+	return "memory"
+}
+
+func (s *MemorySink) Publish(c context.Context, ev Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, ev)
+	if len(s.events) > s.limit {
+		s.events = s.events[len(s.events)-s.limit:]
+	}
+	return nil
+}
+
+// Events returns a copy of what the sink holds, oldest first.
+func (s *MemorySink) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Event{}, s.events...)
+}
+
+// NewSink builds the sink named by kind, as configured with EVENT_SINK. An
+// empty kind means no extra sink.
+func NewSink(kind string) (Sink, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case "memory":
+		return NewMemorySink(1000), nil
+	default:
+		return nil, fmt.Errorf("unknown event sink %q", kind)
+	}
+}
@@ -0,0 +1,410 @@
+// Package filter parses the task query language behind saved views, such as
+//
+//	status:IN_PROGRESS label:backend due<7d -assignee:me "release"
+//
+// Terms are ANDed together unless joined with OR; a leading - or NOT negates
+// a term and parentheses group them. Bare words and "quoted phrases" match
+// task text. Queries are checked as they are parsed, so a parsed query only
+// holds fields, operators and values the repository knows how to compile.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Error reports a problem with a query. Pos is the 1-based character position
+// it was found at.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+func newError(pos int, format string, args ...any) error {
+	return &Error{Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+type Op string
+
+const (
+	OpEq Op = ":"
+	OpLt Op = "<"
+	OpLe Op = "<="
+	OpGt Op = ">"
+	OpGe Op = ">="
+)
+
+type Field string
+
+const (
+	FieldText     Field = "text"
+	FieldStatus   Field = "status"
+	FieldLabel    Field = "label"
+	FieldAssignee Field = "assignee"
+	FieldOwner    Field = "owner"
+	FieldProject  Field = "project"
+	FieldDue      Field = "due"
+	FieldCreated  Field = "created"
+	FieldUpdated  Field = "updated"
+	FieldPoints   Field = "points"
+	FieldArchived Field = "archived"
+)
+
+// Node is a parsed query: an And, Or, Not or Cond.
+type Node interface {
+	node()
+}
+
+type And struct {
+	Terms []Node
+}
+
+type Or struct {
+	Terms []Node
+}
+
+type Not struct {
+	Term Node
+}
+
+// Cond is a single checked condition. Which of the value fields is set
+// depends on Field: Values for text, status and label; Value for assignee,
+// owner and project, with Me or None standing in for "me" and "none"; Time
+// for dates; Number for points; Bool for archived. None and Any on a date
+// or points condition test for a missing or present value.
+type Cond struct {
+	Pos    int
+	Field  Field
+	Op     Op
+	Values []string
+	Value  string
+	Me     bool
+	None   bool
+	Any    bool
+	Time   time.Time
+	Number int64
+	Bool   bool
+}
+
+func (And) node()  {}
+func (Or) node()   {}
+func (Not) node()  {}
+func (Cond) node() {}
+
+// Uses reports whether n has a condition on field.
+func Uses(n Node, field Field) bool {
+	switch n := n.(type) {
+	case And:
+		for _, t := range n.Terms {
+			if Uses(t, field) {
+				return true
+			}
+		}
+	case Or:
+		for _, t := range n.Terms {
+			if Uses(t, field) {
+				return true
+			}
+		}
+	case Not:
+		return Uses(n.Term, field)
+	case Cond:
+		return n.Field == field
+	}
+	return false
+}
+
+type parser struct {
+	lex *lexer
+	tok token
+	now time.Time
+}
+
+// Parse parses and checks query. Relative dates such as 7d are taken from now.
+func Parse(query string, now time.Time) (Node, error) {
+	p := &parser{lex: &lexer{src: []rune(query)}, now: now}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return nil, newError(0, "query is empty")
+	}
+
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, newError(p.tok.pos, "unexpected %s", p.tok.describe())
+	}
+
+	return n, nil
+}
+
+func (p *parser) advance() (err error) {
+	p.tok, err = p.lex.next()
+	return err
+}
+
+func (p *parser) or() (Node, error) {
+	n, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []Node{n}
+	for p.tok.kind == tokOr {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, n)
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return Or{Terms: terms}, nil
+}
+
+func (p *parser) and() (Node, error) {
+	n, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []Node{n}
+	for {
+		switch p.tok.kind {
+		case tokAnd:
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		case tokLParen, tokMinus, tokNot, tokText, tokField:
+		default:
+			if len(terms) == 1 {
+				return terms[0], nil
+			}
+			return And{Terms: terms}, nil
+		}
+
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, n)
+	}
+}
+
+func (p *parser) unary() (Node, error) {
+	if p.tok.kind != tokMinus && p.tok.kind != tokNot {
+		return p.primary()
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	n, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return Not{Term: n}, nil
+}
+
+func (p *parser) primary() (Node, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokLParen:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, newError(tok.pos, "unclosed (")
+		}
+		return n, p.advance()
+	case tokText:
+		if strings.TrimSpace(tok.value) == "" {
+			return nil, newError(tok.pos, "empty phrase")
+		}
+		return Cond{Pos: tok.pos + 1, Field: FieldText, Op: OpEq, Values: []string{tok.value}}, p.advance()
+	case tokField:
+		c, err := p.cond(tok)
+		if err != nil {
+			return nil, err
+		}
+		return c, p.advance()
+	case tokEOF:
+		return nil, newError(tok.pos, "expected a term at the end of the query")
+	default:
+		return nil, newError(tok.pos, "unexpected %s", tok.describe())
+	}
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokLParen:
+		return "("
+	case tokRParen:
+		return ")"
+	case tokMinus:
+		return "-"
+	case tokAnd:
+		return "AND"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "NOT"
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// cond checks a field term and turns it into a condition.
+func (p *parser) cond(tok token) (Cond, error) {
+	c := Cond{Pos: tok.pos + 1, Field: Field(tok.field), Op: tok.op}
+	if c.Op == "=" {
+		c.Op = OpEq
+	}
+	value := strings.TrimSpace(tok.value)
+	if value == "" {
+		return c, newError(tok.valuePos, "%s needs a value", tok.field)
+	}
+
+	badValue := func(format string, args ...any) error {
+		return newError(tok.valuePos, format, args...)
+	}
+	equalOnly := func() error {
+		if c.Op != OpEq {
+			return newError(tok.valuePos-len(c.Op), "%s only supports :", tok.field)
+		}
+		return nil
+	}
+
+	switch c.Field {
+	case FieldStatus, FieldLabel:
+		if err := equalOnly(); err != nil {
+			return c, err
+		}
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				c.Values = append(c.Values, v)
+			}
+		}
+		if len(c.Values) == 0 {
+			return c, badValue("%s needs a value", tok.field)
+		}
+
+	case FieldAssignee, FieldOwner, FieldProject:
+		if err := equalOnly(); err != nil {
+			return c, err
+		}
+		switch {
+		case value == "me" && c.Field != FieldProject:
+			c.Me = true
+		case value == "none" && c.Field != FieldOwner:
+			c.None = true
+		case c.Field == FieldProject:
+			if _, err := uuid.Parse(value); err != nil {
+				return c, badValue("project must be a project id or none")
+			}
+			c.Value = value
+		default:
+			// A user id or an email; the repository resolves which.
+			c.Value = value
+		}
+
+	case FieldDue, FieldCreated, FieldUpdated:
+		if c.Op == OpEq {
+			if c.Field != FieldDue || (value != "none" && value != "any") {
+				return c, newError(tok.valuePos-1, "%s needs a comparison such as %s<7d", tok.field, tok.field)
+			}
+			c.None, c.Any = value == "none", value == "any"
+			break
+		}
+		t, ok := parseTime(value, p.now)
+		if !ok {
+			return c, badValue("%q is not a date; use a date, a time, now, today or an offset such as 7d or -2w", value)
+		}
+		c.Time = t
+
+	case FieldPoints:
+		if c.Op == OpEq && (value == "none" || value == "any") {
+			c.None, c.Any = value == "none", value == "any"
+			break
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return c, badValue("points must be a whole number")
+		}
+		c.Number = n
+
+	case FieldArchived:
+		if err := equalOnly(); err != nil {
+			return c, err
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return c, badValue("archived must be true or false")
+		}
+		c.Bool = b
+
+	default:
+		return c, newError(tok.pos, "unknown field %q; use status, label, assignee, owner, project, due, created, updated, points or archived", tok.field)
+	}
+
+	return c, nil
+}
+
+var offset = regexp.MustCompile(`^([+-]?)(\d+)([hdw])$`)
+
+// parseTime reads an absolute date or time, now, today, or an offset from
+// now in hours, days or weeks.
+func parseTime(s string, now time.Time) (time.Time, bool) {
+	switch s {
+	case "now":
+		return now, true
+	case "today":
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), true
+	}
+
+	if m := offset.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return time.Time{}, false
+		}
+		unit := map[string]time.Duration{"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}[m[3]]
+		d := time.Duration(n) * unit
+		if m[1] == "-" {
+			d = -d
+		}
+		return now.Add(d), true
+	}
+
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,197 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// show writes n out with its structure explicit, leaving out positions.
+func show(n Node) string {
+	switch n := n.(type) {
+	case And:
+		return "and(" + showTerms(n.Terms) + ")"
+	case Or:
+		return "or(" + showTerms(n.Terms) + ")"
+	case Not:
+		return "not(" + show(n.Term) + ")"
+	case Cond:
+		var value string
+		switch {
+		case n.Me:
+			value = "me"
+		case n.None:
+			value = "none"
+		case n.Any:
+			value = "any"
+		case n.Values != nil:
+			value = strings.Join(n.Values, ",")
+		case n.Value != "":
+			value = n.Value
+		case !n.Time.IsZero():
+			value = n.Time.Format(time.RFC3339)
+		case n.Field == FieldPoints:
+			value = fmt.Sprint(n.Number)
+		case n.Field == FieldArchived:
+			value = fmt.Sprint(n.Bool)
+		}
+		return string(n.Field) + string(n.Op) + value
+	default:
+		return fmt.Sprintf("%T", n)
+	}
+}
+
+func showTerms(terms []Node) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = show(t)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestParseExample(t *testing.T) {
+	got, err := Parse(`status:IN_PROGRESS label:backend due<7d -assignee:me "release"`, now)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := And{Terms: []Node{
+		Cond{Pos: 1, Field: FieldStatus, Op: OpEq, Values: []string{"IN_PROGRESS"}},
+		Cond{Pos: 20, Field: FieldLabel, Op: OpEq, Values: []string{"backend"}},
+		Cond{Pos: 34, Field: FieldDue, Op: OpLt, Time: now.Add(7 * 24 * time.Hour)},
+		Not{Term: Cond{Pos: 42, Field: FieldAssignee, Op: OpEq, Me: true}},
+		Cond{Pos: 54, Field: FieldText, Op: OpEq, Values: []string{"release"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// Terms and values.
+		{`release`, `text:release`},
+		{`"release notes"`, `text:release notes`},
+		{`status:DONE,TO_DO`, `status:DONE,TO_DO`},
+		{`status=DONE`, `status:DONE`},
+		{`label:"needs review"`, `label:needs review`},
+		{`Label:backend`, `label:backend`},
+		{`assignee:none`, `assignee:none`},
+		{`owner:me`, `owner:me`},
+		{`owner:someone@example.com`, `owner:someone@example.com`},
+		{`project:none`, `project:none`},
+		{`project:6f1c1f8e-5b0a-4a59-9c55-6b7a3e1c2d4f`, `project:6f1c1f8e-5b0a-4a59-9c55-6b7a3e1c2d4f`},
+		{`due:none`, `due:none`},
+		{`due:any`, `due:any`},
+		{`due<=today`, `due<=2026-10-19T00:00:00Z`},
+		{`due>-2w`, `due>2026-10-05T12:00:00Z`},
+		{`created>=2026-01-02`, `created>=2026-01-02T00:00:00Z`},
+		{`updated<now`, `updated<2026-10-19T12:00:00Z`},
+		{`updated<12h`, `updated<2026-10-20T00:00:00Z`},
+		{`points>=3`, `points>=3`},
+		{`points:none`, `points:none`},
+		{`archived:true`, `archived:true`},
+		{`a-b`, `text:a-b`},
+
+		// Negation.
+		{`-status:DONE`, `not(status:DONE)`},
+		{`NOT status:DONE`, `not(status:DONE)`},
+		{`NOT -label:x`, `not(not(label:x))`},
+		{`-(a OR b)`, `not(or(text:a text:b))`},
+		{`a -b`, `and(text:a not(text:b))`},
+
+		// AND binds tighter than OR, whether written out or implied.
+		{`a b OR c`, `or(and(text:a text:b) text:c)`},
+		{`a OR b c`, `or(text:a and(text:b text:c))`},
+		{`a AND b OR c AND d`, `or(and(text:a text:b) and(text:c text:d))`},
+		{`a OR b OR c`, `or(text:a text:b text:c)`},
+		{`a (b OR c)`, `and(text:a or(text:b text:c))`},
+		{`-a OR b`, `or(not(text:a) text:b)`},
+		{`((a))`, `text:a`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			n, err := Parse(tt.query, now)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			if got := show(n); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{``, 1, "query is empty"},
+		{`   `, 1, "query is empty"},
+		{`(status:DONE`, 1, "unclosed ("},
+		{`label:x (a OR (b c)`, 9, "unclosed ("},
+		{`due=7d`, 4, "due needs a comparison such as due<7d"},
+		{`due:7d`, 4, "due needs a comparison such as due<7d"},
+		{`created:any`, 8, "created needs a comparison such as created<7d"},
+		{`due<soon`, 5, `"soon" is not a date`},
+		{`due<7x`, 5, `"7x" is not a date`},
+		{`status<DONE`, 7, "status only supports :"},
+		{`archived>=true`, 9, "archived only supports :"},
+		{`archived:maybe`, 10, "archived must be true or false"},
+		{`points>many`, 8, "points must be a whole number"},
+		{`project:backend`, 9, "project must be a project id or none"},
+		{`status:,`, 8, "status needs a value"},
+		{`status:`, 8, "expected a value after status:"},
+		{`colour:red`, 1, `unknown field "colour"`},
+		{`a :b`, 3, "expected a field name before"},
+		{`due<<7d`, 4, `unknown operator "<<"`},
+		{`a "release`, 3, "unterminated quote"},
+		{`a ""`, 3, "empty phrase"},
+		{`a OR`, 5, "expected a term at the end of the query"},
+		{`(a OR)`, 6, "unexpected )"},
+		{`a)`, 2, "unexpected )"},
+		{`OR a`, 1, "unexpected OR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			n, err := Parse(tt.query, now)
+			var fe *Error
+			if !errors.As(err, &fe) {
+				t.Fatalf("Parse(%q) = %s, %v; want an *Error", tt.query, show(n), err)
+			}
+			if fe.Pos != tt.pos || !strings.Contains(fe.Msg, tt.msg) {
+				t.Errorf("Parse(%q) error = %q at %d, want %q at %d", tt.query, fe.Msg, fe.Pos, tt.msg, tt.pos)
+			}
+		})
+	}
+}
+
+func TestUses(t *testing.T) {
+	n, err := Parse(`a OR -(status:DONE archived:true)`, now)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	for field, want := range map[Field]bool{
+		FieldText:     true,
+		FieldStatus:   true,
+		FieldArchived: true,
+		FieldLabel:    false,
+	} {
+		if got := Uses(n, field); got != want {
+			t.Errorf("Uses(%s) = %v, want %v", field, got, want)
+		}
+	}
+}
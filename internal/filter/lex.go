@@ -0,0 +1,150 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokLParen
+	tokRParen
+	tokMinus
+	tokAnd
+	tokOr
+	tokNot
+	// tokText is a bare word or a quoted phrase.
+	tokText
+	// tokField is field, operator and value, such as status:DONE or due<7d.
+	tokField
+)
+
+type token struct {
+	kind     tokenKind
+	pos      int
+	field    string
+	op       Op
+	value    string
+	valuePos int
+	quoted   bool
+}
+
+type lexer struct {
+	src []rune
+	i   int
+}
+
+func (l *lexer) errorf(pos int, format string, args ...any) error {
+	return newError(pos, format, args...)
+}
+
+func (l *lexer) peek() rune {
+	if l.i >= len(l.src) {
+		return 0
+	}
+	return l.src[l.i]
+}
+
+func isDelimiter(r rune) bool {
+	return r == 0 || unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+func isOperator(r rune) bool {
+	return r == ':' || r == '<' || r == '>' || r == '='
+}
+
+// next returns the token starting at the current position.
+func (l *lexer) next() (token, error) {
+	for unicode.IsSpace(l.peek()) {
+		l.i++
+	}
+
+	start := l.i
+	switch r := l.peek(); {
+	case r == 0:
+		return token{kind: tokEOF, pos: start}, nil
+	case r == '(':
+		l.i++
+		return token{kind: tokLParen, pos: start}, nil
+	case r == ')':
+		l.i++
+		return token{kind: tokRParen, pos: start}, nil
+	case r == '-' && l.i+1 < len(l.src) && !unicode.IsSpace(l.src[l.i+1]) && l.src[l.i+1] != ')':
+		l.i++
+		return token{kind: tokMinus, pos: start}, nil
+	case r == '"':
+		value, err := l.quoted()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokText, pos: start, value: value, valuePos: start, quoted: true}, nil
+	}
+
+	for !isDelimiter(l.peek()) && !isOperator(l.peek()) {
+		l.i++
+	}
+	word := string(l.src[start:l.i])
+
+	if !isOperator(l.peek()) {
+		switch word {
+		case "AND":
+			return token{kind: tokAnd, pos: start}, nil
+		case "OR":
+			return token{kind: tokOr, pos: start}, nil
+		case "NOT":
+			return token{kind: tokNot, pos: start}, nil
+		}
+		return token{kind: tokText, pos: start, value: word, valuePos: start}, nil
+	}
+
+	if word == "" {
+		return token{}, l.errorf(start, "expected a field name before %q", string(l.peek()))
+	}
+
+	opPos := l.i
+	op := string(l.peek())
+	l.i++
+	if (op == "<" || op == ">") && l.peek() == '=' {
+		op += "="
+		l.i++
+	}
+	if isOperator(l.peek()) {
+		return token{}, l.errorf(opPos, "unknown operator %q", op+string(l.peek()))
+	}
+
+	tok := token{kind: tokField, pos: start, field: strings.ToLower(word), op: Op(op), valuePos: l.i}
+	if l.peek() == '"' {
+		value, err := l.quoted()
+		if err != nil {
+			return token{}, err
+		}
+		tok.value, tok.quoted = value, true
+		return tok, nil
+	}
+
+	for !isDelimiter(l.peek()) {
+		l.i++
+	}
+	tok.value = string(l.src[tok.valuePos:l.i])
+	if tok.value == "" {
+		return token{}, l.errorf(tok.valuePos, "expected a value after %s%s", word, op)
+	}
+
+	return tok, nil
+}
+
+// quoted reads a double-quoted string. There are no escapes.
+func (l *lexer) quoted() (string, error) {
+	start := l.i
+	l.i++
+	for l.peek() != '"' {
+		if l.peek() == 0 {
+			return "", l.errorf(start, "unterminated quote")
+		}
+		l.i++
+	}
+	l.i++
+	return string(l.src[start+1 : l.i-1]), nil
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type ArchiveHandler struct {
+	archiveService *service.ArchiveService
+}
+
+func NewArchiveHandler(archiveService *service.ArchiveService) *ArchiveHandler {
+	return &ArchiveHandler{
+		archiveService: archiveService,
+	}
+}
+
+func (h *ArchiveHandler) Archive(c *gin.Context) {
+	res, err := h.archiveService.Archive(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ArchiveHandler) Unarchive(c *gin.Context) {
+	res, err := h.archiveService.Unarchive(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ArchiveHandler) ArchiveDone(c *gin.Context) {
+	var req model.RequestArchiveDone
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.archiveService.ArchiveDone(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ArchiveHandler) GetAutoArchive(c *gin.Context) {
+	res, err := h.archiveService.GetAutoArchive(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ArchiveHandler) SetAutoArchive(c *gin.Context) {
+	var req model.AutoArchiveSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.archiveService.SetAutoArchive(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type AssignmentHandler struct {
+	assignmentService *service.AssignmentService
+}
+
+func NewAssignmentHandler(assignmentService *service.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{
+		assignmentService: assignmentService,
+	}
+}
+
+func (h *AssignmentHandler) Assign(c *gin.Context) {
+	var req model.RequestAssignTask
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.assignmentService.Assign(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *AssignmentHandler) Unassign(c *gin.Context) {
+	if err := h.assignmentService.Unassign(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("userID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AssignmentHandler) GetAssignees(c *gin.Context) {
+	res, err := h.assignmentService.GetAssignees(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *AssignmentHandler) Watch(c *gin.Context) {
+	if err := h.assignmentService.Watch(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AssignmentHandler) Unwatch(c *gin.Context) {
+	if err := h.assignmentService.Unwatch(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AssignmentHandler) GetWatchers(c *gin.Context) {
+	res, err := h.assignmentService.GetWatchers(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
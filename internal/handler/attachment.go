@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type AttachmentHandler struct {
+	attachmentService *service.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	// Leave some headroom for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachmentService.MaxSize()+1<<20)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.attachmentService.Upload(c.Request.Context(), c.GetString("userID"), c.Param("id"), file)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	res, err := h.attachmentService.GetAttachments(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	a, blob, err := h.attachmentService.Open(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("attachmentID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Type", a.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("ETag", `"`+a.SHA256+`"`)
+
+	// ServeContent handles Range, If-Range and conditional GETs for us.
+	http.ServeContent(c.Writer, c.Request, a.Filename, a.CreatedAt, blob)
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	err := h.attachmentService.DeleteAttachment(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("attachmentID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+// The CalDAV subset served here is what task apps need to sync both ways:
+// PROPFIND to discover the calendars and list their tasks, the
+// calendar-query, calendar-multiget and sync-collection reports, and GET, PUT
+// and DELETE on the VTODO resources. Each workspace is one calendar.
+const (
+	davPrincipalHref = "/dav/principals/me/"
+	davHomeHref      = "/dav/calendars/"
+
+	// maxCalendarObjectBytes caps the size of a stored VTODO.
+	maxCalendarObjectBytes = 1 << 20
+)
+
+var (
+	davResourceType      = xml.Name{Space: davNS, Local: "resourcetype"}
+	davDisplayName       = xml.Name{Space: davNS, Local: "displayname"}
+	davPrincipal         = xml.Name{Space: davNS, Local: "current-user-principal"}
+	davPrincipalURL      = xml.Name{Space: davNS, Local: "principal-URL"}
+	davPrivileges        = xml.Name{Space: davNS, Local: "current-user-privilege-set"}
+	davReports           = xml.Name{Space: davNS, Local: "supported-report-set"}
+	davSyncToken         = xml.Name{Space: davNS, Local: "sync-token"}
+	davETag              = xml.Name{Space: davNS, Local: "getetag"}
+	davContentType       = xml.Name{Space: davNS, Local: "getcontenttype"}
+	davCalendarHome      = xml.Name{Space: caldavNS, Local: "calendar-home-set"}
+	davComponents        = xml.Name{Space: caldavNS, Local: "supported-calendar-component-set"}
+	davCalendarData      = xml.Name{Space: caldavNS, Local: "calendar-data"}
+	davCTag              = xml.Name{Space: calendarServerNS, Local: "getctag"}
+	davCalendarQuery     = xml.Name{Space: caldavNS, Local: "calendar-query"}
+	davCalendarMultiget  = xml.Name{Space: caldavNS, Local: "calendar-multiget"}
+	davSyncCollection    = xml.Name{Space: davNS, Local: "sync-collection"}
+	davCalendarObjectTyp = "text/calendar; charset=utf-8; component=VTODO"
+)
+
+type CalDAVHandler struct {
+	caldavService *service.CalDAVService
+}
+
+func NewCalDAVHandler(caldavService *service.CalDAVService) *CalDAVHandler {
+	return &CalDAVHandler{
+		caldavService: caldavService,
+	}
+}
+
+// WellKnown points clients that only know the server's address at the root.
+func (h *CalDAVHandler) WellKnown(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, "/dav/")
+}
+
+func (h *CalDAVHandler) Options(c *gin.Context) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Header("Allow", "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT")
+	c.Status(http.StatusOK)
+}
+
+// Root answers PROPFIND on /dav/ and on the principal, which is how clients
+// find their way to the calendars.
+func (h *CalDAVHandler) Root(c *gin.Context) {
+	req, ok := readDAVRequest(c)
+	if !ok {
+		return
+	}
+
+	href := "/dav/"
+	types := "<d:collection/>"
+	if strings.HasPrefix(c.Request.URL.Path, "/dav/principals") {
+		href, types = davPrincipalHref, "<d:principal/>"
+	}
+
+	props := davProps{
+		davResourceType: types,
+		davDisplayName:  xmlText(c.GetString("username")),
+		davPrincipal:    davHref(davPrincipalHref),
+		davPrincipalURL: davHref(davPrincipalHref),
+		davCalendarHome: davHref(davHomeHref),
+	}
+	writeMultistatus(c, []davResponse{props.response(href, req)}, "")
+}
+
+// Home answers PROPFIND on the calendar home, listing the calendars one level
+// down.
+func (h *CalDAVHandler) Home(c *gin.Context) {
+	req, ok := readDAVRequest(c)
+	if !ok {
+		return
+	}
+
+	props := davProps{
+		davResourceType: "<d:collection/>",
+		davPrincipal:    davHref(davPrincipalHref),
+	}
+	responses := []davResponse{props.response(davHomeHref, req)}
+
+	if davDepth(c) > 0 {
+		workspaces, err := h.caldavService.Calendars(c.Request.Context(), c.GetString("userID"))
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		for _, w := range workspaces {
+			props := davCalendarProps(w.Name, "")
+			delete(props, davSyncToken)
+			delete(props, davCTag)
+			responses = append(responses, props.response(davCalendarHref(w.ID), req))
+		}
+	}
+
+	writeMultistatus(c, responses, "")
+}
+
+// Calendar answers PROPFIND on a calendar, listing its tasks one level down.
+func (h *CalDAVHandler) Calendar(c *gin.Context) {
+	req, ok := readDAVRequest(c)
+	if !ok {
+		return
+	}
+
+	w, err := h.caldavService.Calendar(c.Request.Context(), c.GetString("userID"), c.GetString("workspaceID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.caldavService.SyncToken(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	href := davCalendarHref(w.ID)
+	responses := []davResponse{davCalendarProps(w.Name, token).response(href, req)}
+
+	if davDepth(c) > 0 {
+		objects, err := h.caldavService.Objects(c.Request.Context())
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		for i := range objects {
+			responses = append(responses, davObjectProps(&objects[i]).response(href+objects[i].ID+".ics", req))
+		}
+	}
+
+	writeMultistatus(c, responses, "")
+}
+
+// Report runs a calendar-query, calendar-multiget or sync-collection report
+// against a calendar. Queries return every task: the only component held is
+// VTODO, and finer filters are left for the client to apply.
+func (h *CalDAVHandler) Report(c *gin.Context) {
+	req, ok := readDAVRequest(c)
+	if !ok {
+		return
+	}
+
+	href := davCalendarHref(c.GetString("workspaceID"))
+	responses := []davResponse{}
+	var syncToken string
+
+	switch req.XMLName {
+	case davCalendarQuery:
+		if !req.Filter.matchesTodo() {
+			break
+		}
+		objects, err := h.caldavService.Objects(c.Request.Context())
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		for i := range objects {
+			responses = append(responses, davObjectProps(&objects[i]).response(href+objects[i].ID+".ics", req))
+		}
+
+	case davCalendarMultiget:
+		for _, target := range req.Hrefs {
+			dir, name := path.Split(target)
+			if dir != href {
+				responses = append(responses, davResponse{href: target, status: http.StatusNotFound})
+				continue
+			}
+
+			obj, err := h.caldavService.Object(c.Request.Context(), c.GetString("userID"), name)
+			if err != nil {
+				responses = append(responses, davResponse{href: target, status: errorStatus(err)})
+				continue
+			}
+			responses = append(responses, davObjectProps(obj).response(target, req))
+		}
+
+	case davSyncCollection:
+		objects, deleted, token, err := h.caldavService.Changes(c.Request.Context(), req.SyncToken)
+		if errors.Is(err, service.ErrInvalidSyncToken) {
+			writeDAVError(c, http.StatusForbidden, "<d:valid-sync-token/>")
+			return
+		}
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		for i := range objects {
+			responses = append(responses, davObjectProps(&objects[i]).response(href+objects[i].ID+".ics", req))
+		}
+		for _, id := range deleted {
+			responses = append(responses, davResponse{href: href + id + ".ics", status: http.StatusNotFound})
+		}
+		syncToken = token
+
+	default:
+		writeDAVError(c, http.StatusForbidden, "<d:supported-report/>")
+		return
+	}
+
+	writeMultistatus(c, responses, syncToken)
+}
+
+// PropfindObject answers PROPFIND on a single task.
+func (h *CalDAVHandler) PropfindObject(c *gin.Context) {
+	req, ok := readDAVRequest(c)
+	if !ok {
+		return
+	}
+
+	obj, err := h.caldavService.Object(c.Request.Context(), c.GetString("userID"), c.Param("object"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	writeMultistatus(c, []davResponse{davObjectProps(obj).response(c.Request.URL.Path, req)}, "")
+}
+
+func (h *CalDAVHandler) GetObject(c *gin.Context) {
+	obj, err := h.caldavService.Object(c.Request.Context(), c.GetString("userID"), c.Param("object"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", obj.ETag)
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.Data(http.StatusOK, davCalendarObjectTyp, obj.Data)
+}
+
+func (h *CalDAVHandler) PutObject(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxCalendarObjectBytes)
+
+	obj, created, err := h.caldavService.PutObject(c.Request.Context(), c.GetString("userID"), c.Param("object"), body, c.GetHeader("If-Match"), c.GetHeader("If-None-Match"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", obj.ETag)
+	if created {
+		c.Header("Location", davCalendarHref(c.GetString("workspaceID"))+obj.ID+".ics")
+		c.Status(http.StatusCreated)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CalDAVHandler) DeleteObject(c *gin.Context) {
+	if err := h.caldavService.DeleteObject(c.Request.Context(), c.GetString("userID"), c.Param("object"), c.GetHeader("If-Match")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func davCalendarHref(workspaceID string) string {
+	return davHomeHref + workspaceID + "/"
+}
+
+func davCalendarProps(name, token string) davProps {
+	return davProps{
+		davResourceType: "<d:collection/><c:calendar/>",
+		davDisplayName:  xmlText(name),
+		davPrincipal:    davHref(davPrincipalHref),
+		davComponents:   `<c:comp name="VTODO"/>`,
+		davPrivileges:   "<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>",
+		davReports: "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><d:sync-collection/></d:report></d:supported-report>",
+		davSyncToken: xmlText(token),
+		davCTag:      xmlText(token),
+	}
+}
+
+// davObjectProps are the properties of a task. Its data only goes out when
+// asked for by name, as reports do.
+func davObjectProps(obj *model.CalendarObject) davProps {
+	return davProps{
+		davResourceType: "",
+		davETag:         xmlText(obj.ETag),
+		davContentType:  davCalendarObjectTyp,
+		davCalendarData: xmlText(string(obj.Data)),
+	}
+}
+
+// davDepth reads the Depth header: 0 or 1, with infinity treated as 1.
+func davDepth(c *gin.Context) int {
+	if c.GetHeader("Depth") == "0" {
+		return 0
+	}
+	return 1
+}
+
+func davHref(href string) string {
+	return "<d:href>" + xmlText(href) + "</d:href>"
+}
+
+func xmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// readDAVRequest parses the XML body of a PROPFIND or REPORT. An empty body
+// asks for every property.
+func readDAVRequest(c *gin.Context) (*davRequest, bool) {
+	var req davRequest
+	err := xml.NewDecoder(io.LimitReader(c.Request.Body, maxCalendarObjectBytes)).Decode(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request body is not valid XML"})
+		return nil, false
+	}
+	return &req, true
+}
+
+func writeDAVError(c *gin.Context, status int, condition string) {
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Data(status, "application/xml; charset=utf-8",
+		[]byte(xml.Header+`<d:error xmlns:d="DAV:" xmlns:c="`+caldavNS+`">`+condition+`</d:error>`))
+}
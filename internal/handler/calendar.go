@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type CalendarHandler struct {
+	calendarService *service.CalendarService
+}
+
+func NewCalendarHandler(calendarService *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+	}
+}
+
+func (h *CalendarHandler) GetFeed(c *gin.Context) {
+	res, err := h.calendarService.GetFeed(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *CalendarHandler) RegenerateFeed(c *gin.Context) {
+	res, err := h.calendarService.RegenerateFeed(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *CalendarHandler) DeleteFeed(c *gin.Context) {
+	if err := h.calendarService.DeleteFeed(c.Request.Context(), c.GetString("userID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Feed serves the iCalendar file calendar apps subscribe to. The token comes
+// with an .ics extension, which some apps need to recognise the URL.
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	var req model.RequestCalendarFeed
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	body, err := h.calendarService.Feed(c.Request.Context(), token, req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="tasks.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body)
+}
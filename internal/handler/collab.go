@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	collabReadTimeout  = 75 * time.Second
+	collabWriteTimeout = 10 * time.Second
+	collabMaxMessage   = 4096
+
+	// collabCloseExpired is the close code sent when the access token runs
+	// out; the client should sign in again and reconnect.
+	collabCloseExpired = 4001
+)
+
+type CollabHandler struct {
+	collabService *service.CollabService
+	origin        string
+}
+
+// NewCollabHandler takes the origin of the web app. Browsers send the
+// access_token cookie with any page's WebSocket, so connections from other
+// origins are refused.
+func NewCollabHandler(collabService *service.CollabService, origin string) *CollabHandler {
+	return &CollabHandler{
+		collabService: collabService,
+		origin:        origin,
+	}
+}
+
+// Connect upgrades to the collaboration WebSocket; see model.CollabMessage
+// for what goes over it. The server pings every 20 seconds and closes
+// connections it hears nothing from for 75. When the access token expires the
+// client gets session_expired and the connection closes with code 4001.
+func (h *CollabHandler) Connect(c *gin.Context) {
+	userID := c.GetString("userID")
+	username := c.GetString("username")
+	expiresAt := c.GetTime("tokenExpiresAt")
+
+	server := websocket.Server{
+		Handshake: func(cfg *websocket.Config, r *http.Request) error {
+			if origin := r.Header.Get("Origin"); origin != "" && origin != h.origin {
+				return errors.New("origin not allowed")
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			h.serve(c.Request.Context(), ws, userID, username, expiresAt)
+		},
+	}
+
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *CollabHandler) serve(ctx context.Context, ws *websocket.Conn, userID, username string, expiresAt time.Time) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = collabMaxMessage
+
+	// The connection inherits the server's request timeouts; it sets its
+	// own from here on.
+	_ = ws.SetDeadline(time.Time{})
+
+	cl := h.collabService.Connect(ctx, userID, username)
+	defer h.collabService.Disconnect(ctx, cl)
+
+	closed := make(chan struct{})
+	go h.read(ctx, ws, cl, closed)
+
+	var expired <-chan time.Time
+	if !expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-cl.Done:
+			return
+		case <-closed:
+			return
+		case <-expired:
+			h.write(ws, model.CollabMessage{Type: "session_expired"})
+			_ = ws.WriteClose(collabCloseExpired)
+			return
+		case msg := <-cl.Send:
+			if err := h.write(ws, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *CollabHandler) write(ws *websocket.Conn, msg model.CollabMessage) error {
+	_ = ws.SetWriteDeadline(time.Now().Add(collabWriteTimeout))
+	return websocket.JSON.Send(ws, msg)
+}
+
+// read handles the client's messages until it goes away, then closes closed
+// to let the writer know.
+func (h *CollabHandler) read(ctx context.Context, ws *websocket.Conn, cl *service.CollabClient, closed chan struct{}) {
+	defer close(closed)
+
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(collabReadTimeout))
+
+		var msg model.CollabMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("CollabHandler.read - Connection closed: %v", err)
+			}
+			return
+		}
+
+		if err := h.collabService.Handle(ctx, cl, msg); err != nil {
+			select {
+			case cl.Send <- model.CollabMessage{Type: "error", Topic: msg.Topic, Error: err.Error()}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The XML side of WebDAV: just enough of the request bodies to know which
+// properties and resources a client asked for, and multistatus responses
+// written out by hand so the namespace prefixes stay the ones clients expect.
+const (
+	davNS            = "DAV:"
+	caldavNS         = "urn:ietf:params:xml:ns:caldav"
+	calendarServerNS = "http://calendarserver.org/ns/"
+)
+
+var davPrefixes = map[string]string{
+	davNS:            "d",
+	caldavNS:         "c",
+	calendarServerNS: "cs",
+}
+
+// davRequest is the body of a PROPFIND or REPORT. Which of the fields are set
+// depends on the element the body is.
+type davRequest struct {
+	XMLName   xml.Name
+	AllProp   *struct{}    `xml:"DAV: allprop"`
+	Prop      *davPropList `xml:"DAV: prop"`
+	Hrefs     []string     `xml:"DAV: href"`
+	SyncToken string       `xml:"DAV: sync-token"`
+	Filter    davFilter    `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// davPropList is a <prop> element naming the properties wanted.
+type davPropList struct {
+	Names []xml.Name
+}
+
+func (p *davPropList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			p.Names = append(p.Names, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type davFilter struct {
+	CompFilters []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type davCompFilter struct {
+	Name        string          `xml:"name,attr"`
+	CompFilters []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// matchesTodo reports whether a calendar-query filter can match a VTODO. No
+// filter, or one on the calendar alone, matches everything.
+func (f davFilter) matchesTodo() bool {
+	if len(f.CompFilters) == 0 {
+		return true
+	}
+
+	for _, cal := range f.CompFilters {
+		if !strings.EqualFold(cal.Name, "VCALENDAR") {
+			continue
+		}
+		if len(cal.CompFilters) == 0 {
+			return true
+		}
+		for _, comp := range cal.CompFilters {
+			if strings.EqualFold(comp.Name, "VTODO") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// davProps are the properties of a resource, as the XML that goes inside
+// each property's element.
+type davProps map[xml.Name]string
+
+type davProp struct {
+	name  xml.Name
+	value string
+}
+
+// davResponse is one <response> of a multistatus: either the properties of a
+// resource, or a status for the whole of it when status is set.
+type davResponse struct {
+	href    string
+	status  int
+	found   []davProp
+	missing []xml.Name
+}
+
+// response picks the properties req asked for. Without a <prop>, that is
+// all of them except the calendar data, which is only sent when asked for.
+func (p davProps) response(href string, req *davRequest) davResponse {
+	r := davResponse{href: href}
+
+	if req.Prop == nil {
+		for name, value := range p {
+			if name != davCalendarData {
+				r.found = append(r.found, davProp{name: name, value: value})
+			}
+		}
+		sort.Slice(r.found, func(i, j int) bool {
+			a, b := r.found[i].name, r.found[j].name
+			return a.Space < b.Space || (a.Space == b.Space && a.Local < b.Local)
+		})
+		return r
+	}
+
+	for _, name := range req.Prop.Names {
+		if value, ok := p[name]; ok {
+			r.found = append(r.found, davProp{name: name, value: value})
+		} else {
+			r.missing = append(r.missing, name)
+		}
+	}
+	return r
+}
+
+func writeMultistatus(c *gin.Context, responses []davResponse, syncToken string) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="` + caldavNS + `" xmlns:cs="` + calendarServerNS + `">`)
+
+	for _, r := range responses {
+		b.WriteString("<d:response>" + davHref(r.href))
+		if r.status != 0 {
+			b.WriteString(davStatus(r.status))
+		}
+		if len(r.found) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, p := range r.found {
+				writeDAVElement(&b, p.name, p.value)
+			}
+			b.WriteString("</d:prop>" + davStatus(http.StatusOK) + "</d:propstat>")
+		}
+		if len(r.missing) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, name := range r.missing {
+				writeDAVElement(&b, name, "")
+			}
+			b.WriteString("</d:prop>" + davStatus(http.StatusNotFound) + "</d:propstat>")
+		}
+		b.WriteString("</d:response>")
+	}
+
+	if syncToken != "" {
+		b.WriteString("<d:sync-token>" + xmlText(syncToken) + "</d:sync-token>")
+	}
+	b.WriteString("</d:multistatus>")
+
+	c.Header("DAV", "1, 3, calendar-access")
+	c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(b.String()))
+}
+
+// writeDAVElement writes a property element. Properties in namespaces we
+// don't know, which only ever come back empty, declare their own.
+func writeDAVElement(b *strings.Builder, name xml.Name, value string) {
+	tag, decl := davPrefixes[name.Space]+":"+name.Local, ""
+	if _, ok := davPrefixes[name.Space]; !ok {
+		tag, decl = "x:"+name.Local, ` xmlns:x="`+xmlText(name.Space)+`"`
+	}
+
+	if value == "" {
+		b.WriteString("<" + tag + decl + "/>")
+		return
+	}
+	b.WriteString("<" + tag + decl + ">" + value + "</" + tag + ">")
+}
+
+func davStatus(code int) string {
+	return fmt.Sprintf("<d:status>HTTP/1.1 %d %s</d:status>", code, http.StatusText(code))
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/filter"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+func errorStatus(err error) int {
+	var filterErr *filter.Error
+
+	switch {
+	case errors.Is(err, service.ErrInvalidRequest),
+		errors.As(err, &filterErr):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrTaskNotFound),
+		errors.Is(err, repository.ErrTimeEntryNotFound),
+		errors.Is(err, repository.ErrNoRunningTimer),
+		errors.Is(err, repository.ErrNotAssigned),
+		errors.Is(err, service.ErrAttachmentNotFound),
+		errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, repository.ErrWorkspaceNotFound),
+		errors.Is(err, repository.ErrMemberNotFound),
+		errors.Is(err, repository.ErrInvitationNotFound),
+		errors.Is(err, repository.ErrProjectNotFound),
+		errors.Is(err, repository.ErrShareNotFound),
+		errors.Is(err, repository.ErrShareLinkNotFound),
+		errors.Is(err, repository.ErrEventNotFound),
+		errors.Is(err, repository.ErrViewNotFound),
+		errors.Is(err, repository.ErrWebhookNotFound),
+		errors.Is(err, repository.ErrDeliveryNotFound),
+		errors.Is(err, repository.ErrCalendarFeedNotFound),
+		errors.Is(err, repository.ErrImportJobNotFound),
+		errors.Is(err, repository.ErrAppPasswordNotFound),
+		errors.Is(err, repository.ErrCommentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrPasswordRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrForbidden),
+		errors.Is(err, service.ErrWorkspaceForbidden),
+		errors.Is(err, service.ErrViewForbidden),
+		errors.Is(err, service.ErrInvalidSyncToken):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrTimerRunning),
+		errors.Is(err, repository.ErrAlreadyMember),
+		errors.Is(err, service.ErrLastOwner),
+		errors.Is(err, repository.ErrViewNameTaken):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrVersionConflict),
+		errors.Is(err, service.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, repository.ErrInvitationInvalid):
+		return http.StatusGone
+	case errors.Is(err, service.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, repository.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorBody is the JSON error response for err. Filter query errors also say
+// where in the query the problem is.
+func errorBody(err error) gin.H {
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		return gin.H{"error": filterErr.Error(), "position": filterErr.Pos}
+	}
+	return gin.H{"error": err.Error()}
+}
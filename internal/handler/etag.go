@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag renders a task version as a strong entity tag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatch reads the task versions a client accepts from the If-Match header,
+// which may list several entity tags. It returns no versions when the header
+// is missing or "*", and ok is false when no tag in it can match. If-Match
+// uses strong comparison, so weak tags never match, and neither do tags that
+// aren't versions we hand out.
+func ifMatch(c *gin.Context) (versions []int64, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") || len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+
+		v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, len(versions) > 0
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type ImportHandler struct {
+	importService *service.ImportService
+}
+
+func NewImportHandler(importService *service.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+func (h *ImportHandler) Import(c *gin.Context) {
+	// Leave some headroom for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxImportBytes+1<<20)
+
+	var req model.RequestImport
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	res, err := h.importService.Import(c.Request.Context(), c.GetString("userID"), req, file)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case res.JobID != "":
+		c.Header("Location", c.Request.URL.Path+"/"+res.JobID)
+		c.JSON(http.StatusAccepted, res)
+	case res.Status == "rejected":
+		c.JSON(http.StatusUnprocessableEntity, res)
+	case res.Status == "imported":
+		c.JSON(http.StatusCreated, res)
+	default:
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+func (h *ImportHandler) GetJob(c *gin.Context) {
+	res, err := h.importService.GetJob(c.Request.Context(), c.GetString("userID"), c.Param("jobID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
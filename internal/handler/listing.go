@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+// taskFields are the JSON names a fields= parameter may pick from.
+var taskFields = func() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(repository.Task{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// writeTaskPage responds with one page of a task listing. The next page, if
+// any, is linked from a Link header, and fields= trims each task down to the
+// named fields.
+func writeTaskPage(c *gin.Context, tasks []repository.Task, next string) {
+	if next != "" {
+		u := *c.Request.URL
+		q := u.Query()
+		q.Set("cursor", next)
+		u.RawQuery = q.Encode()
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+	}
+
+	fields := c.Query("fields")
+	if fields == "" {
+		c.JSON(http.StatusOK, tasks)
+		return
+	}
+
+	keep := map[string]bool{}
+	for _, f := range strings.Split(fields, ",") {
+		f = strings.TrimSpace(f)
+		if !taskFields[f] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%v: unknown field %q", service.ErrInvalidRequest, f)})
+			return
+		}
+		keep[f] = true
+	}
+
+	sparse := make([]map[string]json.RawMessage, 0, len(tasks))
+	for _, t := range tasks {
+		b, err := json.Marshal(t)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		for k := range all {
+			if !keep[k] {
+				delete(all, k)
+			}
+		}
+		sparse = append(sparse, all)
+	}
+
+	c.JSON(http.StatusOK, sparse)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type ProjectHandler struct {
+	projectService *service.ProjectService
+}
+
+func NewProjectHandler(projectService *service.ProjectService) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: projectService,
+	}
+}
+
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	var req model.RequestCreateProject
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.projectService.CreateProject(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *ProjectHandler) GetProjects(c *gin.Context) {
+	res, err := h.projectService.GetProjects(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	res, err := h.projectService.GetProject(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	var req model.RequestUpdateProject
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.projectService.UpdateProject(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	if err := h.projectService.DeleteProject(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type ShareHandler struct {
+	shareService *service.ShareService
+}
+
+func NewShareHandler(shareService *service.ShareService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+	}
+}
+
+func (h *ShareHandler) ShareTask(c *gin.Context) {
+	var req model.RequestShare
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.shareService.ShareTask(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ShareHandler) UnshareTask(c *gin.Context) {
+	if err := h.shareService.UnshareTask(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("userID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ShareHandler) GetTaskShares(c *gin.Context) {
+	res, err := h.shareService.GetTaskShares(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ShareHandler) CreateTaskLink(c *gin.Context) {
+	var req model.RequestCreateShareLink
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.shareService.CreateTaskLink(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *ShareHandler) GetTaskLinks(c *gin.Context) {
+	res, err := h.shareService.GetTaskLinks(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ShareHandler) RevokeTaskLink(c *gin.Context) {
+	if err := h.shareService.RevokeTaskLink(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("linkID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ShareHandler) ShareProject(c *gin.Context) {
+	var req model.RequestShare
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.shareService.ShareProject(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ShareHandler) UnshareProject(c *gin.Context) {
+	if err := h.shareService.UnshareProject(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID"), c.Param("userID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ShareHandler) GetProjectShares(c *gin.Context) {
+	res, err := h.shareService.GetProjectShares(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ShareHandler) CreateProjectLink(c *gin.Context) {
+	var req model.RequestCreateShareLink
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.shareService.CreateProjectLink(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *ShareHandler) GetProjectLinks(c *gin.Context) {
+	res, err := h.shareService.GetProjectLinks(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ShareHandler) RevokeProjectLink(c *gin.Context) {
+	if err := h.shareService.RevokeProjectLink(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("projectID"), c.Param("linkID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// OpenLink serves the public, read-only view behind a share link. Password
+// protected links take the password in the X-Share-Password header so it
+// stays out of URLs and access logs.
+func (h *ShareHandler) OpenLink(c *gin.Context) {
+	res, err := h.shareService.OpenLink(c.Request.Context(), c.Param("token"), c.GetHeader("X-Share-Password"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+type StreamHandler struct {
+	streamService *service.StreamService
+}
+
+func NewStreamHandler(streamService *service.StreamService) *StreamHandler {
+	return &StreamHandler{
+		streamService: streamService,
+	}
+}
+
+// Stream pushes task.created, task.updated and task.deleted events for the
+// caller's tasks as Server-Sent Events. Each event's id can be sent back as
+// Last-Event-ID (or the last_event_id query param) to resume after it; a
+// "reset" event means some events were missed and the client should reload.
+// A comment line goes out every 15 seconds to keep the connection open.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+
+	var after int64
+	if lastID != "" {
+		n, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID must be an event id"})
+			return
+		}
+		after = n
+	}
+
+	ctx := c.Request.Context()
+
+	// Subscribe before replaying so nothing falls between the two.
+	sub := h.streamService.Subscribe(ctx, c.GetString("userID"))
+	defer h.streamService.Unsubscribe(sub)
+
+	var backlog []repository.TaskStreamEvent
+	complete := true
+	if lastID != "" {
+		var err error
+		backlog, complete, err = h.streamService.Replay(ctx, sub, after)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// The stream outlives the server's write timeout.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range backlog {
+		writeStreamEvent(c, e)
+		after = e.ID
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Dropped:
+			return
+		case e := <-sub.C:
+			if e.ID <= after {
+				continue
+			}
+			writeStreamEvent(c, e)
+			after = e.ID
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeStreamEvent(c *gin.Context, e repository.TaskStreamEvent) {
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Event, e.Data)
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/0xrishabk/tasktracker/internal/model"
@@ -26,34 +29,45 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		return
 	}
 
+	// Tasks are always created as the signed-in caller.
+	req.UserID = c.GetString("userID")
+
 	res, err := h.taskService.CreateTask(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", etag(res.Version))
 	c.JSON(http.StatusCreated, res)
 }
 
 func (h *TaskHandler) GetAllTasks(c *gin.Context) {
-	t, err := h.taskService.GetTasks(c.Request.Context())
+	var req model.RequestListTasks
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, next, err := h.taskService.GetTasks(c.Request.Context(), c.Query("project_id"), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, t)
+	writeTaskPage(c, t, next)
 }
 
 func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 	tid := c.Param("id")
 
-	t, err := h.taskService.GetTaskByID(c.Request.Context(), tid)
+	t, err := h.taskService.GetTaskByID(c.Request.Context(), c.GetString("userID"), tid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", etag(t.Version))
 	c.JSON(http.StatusOK, t)
 }
 
@@ -65,22 +79,102 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		return
 	}
 
+	var req model.RequestListTasks
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var (
-		t   []repository.Task
-		err error
+		t    []repository.Task
+		next string
+		err  error
 	)
 
 	if uid != "" {
-		t, err = h.taskService.GetTasksByUserID(c.Request.Context(), uid)
+		t, next, err = h.taskService.GetTasksByUserID(c.Request.Context(), uid, req)
 	} else {
-		t, err = h.taskService.GetTasksByEmail(c.Request.Context(), email)
+		t, next, err = h.taskService.GetTasksByEmail(c.Request.Context(), email, req)
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, t)
+	writeTaskPage(c, t, next)
+}
+
+func (h *TaskHandler) GetMyTasks(c *gin.Context) {
+	var req model.RequestListTasks
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, next, err := h.taskService.GetMyTasks(c.Request.Context(), c.GetString("userID"), c.Query("filter"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	writeTaskPage(c, t, next)
+}
+
+func (h *TaskHandler) Filter(c *gin.Context) {
+	var req model.RequestListTasks
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, next, err := h.taskService.FilterTasks(c.Request.Context(), c.GetString("userID"), c.Query("q"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
+		return
+	}
+
+	writeTaskPage(c, t, next)
+}
+
+// Export streams every task the caller can see that matches the listing
+// parameters and q, as a file to download.
+func (h *TaskHandler) Export(c *gin.Context) {
+	var req model.RequestExport
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	export, err := h.taskService.Export(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": export.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	// The response is under way by now, so a failure can only cut it short;
+	// the service logs it.
+	_ = export.Write(c.Request.Context(), c.Writer)
+}
+
+func (h *TaskHandler) Search(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a number"})
+		return
+	}
+
+	res, err := h.taskService.Search(c.Request.Context(), c.GetString("userID"), c.Query("q"), c.Query("archived"), limit)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
 }
 
 func (h *TaskHandler) UpdateTaskDetails(c *gin.Context) {
@@ -90,13 +184,42 @@ func (h *TaskHandler) UpdateTaskDetails(c *gin.Context) {
 		return
 	}
 
+	versions, ok := ifMatch(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": repository.ErrVersionConflict.Error()})
+		return
+	}
+
 	tid := c.Param("id")
-	res, err := h.taskService.UpdateTaskDetails(c.Request.Context(), tid, &req)
+
+	var version *int64
+	switch len(versions) {
+	case 0:
+	case 1:
+		version = &versions[0]
+	default:
+		// With several tags to choose from, pin the update to the current
+		// version when it is one of them, so that it still fails if the task
+		// changes in between.
+		t, err := h.taskService.GetTaskByID(c.Request.Context(), c.GetString("userID"), tid)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		if !slices.Contains(versions, t.Version) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": repository.ErrVersionConflict.Error()})
+			return
+		}
+		version = &t.Version
+	}
+
+	res, err := h.taskService.UpdateTaskDetails(c.Request.Context(), c.GetString("userID"), tid, &req, version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", etag(res.Version))
 	c.JSON(http.StatusOK, res)
 }
 
@@ -107,10 +230,211 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 		return
 	}
 
-	if err := h.taskService.DeleteTask(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.taskService.DeleteTask(c.Request.Context(), c.GetString("userID"), id); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusNoContent, gin.H{"message": "Deleted"})
 }
+
+func (h *TaskHandler) GetEstimate(c *gin.Context) {
+	res, err := h.taskService.GetEstimate(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) GetEstimateSummary(c *gin.Context) {
+	res, err := h.taskService.GetEstimateSummary(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) GetHistory(c *gin.Context) {
+	res, err := h.taskService.GetHistory(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) Revert(c *gin.Context) {
+	var req model.RequestRevertTask
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.taskService.Revert(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) Bulk(c *gin.Context) {
+	var req model.RequestBulk
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.taskService.Bulk(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range res.Results {
+		r := &res.Results[i]
+		switch {
+		case r.Err != nil:
+			r.Code, r.Error = errorStatus(r.Err), r.Err.Error()
+		case r.Status == "ok" && r.Op == "create":
+			r.Code = http.StatusCreated
+		case r.Status == "ok":
+			r.Code = http.StatusOK
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Sync hands out the task changes since the token in the query string; with
+// no token it starts a full sync.
+func (h *TaskHandler) Sync(c *gin.Context) {
+	res, err := h.taskService.Sync(c.Request.Context(), c.GetString("userID"), c.Query("token"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) PushChanges(c *gin.Context) {
+	var req model.RequestSyncPush
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.taskService.PushChanges(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	for i := range res.Results {
+		r := &res.Results[i]
+		switch {
+		case r.Err != nil:
+			r.Code, r.Error = errorStatus(r.Err), r.Err.Error()
+		case r.Status == "conflict":
+			r.Code = http.StatusConflict
+		default:
+			r.Code = http.StatusOK
+		}
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) GetLabels(c *gin.Context) {
+	res, err := h.taskService.GetLabels(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) AddLabels(c *gin.Context) {
+	var req model.RequestLabels
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.taskService.AddLabels(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.Labels)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) RemoveLabel(c *gin.Context) {
+	res, err := h.taskService.RemoveLabels(c.Request.Context(), c.GetString("userID"), c.Param("id"), []string{c.Param("label")})
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) GetComments(c *gin.Context) {
+	res, err := h.taskService.GetComments(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) AddComment(c *gin.Context) {
+	var req model.RequestComment
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.taskService.AddComment(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.Body)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *TaskHandler) UpdateComment(c *gin.Context) {
+	var req model.RequestComment
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.taskService.UpdateComment(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("commentID"), req.Body)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TaskHandler) DeleteComment(c *gin.Context) {
+	if err := h.taskService.DeleteComment(c.Request.Context(), c.GetString("userID"), c.Param("id"), c.Param("commentID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
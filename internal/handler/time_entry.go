@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type TimeEntryHandler struct {
+	timeEntryService *service.TimeEntryService
+}
+
+func NewTimeEntryHandler(timeEntryService *service.TimeEntryService) *TimeEntryHandler {
+	return &TimeEntryHandler{
+		timeEntryService: timeEntryService,
+	}
+}
+
+func (h *TimeEntryHandler) StartTimer(c *gin.Context) {
+	var req model.RequestStartTimer
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	res, err := h.timeEntryService.StartTimer(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *TimeEntryHandler) StopTimer(c *gin.Context) {
+	res, err := h.timeEntryService.StopTimer(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TimeEntryHandler) GetRunningTimer(c *gin.Context) {
+	res, err := h.timeEntryService.GetRunningTimer(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TimeEntryHandler) CreateEntry(c *gin.Context) {
+	var req model.RequestCreateTimeEntry
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.timeEntryService.CreateEntry(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *TimeEntryHandler) GetTaskTime(c *gin.Context) {
+	entries, total, err := h.timeEntryService.GetTaskTime(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "total_seconds": total})
+}
+
+func (h *TimeEntryHandler) UpdateEntry(c *gin.Context) {
+	var req model.RequestUpdateTimeEntry
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.timeEntryService.UpdateEntry(c.Request.Context(), c.GetString("userID"), c.Param("entryID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TimeEntryHandler) DeleteEntry(c *gin.Context) {
+	if err := h.timeEntryService.DeleteEntry(c.Request.Context(), c.GetString("userID"), c.Param("entryID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TimeEntryHandler) GetTotals(c *gin.Context) {
+	res, err := h.timeEntryService.GetTotals(
+		c.Request.Context(),
+		c.GetString("userID"),
+		c.Query("group_by"),
+		c.Query("from"),
+		c.Query("to"),
+		c.Query("tz"),
+	)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *TimeEntryHandler) GetTimesheet(c *gin.Context) {
+	sheet, err := h.timeEntryService.GetTimesheet(c.Request.Context(), c.GetString("userID"), c.Query("week"), c.Query("tz"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, sheet)
+	case "csv":
+		writeTimesheetCSV(c, sheet)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+	}
+}
+
+// writeTimesheetCSV renders the timesheet in decimal hours, which is what
+// billing spreadsheets expect.
+func writeTimesheetCSV(c *gin.Context, sheet *model.ResponseTimesheet) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="timesheet-%s.csv"`, sheet.WeekStart))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+
+	header := append([]string{"task_id", "task"}, sheet.Days...)
+	w.Write(append(header, "total"))
+
+	for _, row := range sheet.Rows {
+		record := []string{row.TaskID, row.TaskName}
+		for _, s := range row.Seconds {
+			record = append(record, hours(s))
+		}
+		w.Write(append(record, hours(row.TotalSeconds)))
+	}
+
+	totals := []string{"", "total"}
+	for _, s := range sheet.DayTotals {
+		totals = append(totals, hours(s))
+	}
+	w.Write(append(totals, hours(sheet.TotalSeconds)))
+
+	w.Flush()
+}
+
+func hours(seconds int64) string {
+	return strconv.FormatFloat(float64(seconds)/3600, 'f', 2, 64)
+}
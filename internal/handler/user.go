@@ -128,3 +128,38 @@ func (h *UserHandler) Delete(c *gin.Context) {
 
 	c.Status(http.StatusOK)
 }
+
+func (h *UserHandler) CreateAppPassword(c *gin.Context) {
+	var req model.RequestCreateAppPassword
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.userService.CreateAppPassword(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *UserHandler) GetAppPasswords(c *gin.Context) {
+	res, err := h.userService.GetAppPasswords(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *UserHandler) DeleteAppPassword(c *gin.Context) {
+	if err := h.userService.DeleteAppPassword(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type ViewHandler struct {
+	viewService *service.ViewService
+}
+
+func NewViewHandler(viewService *service.ViewService) *ViewHandler {
+	return &ViewHandler{
+		viewService: viewService,
+	}
+}
+
+func (h *ViewHandler) CreateView(c *gin.Context) {
+	var req model.RequestSavedView
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.viewService.CreateView(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *ViewHandler) GetViews(c *gin.Context) {
+	res, err := h.viewService.GetViews(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ViewHandler) GetView(c *gin.Context) {
+	res, err := h.viewService.GetView(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ViewHandler) UpdateView(c *gin.Context) {
+	var req model.RequestSavedView
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.viewService.UpdateView(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ViewHandler) DeleteView(c *gin.Context) {
+	if err := h.viewService.DeleteView(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ViewHandler) GetViewTasks(c *gin.Context) {
+	var req model.RequestListTasks
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, next, err := h.viewService.GetViewTasks(c.Request.Context(), c.GetString("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
+		return
+	}
+
+	writeTaskPage(c, t, next)
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+type WorkspaceHandler struct {
+	workspaceService *service.WorkspaceService
+}
+
+func NewWorkspaceHandler(workspaceService *service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceService: workspaceService,
+	}
+}
+
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	var req model.RequestCreateWorkspace
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.workspaceService.CreateWorkspace(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *WorkspaceHandler) GetWorkspaces(c *gin.Context) {
+	res, err := h.workspaceService.GetWorkspaces(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	res, err := h.workspaceService.GetWorkspace(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *WorkspaceHandler) UpdateWorkspace(c *gin.Context) {
+	var req model.RequestUpdateWorkspace
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.workspaceService.UpdateWorkspace(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *WorkspaceHandler) DeleteWorkspace(c *gin.Context) {
+	if err := h.workspaceService.DeleteWorkspace(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) GetMembers(c *gin.Context) {
+	res, err := h.workspaceService.GetMembers(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *WorkspaceHandler) UpdateMemberRole(c *gin.Context) {
+	var req model.RequestUpdateMemberRole
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.workspaceService.UpdateMemberRole(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	if err := h.workspaceService.RemoveMember(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("userID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) Invite(c *gin.Context) {
+	var req model.RequestInviteMember
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.workspaceService.Invite(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *WorkspaceHandler) GetInvitations(c *gin.Context) {
+	res, err := h.workspaceService.GetInvitations(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *WorkspaceHandler) RevokeInvitation(c *gin.Context) {
+	if err := h.workspaceService.RevokeInvitation(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"), c.Param("invitationID")); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WorkspaceHandler) AcceptInvitation(c *gin.Context) {
+	var req model.RequestAcceptInvitation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.workspaceService.AcceptInvitation(c.Request.Context(), c.GetString("userID"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
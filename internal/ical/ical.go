@@ -0,0 +1,123 @@
+// Package ical writes iCalendar (RFC 5545) data: the content lines, their
+// escaping and folding, and the date and time formats.
+package ical
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLine is how many octets a content line may hold before it is folded,
+// not counting the line break.
+const maxLine = 75
+
+const (
+	utcFormat  = "20060102T150405Z"
+	dateFormat = "20060102"
+)
+
+// Writer writes content lines. The first write error sticks and is reported
+// by Err; every later write is skipped.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Err() error {
+	return w.err
+}
+
+func (w *Writer) Begin(component string) {
+	w.Prop("BEGIN", component)
+}
+
+func (w *Writer) End(component string) {
+	w.Prop("END", component)
+}
+
+// Prop writes a property whose value is already in iCalendar form. Params are
+// written as given, e.g. "VALUE=DATE".
+func (w *Writer) Prop(name, value string, params ...string) {
+	if w.err != nil {
+		return
+	}
+
+	line := name
+	for _, p := range params {
+		line += ";" + p
+	}
+	line += ":" + value
+
+	_, w.err = io.WriteString(w.w, fold(line))
+}
+
+// Text writes a TEXT property, escaped. Empty values are left out.
+func (w *Writer) Text(name, value string) {
+	if value != "" {
+		w.Prop(name, EscapeText(value))
+	}
+}
+
+// TextList writes a property holding a list of TEXT values, such as
+// CATEGORIES. An empty list is left out.
+func (w *Writer) TextList(name string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = EscapeText(v)
+	}
+	w.Prop(name, strings.Join(escaped, ","))
+}
+
+// Time writes a DATE-TIME property in UTC.
+func (w *Writer) Time(name string, t time.Time) {
+	w.Prop(name, t.UTC().Format(utcFormat))
+}
+
+// Date writes a DATE property for the day t falls on in its own location.
+func (w *Writer) Date(name string, t time.Time) {
+	w.Prop(name, t.Format(dateFormat), "VALUE=DATE")
+}
+
+// EscapeText escapes a TEXT value.
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", "",
+)
+
+// fold breaks a content line into lines of at most maxLine octets, each one
+// after the first starting with a space, without splitting a character.
+func fold(line string) string {
+	var b strings.Builder
+	limit := maxLine
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLine - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
@@ -0,0 +1,245 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrMalformed = errors.New("malformed iCalendar data")
+
+// Component is a parsed BEGIN/END block, such as a VCALENDAR or a VTODO.
+type Component struct {
+	Name     string
+	Props    []Property
+	Children []*Component
+}
+
+// Property is one content line. Param names are upper-cased; Value is left
+// as it was sent, escapes and all.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Prop returns the first property with the given name, or nil.
+func (c *Component) Prop(name string) *Property {
+	for i := range c.Props {
+		if c.Props[i].Name == name {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// Child returns the first child component with the given name, or nil.
+func (c *Component) Child(name string) *Component {
+	for _, child := range c.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Text returns the unescaped value of a TEXT property, or "" if c lacks it.
+func (c *Component) Text(name string) string {
+	if p := c.Prop(name); p != nil {
+		return UnescapeText(p.Value)
+	}
+	return ""
+}
+
+// TextList returns the values of every property with the given name that
+// holds a list of TEXT values, such as CATEGORIES.
+func (c *Component) TextList(name string) []string {
+	var values []string
+	for _, p := range c.Props {
+		if p.Name != name {
+			continue
+		}
+		for _, v := range splitList(p.Value) {
+			if v = UnescapeText(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// Time reads a DATE or DATE-TIME property. Times with a TZID are read in that
+// zone when it is a known IANA zone; floating times, dates and times in zones
+// we don't know are taken as UTC. dateOnly reports a DATE value.
+func (p *Property) Time() (t time.Time, dateOnly bool, err error) {
+	if p.Params["VALUE"] == "DATE" || len(p.Value) == len(dateFormat) {
+		t, err = time.Parse(dateFormat, p.Value)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(p.Value, "Z") {
+		t, err = time.Parse(utcFormat, p.Value)
+		return t, false, err
+	}
+
+	loc := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = l
+		}
+	}
+	t, err = time.ParseInLocation(strings.TrimSuffix(utcFormat, "Z"), p.Value, loc)
+	return t, false, err
+}
+
+// Parse reads a single top-level component, usually a VCALENDAR.
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for _, line := range lines {
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch p.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(p.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, c)
+			} else if root != nil {
+				return nil, fmt.Errorf("%w: more than one top-level component", ErrMalformed)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("%w: unexpected END:%s", ErrMalformed, p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: property outside a component", ErrMalformed)
+			}
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, p)
+		}
+	}
+
+	if root == nil || len(stack) > 0 {
+		return nil, fmt.Errorf("%w: unterminated component", ErrMalformed)
+	}
+	return root, nil
+}
+
+// unfold joins folded lines back into whole content lines.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		switch {
+		case line == "":
+		case (line[0] == ' ' || line[0] == '\t') && len(lines) > 0:
+			lines[len(lines)-1] += line[1:]
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits a content line into its name, params and value. Param
+// values may be quoted, in which case they can hold ';', ':' and ','.
+func parseLine(line string) (Property, error) {
+	p := Property{Params: map[string]string{}}
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return p, fmt.Errorf("%w: %q", ErrMalformed, line)
+	}
+	p.Name = strings.ToUpper(line[:i])
+
+	for line[i] == ';' {
+		line = line[i+1:]
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return p, fmt.Errorf("%w: bad parameter in %s", ErrMalformed, p.Name)
+		}
+		name := strings.ToUpper(line[:eq])
+		line = line[eq+1:]
+
+		// Only the first of several values is kept; nothing we read takes more.
+		skip := 0
+		if strings.HasPrefix(line, `"`) {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				return p, fmt.Errorf("%w: unterminated quote in %s", ErrMalformed, p.Name)
+			}
+			p.Params[name] = line[1 : end+1]
+			skip = end + 2
+		}
+
+		i = strings.IndexAny(line[skip:], ";:")
+		if i < 0 {
+			return p, fmt.Errorf("%w: no value in %s", ErrMalformed, p.Name)
+		}
+		i += skip
+		if skip == 0 {
+			p.Params[name] = line[:i]
+		}
+	}
+
+	p.Value = line[i+1:]
+	return p, nil
+}
+
+// UnescapeText undoes EscapeText.
+func UnescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// splitList splits a list value on the commas that aren't escaped.
+func splitList(s string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(values, s[start:])
+}
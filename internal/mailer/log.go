@@ -0,0 +1,19 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer writes outgoing mail to the server log instead of sending it,
+// which is all a development setup needs.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(c context.Context, msg Message) error {
+	log.Printf("LogMailer.Send - To: %s Subject: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
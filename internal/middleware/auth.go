@@ -1,11 +1,18 @@
 package middleware
 
 import (
+	"context"
+	"crypto/sha256"
 	"errors"
+	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/repository"
 )
 
 func parseJWTFromCookie(c *gin.Context) (jwt.MapClaims, error) {
@@ -45,7 +52,8 @@ func JWTAuth() gin.HandlerFunc {
 		}
 
 		if userID, ok := claims["id"].(string); ok {
-			c.Set("userID", userID)
+			setUser(c, userID)
+			setSession(c, claims)
 			c.Next()
 			return
 		}
@@ -60,9 +68,101 @@ func JWTAuthOptional() gin.HandlerFunc {
 		claims, err := parseJWTFromCookie(c)
 		if err == nil {
 			if userID, ok := claims["id"].(string); ok {
-				c.Set("userID", userID)
+				setUser(c, userID)
 			}
 		}
 		c.Next()
 	}
 }
+
+type AppPasswordAuthenticator interface {
+	AuthenticateAppPassword(c context.Context, email, password string) (*repository.User, error)
+}
+
+// basicAuthTTL is how long a successful Basic sign-in is remembered, which
+// is also how long a revoked app password may keep working.
+const basicAuthTTL = time.Minute
+
+type basicAuthEntry struct {
+	userID   string
+	username string
+	expires  time.Time
+}
+
+// BasicAuth signs the caller in with their email and an app password over
+// HTTP Basic authentication, for clients such as calendar apps that can't
+// hold on to a session cookie. Clients send the credentials with every
+// request, so successful sign-ins are cached briefly, keyed by a hash of the
+// credentials.
+func BasicAuth(a AppPasswordAuthenticator, realm string) gin.HandlerFunc {
+	var (
+		mu    sync.Mutex
+		cache = map[[sha256.Size]byte]basicAuthEntry{}
+		swept = time.Now()
+	)
+
+	return func(c *gin.Context) {
+		email, password, ok := c.Request.BasicAuth()
+		if ok {
+			key := sha256.Sum256([]byte(email + "\x00" + password))
+			now := time.Now()
+
+			mu.Lock()
+			// Drop expired sign-ins now and then so the map doesn't grow forever.
+			if now.Sub(swept) > basicAuthTTL {
+				for k, e := range cache {
+					if now.After(e.expires) {
+						delete(cache, k)
+					}
+				}
+				swept = now
+			}
+			entry, found := cache[key]
+			mu.Unlock()
+
+			valid := found && now.Before(entry.expires)
+			if !valid {
+				user, err := a.AuthenticateAppPassword(c.Request.Context(), email, password)
+				if err == nil {
+					entry = basicAuthEntry{userID: user.ID.String(), username: user.Username, expires: now.Add(basicAuthTTL)}
+					valid = true
+
+					mu.Lock()
+					cache[key] = entry
+					mu.Unlock()
+				}
+			}
+
+			if valid {
+				setUser(c, entry.userID)
+				c.Set("username", entry.username)
+				c.Next()
+				return
+			}
+		}
+
+		c.Header("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or app password"})
+		c.Abort()
+	}
+}
+
+// setSession exposes the rest of the token to handlers that hold on to the
+// caller for longer than a request, such as the collaboration socket.
+func setSession(c *gin.Context, claims jwt.MapClaims) {
+	if username, ok := claims["username"].(string); ok {
+		c.Set("username", username)
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		c.Set("tokenExpiresAt", exp.Time)
+	}
+}
+
+// setUser exposes the authenticated user to handlers and, through the request
+// context, to the row-level security settings applied by the repositories.
+func setUser(c *gin.Context, userID string) {
+	c.Set("userID", userID)
+	if uid, err := uuid.Parse(userID); err == nil {
+		c.Request = c.Request.WithContext(repository.WithUser(c.Request.Context(), uid))
+	}
+}
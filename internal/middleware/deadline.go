@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deadline overrides the server-wide read and write timeouts for routes that
+// stream large bodies, such as attachment uploads and downloads.
+func Deadline(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rc := http.NewResponseController(c.Writer)
+		deadline := time.Now().Add(d)
+		if err := rc.SetReadDeadline(deadline); err != nil {
+			log.Printf("Deadline - failed to extend read deadline for %s: %v", c.FullPath(), err)
+		}
+		if err := rc.SetWriteDeadline(deadline); err != nil {
+			log.Printf("Deadline - failed to extend write deadline for %s: %v", c.FullPath(), err)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestDeadlineBehindIdempotency checks that a route with a longer deadline
+// outlives the server's write timeout even when the request carries an
+// Idempotency-Key, whose middleware wraps the response writer.
+func TestDeadlineBehindIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Idempotency(newMemoryStore()))
+	r.POST("/import", Deadline(time.Minute), func(c *gin.Context) {
+		time.Sleep(300 * time.Millisecond)
+		c.String(http.StatusCreated, "imported")
+	})
+
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/import", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	req.Header.Set("Idempotency-Key", "import-1")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request was cut off by the write timeout: %v", err)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusCreated || string(body) != "imported" {
+		t.Errorf("request = %d %q, %v; want 201 \"imported\"", res.StatusCode, body, err)
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+	"github.com/0xrishabk/tasktracker/internal/service"
+)
+
+const (
+	maxIdempotencyKey  = 255
+	maxIdempotentBody  = 1 << 20
+	maxIdempotentReply = 1 << 20
+)
+
+type IdempotencyStore interface {
+	Reserve(c context.Context, scope, key, fingerprint string) (*repository.IdempotencyRecord, error)
+	Complete(c context.Context, scope, key string, status int, header http.Header, body []byte) error
+	Release(c context.Context, scope, key string) error
+}
+
+// replayedHeaders are the response headers stored along with the body, so
+// that a replay describes the result the same way the first response did.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location", "Link"}
+
+// responseRecorder keeps a copy of what the handler writes so it can be
+// stored for replay.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Unwrap lets http.ResponseController reach the connection underneath, for
+// middleware such as Deadline.
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes mutating requests sent with an Idempotency-Key header
+// safe to retry. The first response for a key is stored and replayed for
+// later requests with the same key and body; reusing the key for a
+// different request is rejected. Server errors are not stored, so the
+// request can be retried for real.
+func Idempotency(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKey {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKey)})
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Idempotency-Key is not supported for request bodies over 1 MB"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := idempotencyScope(c)
+		sum := sha256.New()
+		fmt.Fprintf(sum, "%s %s\n", c.Request.Method, c.Request.URL.RequestURI())
+		sum.Write(body)
+		fingerprint := hex.EncodeToString(sum.Sum(nil))
+
+		rec, err := store.Reserve(c.Request.Context(), scope, key, fingerprint)
+		switch {
+		case errors.Is(err, service.ErrIdempotencyMismatch):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, service.ErrIdempotencyInFlight):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		case rec != nil:
+			for name, values := range rec.Header {
+				for _, v := range values {
+					c.Writer.Header().Add(name, v)
+				}
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Status(*rec.Status)
+			c.Writer.Write(rec.Body)
+			c.Abort()
+			return
+		}
+
+		w := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			// The handler panicked or failed; let the client try again.
+			if err := store.Release(context.WithoutCancel(c.Request.Context()), scope, key); err != nil {
+				log.Printf("Idempotency - failed to release key %s: %v", key, err)
+			}
+		}()
+
+		c.Next()
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError || w.body.Len() > maxIdempotentReply {
+			return
+		}
+
+		header := http.Header{}
+		for _, name := range replayedHeaders {
+			for _, v := range w.Header().Values(name) {
+				header.Add(name, v)
+			}
+		}
+
+		err = store.Complete(context.WithoutCancel(c.Request.Context()), scope, key, status, header, w.body.Bytes())
+		if err != nil {
+			log.Printf("Idempotency - failed to store response for key %s: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// idempotencyScope keeps keys apart per signed-in user, falling back to the
+// client IP for anonymous requests.
+func idempotencyScope(c *gin.Context) string {
+	if claims, err := parseJWTFromCookie(c); err == nil {
+		if id, ok := claims["id"].(string); ok && id != "" {
+			return "user:" + id
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+// memoryStore is an IdempotencyStore that keeps keys in memory.
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*repository.IdempotencyRecord
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]*repository.IdempotencyRecord{}}
+}
+
+func (s *memoryStore) Reserve(c context.Context, scope, key, fingerprint string) (*repository.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[scope+" "+key]; ok {
+		return rec, nil
+	}
+	s.records[scope+" "+key] = &repository.IdempotencyRecord{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(c context.Context, scope, key string, status int, header http.Header, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.records[scope+" "+key]
+	rec.Status, rec.Header, rec.Body = &status, header, body
+	return nil
+}
+
+func (s *memoryStore) Release(c context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, scope+" "+key)
+	return nil
+}
+
+func post(t *testing.T, url, key string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"name":"write report"}`))
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	req.Header.Set("Idempotency-Key", key)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+func TestIdempotencyReplaysHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+
+	r := gin.New()
+	r.Use(Idempotency(newMemoryStore()))
+	r.POST("/task", func(c *gin.Context) {
+		calls++
+		c.Header("ETag", `"1"`)
+		c.Header("Location", "/api/task/id/42")
+		c.Header("Link", `</api/task/42/history>; rel="history"`)
+		c.Header("X-Request-Only", "yes")
+		c.JSON(http.StatusCreated, gin.H{"id": "42"})
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	first := post(t, srv.URL+"/task", "create-42")
+	again := post(t, srv.URL+"/task", "create-42")
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want once", calls)
+	}
+	if again.StatusCode != first.StatusCode {
+		t.Errorf("replayed status = %d, want %d", again.StatusCode, first.StatusCode)
+	}
+	if again.Header.Get("Idempotent-Replayed") != "true" {
+		t.Error("replay is not marked as one")
+	}
+	for _, name := range replayedHeaders {
+		if got, want := again.Header.Get(name), first.Header.Get(name); got != want {
+			t.Errorf("replayed %s = %q, want %q", name, got, want)
+		}
+	}
+	if again.Header.Get("X-Request-Only") != "" {
+		t.Error("replayed a header that isn't stored")
+	}
+}
+
+func TestResponseRecorderUnwraps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(Idempotency(newMemoryStore()))
+	r.POST("/upload", func(c *gin.Context) {
+		if err := http.NewResponseController(c.Writer).EnableFullDuplex(); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	if res := post(t, srv.URL+"/upload", "upload-1"); res.StatusCode != http.StatusNoContent {
+		t.Errorf("response controller through the recorder: status %d, want %d", res.StatusCode, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit allows each client IP, as worked out from the engine's trusted
+// proxies, at most limit requests per window. Counts are kept in memory, so
+// the limit applies per server instance.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	var (
+		mu      sync.Mutex
+		clients = map[string]*rateWindow{}
+		swept   = time.Now()
+	)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+
+		mu.Lock()
+		// Drop stale windows now and then so the map doesn't grow forever.
+		if now.Sub(swept) > window {
+			for ip, w := range clients {
+				if now.Sub(w.start) > window {
+					delete(clients, ip)
+				}
+			}
+			swept = now
+		}
+
+		w, ok := clients[c.ClientIP()]
+		if !ok || now.Sub(w.start) > window {
+			w = &rateWindow{start: now}
+			clients[c.ClientIP()] = w
+		}
+		w.count++
+		count, reset := w.count, w.start.Add(window)
+		mu.Unlock()
+
+		if count > limit {
+			retry := int(time.Until(reset).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retry))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+type WorkspaceMembership interface {
+	Membership(c context.Context, userID, workspaceID string) (uuid.UUID, error)
+}
+
+// Workspace checks that the signed-in user belongs to the workspace named by
+// the :workspaceID route param and scopes the request's repository queries to
+// it. It must run after JWTAuth.
+func Workspace(m WorkspaceMembership) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wid, err := m.Membership(c.Request.Context(), c.GetString("userID"), c.Param("workspaceID"))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, repository.ErrWorkspaceNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("workspaceID", wid.String())
+		c.Request = c.Request.WithContext(repository.WithWorkspace(c.Request.Context(), wid))
+		c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// RequestCreateAppPassword names an app password after the device or app it
+// is for, so that it can be told apart when revoking it.
+type RequestCreateAppPassword struct {
+	Name string `json:"name"`
+}
+
+// ResponseAppPassword describes an app password. The password itself is only
+// shown when it is created.
+type ResponseAppPassword struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Password   string     `json:"password,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
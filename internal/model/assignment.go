@@ -0,0 +1,6 @@
+package model
+
+type RequestAssignTask struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
@@ -0,0 +1,47 @@
+package model
+
+type RequestBulk struct {
+	// Mode is "atomic" (the default), where either every operation is applied
+	// or none is, or "best_effort", where failed operations are skipped.
+	Mode       string          `json:"mode"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// BulkOperation is one item of a bulk request. Op picks which of the other
+// fields are read: create takes task; update takes id, patch and an optional
+// version; status takes id and status; move takes id and project_id (null to
+// take the task out of its project); add_labels and remove_labels take id and
+// labels; delete takes id.
+type BulkOperation struct {
+	Op        string             `json:"op"`
+	ID        string             `json:"id"`
+	Version   *int64             `json:"version"`
+	Task      *RequestCreateTask `json:"task"`
+	Patch     *RequestUpdateTask `json:"patch"`
+	Status    string             `json:"status"`
+	ProjectID Optional[string]   `json:"project_id"`
+	Labels    []string           `json:"labels"`
+}
+
+type BulkResult struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	// Status is ok, failed, skipped (not attempted after an atomic batch
+	// failed) or rolled_back (applied, then undone with the rest of an atomic batch).
+	Status string              `json:"status"`
+	Code   int                 `json:"code,omitempty"`
+	Error  string              `json:"error,omitempty"`
+	Task   *ResponseCreateTask `json:"task,omitempty"`
+	Labels []string            `json:"labels,omitempty"`
+	Err    error               `json:"-"`
+}
+
+type ResponseBulk struct {
+	Mode      string       `json:"mode"`
+	Committed bool         `json:"committed"`
+	Results   []BulkResult `json:"results"`
+}
+
+type RequestLabels struct {
+	Labels []string `json:"labels"`
+}
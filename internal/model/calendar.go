@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// ResponseCalendarFeed describes a user's calendar feed. The URL, which holds
+// the secret token, is only shown when the feed is (re)generated.
+type ResponseCalendarFeed struct {
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RequestCalendarFeed is the query string of a calendar feed. Project and
+// label can be repeated to match any of several. Type is event (the default),
+// which every calendar app shows, or todo for apps with a task list. TZ is the
+// IANA time zone in which a task due at midnight counts as due that whole day.
+type RequestCalendarFeed struct {
+	Project []string `form:"project"`
+	Label   []string `form:"label"`
+	Type    string   `form:"type"`
+	TZ      string   `form:"tz"`
+}
+
+// CalendarObject is a task served as a CalDAV resource: a VCALENDAR holding
+// one VTODO, with the entity tag of that exact data.
+type CalendarObject struct {
+	ID   string
+	ETag string
+	Data []byte
+}
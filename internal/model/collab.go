@@ -0,0 +1,34 @@
+package model
+
+import "encoding/json"
+
+// CollabMessage is a message on the collaboration WebSocket, in either
+// direction. Topics are "task:<id>" or "project:<id>".
+//
+// Clients send:
+//
+//	subscribe    start getting events and presence for Topic
+//	unsubscribe  stop, and leave Topic's presence
+//	presence     set State ("viewing" or "editing") on Topic
+//	typing       tell Topic's other viewers the user is typing a comment
+//	pong         answer a ping
+//
+// The server sends subscribed (with the current Users), event, presence
+// (with every user now on Topic), typing, ping, error and session_expired.
+type CollabMessage struct {
+	Type     string          `json:"type"`
+	Topic    string          `json:"topic,omitempty"`
+	State    string          `json:"state,omitempty"`
+	Event    string          `json:"event,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Users    []PresenceUser  `json:"users,omitempty"`
+	UserID   string          `json:"user_id,omitempty"`
+	Username string          `json:"username,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+type PresenceUser struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	State    string `json:"state"`
+}
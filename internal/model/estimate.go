@@ -0,0 +1,29 @@
+package model
+
+type EstimateTotals struct {
+	Tasks            int   `json:"tasks"`
+	StoryPoints      int64 `json:"story_points"`
+	EstimateSeconds  int64 `json:"estimate_seconds"`
+	RemainingSeconds int64 `json:"remaining_seconds"`
+	ActualSeconds    int64 `json:"actual_seconds"`
+}
+
+type StatusEstimate struct {
+	Status string `json:"status"`
+	EstimateTotals
+}
+
+type ResponseTaskEstimate struct {
+	TaskID           string         `json:"task_id"`
+	StoryPoints      *int           `json:"story_points"`
+	EstimateSeconds  *int64         `json:"estimate_seconds"`
+	RemainingSeconds *int64         `json:"remaining_seconds"`
+	Rollup           EstimateTotals `json:"rollup"`
+	VarianceSeconds  int64          `json:"variance_seconds"`
+}
+
+type ResponseEstimateSummary struct {
+	ByStatus  []StatusEstimate `json:"by_status"`
+	Committed EstimateTotals   `json:"committed"`
+	Done      EstimateTotals   `json:"done"`
+}
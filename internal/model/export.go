@@ -0,0 +1,11 @@
+package model
+
+// RequestExport takes the query parameters of the task listings, along with q,
+// a filter expression as for /filter, and the format to export in: csv, json,
+// md or html. Paging parameters are ignored, since an export holds every
+// matching task.
+type RequestExport struct {
+	RequestListTasks
+	Format string `form:"format"`
+	Query  string `form:"q"`
+}
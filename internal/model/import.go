@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// RequestImport is the form sent along with the file to import. Format is
+// csv, json, todoist (Todoist's CSV template export) or trello (a Trello
+// board's JSON export). Mapping is JSON holding an ImportMapping.
+type RequestImport struct {
+	Format      string `form:"format"`
+	Mapping     string `form:"mapping"`
+	DryRun      bool   `form:"dry_run"`
+	SkipInvalid bool   `form:"skip_invalid"`
+}
+
+// ImportMapping tells an import how to read the file. Fields maps task fields
+// (name, description, status, due_at, labels, project_id, story_points,
+// estimate_seconds) to the CSV columns or JSON keys holding them; fields left
+// out are read from a column of the same name, if there is one. Statuses
+// maps values found in the file to task statuses; anything else is
+// upper-cased with spaces turned into underscores, so "In progress" becomes
+// IN_PROGRESS. Due dates without a zone are read in Timezone, UTC by default.
+type ImportMapping struct {
+	Fields         map[string]string `json:"fields"`
+	Statuses       map[string]string `json:"statuses"`
+	LabelSeparator string            `json:"label_separator"`
+	Timezone       string            `json:"timezone"`
+}
+
+// ImportTask is a row that passed validation, as it will be created. Row is
+// its line in a CSV file, or its position in a JSON one; ParentRow is the row
+// of the task it is a subtask of.
+type ImportTask struct {
+	Row             int        `json:"row"`
+	ParentRow       *int       `json:"parent_row,omitempty"`
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	Status          string     `json:"status"`
+	ProjectID       *string    `json:"project_id"`
+	StoryPoints     *int       `json:"story_points"`
+	EstimateSeconds *int64     `json:"estimate_seconds"`
+	DueAt           *time.Time `json:"due_at"`
+	Labels          []string   `json:"labels"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// ResponseImport reports how an import went. Status is preview for a dry
+// run, imported when the tasks were created, queued when a background job
+// will create them, or rejected when rows failed validation and skip_invalid
+// wasn't set, in which case nothing was created.
+type ResponseImport struct {
+	Status string `json:"status"`
+	// Total counts the tasks read from the file, Valid those that passed
+	// validation and Ignored the entries that aren't tasks, such as Todoist
+	// sections or archived Trello cards.
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Ignored  int              `json:"ignored"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+	// Preview holds the first tasks a dry run would create.
+	Preview []ImportTask `json:"preview,omitempty"`
+	JobID   string       `json:"job_id,omitempty"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "encoding/json"
+
+// Optional is one field of a JSON Merge Patch (RFC 7396). Set reports whether
+// the key was in the patch at all; when it was given as null, Value is nil.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	return json.Unmarshal(b, &o.Value)
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+type RequestShare struct {
+	UserID     string `json:"user_id"`
+	Email      string `json:"email"`
+	Permission string `json:"permission"`
+}
+
+type RequestCreateShareLink struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  string     `json:"password"`
+}
+
+// ResponseShareLink is only returned when the link is created; the token
+// can't be recovered afterwards.
+type ResponseShareLink struct {
+	ID          string     `json:"id"`
+	Token       string     `json:"token"`
+	URL         string     `json:"url"`
+	HasPassword bool       `json:"has_password"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ResponsePublicTask is the read-only view of a task behind a share link. It
+// leaves out everything that identifies people or other tasks.
+type ResponsePublicTask struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	Status           string    `json:"status"`
+	StoryPoints      *int      `json:"story_points"`
+	EstimateSeconds  *int64    `json:"estimate_seconds"`
+	RemainingSeconds *int64    `json:"remaining_seconds"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type ResponsePublicProject struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Tasks       []ResponsePublicTask `json:"tasks"`
+}
+
+type ResponsePublicShare struct {
+	Task    *ResponsePublicTask    `json:"task,omitempty"`
+	Project *ResponsePublicProject `json:"project,omitempty"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type SyncTask struct {
+	ResponseCreateTask
+	Labels []string `json:"labels"`
+}
+
+// ResponseSync is one page of changes. Deleted lists the ids of tasks that
+// were deleted or moved to the trash. While HasMore is set, Token fetches the
+// next page; after the last page it is the token for the next sync.
+type ResponseSync struct {
+	Tasks   []SyncTask `json:"tasks"`
+	Deleted []string   `json:"deleted"`
+	Token   string     `json:"token"`
+	HasMore bool       `json:"has_more"`
+}
+
+type RequestSyncPush struct {
+	Mutations []SyncMutation `json:"mutations"`
+}
+
+// SyncMutation is one change a client made while offline. Op is create, update
+// or delete. Fields holds the task's fields for create and a merge patch for
+// update, both of which may also set the full list of labels.
+//
+// BaseToken is the sync token the client's copy of the task was at. Fields the
+// server changed since then are conflicts: with OnConflict "report" (the
+// default) they are left alone and returned, with "lww" the change made last
+// wins, going by ChangedAt (when the client made the change, now if unset).
+type SyncMutation struct {
+	ID         string          `json:"id"`
+	Op         string          `json:"op"`
+	TaskID     string          `json:"task_id"`
+	BaseToken  string          `json:"base_token"`
+	OnConflict string          `json:"on_conflict"`
+	ChangedAt  *time.Time      `json:"changed_at"`
+	Fields     json.RawMessage `json:"fields"`
+}
+
+// SyncConflict is a field the server changed after the client's base token.
+// Winner is "server" when the client's value was dropped and "client" when it
+// was applied anyway.
+type SyncConflict struct {
+	Field       string          `json:"field"`
+	ServerValue json.RawMessage `json:"server_value"`
+	Winner      string          `json:"winner"`
+}
+
+type SyncMutationResult struct {
+	ID string `json:"id"`
+	// Status is applied, conflict (applied except for the conflicting fields,
+	// or not at all for a delete) or failed.
+	Status    string         `json:"status"`
+	Code      int            `json:"code,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Task      *SyncTask      `json:"task,omitempty"`
+	Conflicts []SyncConflict `json:"conflicts,omitempty"`
+	Err       error          `json:"-"`
+}
+
+type ResponseSyncPush struct {
+	Results []SyncMutationResult `json:"results"`
+}
@@ -3,23 +3,99 @@ package model
 import "time"
 
 type RequestCreateTask struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
-	UserID      string `json:"user_id"`
+	// ID is left out to have one generated; offline clients set it so they can
+	// refer to the task before it has reached the server.
+	ID               *string    `json:"id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Status           string     `json:"status"`
+	UserID           string     `json:"-"`
+	ProjectID        *string    `json:"project_id"`
+	ParentID         *string    `json:"parent_id"`
+	StoryPoints      *int       `json:"story_points"`
+	EstimateSeconds  *int64     `json:"estimate_seconds"`
+	RemainingSeconds *int64     `json:"remaining_seconds"`
+	DueAt            *time.Time `json:"due_at"`
 }
 
 type ResponseCreateTask struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Status           string     `json:"status"`
+	WorkspaceID      string     `json:"workspace_id"`
+	ProjectID        *string    `json:"project_id"`
+	ParentID         *string    `json:"parent_id"`
+	StoryPoints      *int       `json:"story_points"`
+	EstimateSeconds  *int64     `json:"estimate_seconds"`
+	RemainingSeconds *int64     `json:"remaining_seconds"`
+	DueAt            *time.Time `json:"due_at"`
+	Version          int64      `json:"version"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	ArchivedAt       *time.Time `json:"archived_at"`
 }
 
+// RequestUpdateTask is a JSON Merge Patch against a task: fields left out are
+// unchanged and fields set to null are cleared.
 type RequestUpdateTask struct {
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
-	Status      *string `json:"status"`
+	Name             Optional[string]    `json:"name"`
+	Description      Optional[string]    `json:"description"`
+	Status           Optional[string]    `json:"status"`
+	ProjectID        Optional[string]    `json:"project_id"`
+	ParentID         Optional[string]    `json:"parent_id"`
+	StoryPoints      Optional[int]       `json:"story_points"`
+	EstimateSeconds  Optional[int64]     `json:"estimate_seconds"`
+	RemainingSeconds Optional[int64]     `json:"remaining_seconds"`
+	DueAt            Optional[time.Time] `json:"due_at"`
+}
+
+type RequestArchiveDone struct {
+	OlderThanDays int `json:"older_than_days"`
+}
+
+type ResponseArchiveDone struct {
+	Archived int64 `json:"archived"`
+}
+
+// AutoArchiveSettings is a user's opt-in to automatic archival. Days is how
+// long a finished task stays untouched before it is archived; nil turns it off.
+type AutoArchiveSettings struct {
+	Days *int `json:"days"`
+}
+
+type RequestRevertTask struct {
+	EventID int64 `json:"event_id"`
+}
+
+// RequestListTasks is the query string shared by the task listings. Times are
+// RFC 3339, status is a comma-separated list and cursor is the opaque value
+// from a previous page's next link.
+type RequestListTasks struct {
+	Archived      string `form:"archived"`
+	Status        string `form:"status"`
+	Name          string `form:"name"`
+	CreatedAfter  string `form:"created_after"`
+	CreatedBefore string `form:"created_before"`
+	UpdatedAfter  string `form:"updated_after"`
+	UpdatedBefore string `form:"updated_before"`
+	Cursor        string `form:"cursor"`
+	Limit         int    `form:"limit"`
+}
+
+type ResponseTaskSearchHit struct {
+	Task       ResponseCreateTask `json:"task"`
+	Rank       float64            `json:"rank"`
+	Highlights TaskHighlights     `json:"highlights"`
+}
+
+// TaskHighlights are HTML-escaped fragments of a search hit with the matched
+// words wrapped in <mark>.
+type TaskHighlights struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RequestComment struct {
+	Body string `json:"body"`
 }
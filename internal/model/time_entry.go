@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+type RequestStartTimer struct {
+	Note string `json:"note"`
+}
+
+type RequestCreateTimeEntry struct {
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds int64     `json:"duration_seconds"`
+	Note            string    `json:"note"`
+}
+
+type RequestUpdateTimeEntry struct {
+	StartedAt       *time.Time `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationSeconds *int64     `json:"duration_seconds"`
+	Note            *string    `json:"note"`
+}
+
+type TimesheetRow struct {
+	TaskID       string  `json:"task_id"`
+	TaskName     string  `json:"task_name"`
+	Seconds      []int64 `json:"seconds"`
+	TotalSeconds int64   `json:"total_seconds"`
+}
+
+type ResponseTimesheet struct {
+	WeekStart    string         `json:"week_start"`
+	TimeZone     string         `json:"time_zone"`
+	Days         []string       `json:"days"`
+	Rows         []TimesheetRow `json:"rows"`
+	DayTotals    []int64        `json:"day_totals_seconds"`
+	TotalSeconds int64          `json:"total_seconds"`
+}
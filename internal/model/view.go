@@ -0,0 +1,10 @@
+package model
+
+// RequestSavedView creates or replaces a saved view. Query is written in the
+// task filter language; a shared view needs a workspace.
+type RequestSavedView struct {
+	Name        string  `json:"name"`
+	Query       string  `json:"query"`
+	WorkspaceID *string `json:"workspace_id"`
+	Shared      bool    `json:"shared"`
+}
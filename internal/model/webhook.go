@@ -0,0 +1,14 @@
+package model
+
+type RequestCreateWebhook struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	WorkspaceID *string  `json:"workspace_id"`
+}
+
+// RequestUpdateWebhook changes only the fields that are set.
+type RequestUpdateWebhook struct {
+	URL    *string  `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
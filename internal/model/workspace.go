@@ -0,0 +1,32 @@
+package model
+
+type RequestCreateWorkspace struct {
+	Name string `json:"name"`
+}
+
+type RequestUpdateWorkspace struct {
+	Name string `json:"name"`
+}
+
+type RequestUpdateMemberRole struct {
+	Role string `json:"role"`
+}
+
+type RequestInviteMember struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type RequestAcceptInvitation struct {
+	Token string `json:"token"`
+}
+
+type RequestCreateProject struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RequestUpdateProject struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TaskAccess describes how a particular user is related to a task.
+type TaskAccess struct {
+	Owner    bool
+	Assignee bool
+	// Role is the user's role in the task's workspace, empty if they aren't a member.
+	Role string
+	// Share is the permission the task or its project was shared with, if any.
+	Share string
+	// Trashed is set while the task sits in the trash.
+	Trashed bool
+}
+
+func (r *TaskRepository) GetAccess(c context.Context, taskID, userID uuid.UUID) (*TaskAccess, error) {
+	query := `
+			SELECT
+				t.user_id = $2,
+				EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = t.id AND a.user_id = $2),
+				COALESCE((SELECT m.role FROM workspace_members m WHERE m.workspace_id = t.workspace_id AND m.user_id = $2), ''),
+				COALESCE(app_share_permission(t.id, t.project_id, $2), ''),
+				t.deleted_at IS NOT NULL
+			FROM tasks t
+			WHERE t.id = $1 AND ($3::UUID IS NULL OR t.workspace_id = $3)
+	`
+
+	var a TaskAccess
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, query, taskID, userID, workspaceArg(c)).Scan(&a.Owner, &a.Assignee, &a.Role, &a.Share, &a.Trashed)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("get task access: %w", err)
+	}
+
+	return &a, nil
+}
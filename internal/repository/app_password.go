@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrAppPasswordNotFound = errors.New("app password not found")
+
+type AppPassword struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAppPassword stores an app password by the hash of its token; the
+// token itself is never persisted.
+func (r *UserRepository) CreateAppPassword(c context.Context, userID uuid.UUID, name, tokenHash string) (*AppPassword, error) {
+	query := `
+			INSERT INTO app_passwords (user_id, name, token_hash)
+			VALUES ($1, $2, $3)
+			RETURNING id, name, last_used_at, created_at
+	`
+
+	var p AppPassword
+	if err := r.db.QueryRowContext(c, query, userID, name, tokenHash).Scan(&p.ID, &p.Name, &p.LastUsedAt, &p.CreatedAt); err != nil {
+		return nil, fmt.Errorf("insert app password: %w", err)
+	}
+
+	return &p, nil
+}
+
+func (r *UserRepository) GetAppPasswords(c context.Context, userID uuid.UUID) ([]AppPassword, error) {
+	query := `
+			SELECT id, name, last_used_at, created_at
+			FROM app_passwords
+			WHERE user_id = $1
+			ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(c, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get app passwords: %w", err)
+	}
+	defer rows.Close()
+
+	passwords := []AppPassword{}
+	for rows.Next() {
+		var p AppPassword
+		if err := rows.Scan(&p.ID, &p.Name, &p.LastUsedAt, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("get app passwords: %w", err)
+		}
+		passwords = append(passwords, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get app passwords: %w", err)
+	}
+
+	return passwords, nil
+}
+
+func (r *UserRepository) DeleteAppPassword(c context.Context, id, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(c, "DELETE FROM app_passwords WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("delete app password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAppPasswordNotFound
+	}
+
+	return nil
+}
+
+// UseAppPassword finds the user an app password belongs to, given their
+// email and the hash of the token, and records that it was used.
+func (r *UserRepository) UseAppPassword(c context.Context, email, tokenHash string) (*User, error) {
+	query := `
+			UPDATE app_passwords p SET last_used_at = NOW()
+			FROM users u
+			WHERE u.id = p.user_id AND u.email = $1 AND p.token_hash = $2
+			RETURNING u.id, u.username, u.email, u.password_hash, u.created_at, u.updated_at
+	`
+
+	var user User
+	err := r.db.QueryRowContext(c, query, email, tokenHash).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAppPasswordNotFound
+		}
+		return nil, fmt.Errorf("use app password: %w", err)
+	}
+
+	return &user, nil
+}
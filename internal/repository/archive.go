@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ArchiveFilter says how a task listing treats archived tasks.
+type ArchiveFilter int
+
+const (
+	// ExcludeArchived is what listings do by default.
+	ExcludeArchived ArchiveFilter = iota
+	IncludeArchived
+	OnlyArchived
+)
+
+// arg is the query argument for the "($n::BOOLEAN IS NULL OR (archived_at IS
+// NOT NULL) = $n)" filter.
+func (f ArchiveFilter) arg() any {
+	switch f {
+	case IncludeArchived:
+		return nil
+	case OnlyArchived:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *TaskRepository) ArchiveTask(c context.Context, taskID uuid.UUID) (*Task, error) {
+	query := `
+			UPDATE tasks SET archived_at = COALESCE(archived_at, NOW())
+			WHERE
+			id = $1 AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+			RETURNING ` + taskColumns
+
+	task, err := r.queryTask(c, query, taskID, workspaceArg(c))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// UnarchiveTask also touches updated_at so automatic archival doesn't pick
+// the task straight back up.
+func (r *TaskRepository) UnarchiveTask(c context.Context, taskID uuid.UUID) (*Task, error) {
+	query := `
+			UPDATE tasks SET archived_at = NULL, updated_at = NOW()
+			WHERE
+			id = $1 AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+			RETURNING ` + taskColumns
+
+	task, err := r.queryTask(c, query, taskID, workspaceArg(c))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// ArchiveStale archives the user's tasks in the given status that haven't
+// been updated since before, returning how many were archived.
+func (r *TaskRepository) ArchiveStale(c context.Context, userID uuid.UUID, status string, before time.Time) (int64, error) {
+	query := `
+			UPDATE tasks SET archived_at = NOW()
+			WHERE user_id = $1 AND status = $2 AND updated_at < $3
+			AND archived_at IS NULL AND deleted_at IS NULL
+			AND ($4::UUID IS NULL OR workspace_id = $4)
+	`
+
+	result, err := execTenant(c, r.db, query, userID, status, before, workspaceArg(c))
+	if err != nil {
+		return 0, fmt.Errorf("archive stale tasks: %v", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// AutoArchive runs ArchiveStale for every user who turned automatic archival
+// on, each with their own age threshold. It must run as the system.
+func (r *TaskRepository) AutoArchive(c context.Context, status string) (int64, error) {
+	query := `
+			UPDATE tasks t SET archived_at = NOW()
+			FROM users u
+			WHERE u.id = t.user_id AND u.auto_archive_days IS NOT NULL
+			AND t.status = $1 AND t.updated_at < NOW() - make_interval(days => u.auto_archive_days)
+			AND t.archived_at IS NULL AND t.deleted_at IS NULL
+	`
+
+	result, err := execTenant(c, r.db, query, status)
+	if err != nil {
+		return 0, fmt.Errorf("auto archive tasks: %v", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotAssigned = errors.New("user is not assigned to this task")
+
+type TaskPerson struct {
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AssignmentRepository struct {
+	db *sql.DB
+}
+
+func NewAssignmentRepository(db *sql.DB) *AssignmentRepository {
+	return &AssignmentRepository{db: db}
+}
+
+func (r *AssignmentRepository) AddAssignee(c context.Context, taskID, userID, assignedBy uuid.UUID) error {
+	query := `
+			INSERT INTO task_assignees (task_id, user_id, assigned_by)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (task_id, user_id) DO NOTHING
+	`
+
+	if _, err := execTenant(c, r.db, query, taskID, userID, assignedBy); err != nil {
+		return fmt.Errorf("add assignee: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AssignmentRepository) RemoveAssignee(c context.Context, taskID, userID uuid.UUID) error {
+	result, err := execTenant(c, r.db, "DELETE FROM task_assignees WHERE task_id = $1 AND user_id = $2", taskID, userID)
+	if err != nil {
+		return fmt.Errorf("remove assignee: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotAssigned
+	}
+
+	return nil
+}
+
+func (r *AssignmentRepository) GetAssignees(c context.Context, taskID uuid.UUID) ([]TaskPerson, error) {
+	query := `
+			SELECT u.id, u.username, u.email, a.created_at
+			FROM task_assignees a
+			JOIN users u ON u.id = a.user_id
+			WHERE a.task_id = $1
+			ORDER BY a.created_at
+	`
+
+	return r.queryPeople(c, "get assignees", query, taskID)
+}
+
+func (r *AssignmentRepository) AddWatcher(c context.Context, taskID, userID uuid.UUID) error {
+	query := `
+			INSERT INTO task_watchers (task_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT (task_id, user_id) DO NOTHING
+	`
+
+	if _, err := execTenant(c, r.db, query, taskID, userID); err != nil {
+		return fmt.Errorf("add watcher: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AssignmentRepository) RemoveWatcher(c context.Context, taskID, userID uuid.UUID) error {
+	if _, err := execTenant(c, r.db, "DELETE FROM task_watchers WHERE task_id = $1 AND user_id = $2", taskID, userID); err != nil {
+		return fmt.Errorf("remove watcher: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AssignmentRepository) GetWatchers(c context.Context, taskID uuid.UUID) ([]TaskPerson, error) {
+	query := `
+			SELECT u.id, u.username, u.email, w.created_at
+			FROM task_watchers w
+			JOIN users u ON u.id = w.user_id
+			WHERE w.task_id = $1
+			ORDER BY w.created_at
+	`
+
+	return r.queryPeople(c, "get watchers", query, taskID)
+}
+
+func (r *AssignmentRepository) queryPeople(c context.Context, op, query string, args ...any) ([]TaskPerson, error) {
+	people := []TaskPerson{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var p TaskPerson
+			if err := rows.Scan(&p.UserID, &p.Username, &p.Email, &p.CreatedAt); err != nil {
+				return err
+			}
+			people = append(people, p)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return people, nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrQuotaExceeded = errors.New("attachment quota exceeded")
+
+type Attachment struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"task_id"`
+	UserID      string    `json:"user_id"`
+	Filename    string    `json:"filename"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	SHA256      string    `json:"sha256"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// CreateAttachment inserts the metadata row only if the owner stays within
+// quota. The check takes a per-user advisory lock held until commit, so
+// concurrent uploads by the same user are counted one after the other rather
+// than all against the same total.
+func (r *AttachmentRepository) CreateAttachment(c context.Context, a *Attachment, quota int64) (*Attachment, error) {
+	query := `
+			INSERT INTO task_attachments (task_id, user_id, filename, size_bytes, content_type, sha256, storage_key)
+			SELECT $1, $2, $3, $4, $5, $6, $7
+			WHERE app_attachment_usage($2) + $4 <= $8
+			RETURNING id, created_at
+	`
+
+	err := withTenant(c, r.db, func(q querier) error {
+		if _, err := q.ExecContext(c, "SELECT pg_advisory_xact_lock(hashtext('attachment_quota:' || $1))", a.UserID); err != nil {
+			return err
+		}
+
+		return q.QueryRowContext(c, query,
+			a.TaskID, a.UserID, a.Filename, a.Size, a.ContentType, a.SHA256, a.StorageKey, quota,
+		).Scan(
+			&a.ID,
+			&a.CreatedAt,
+		)
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrQuotaExceeded
+		}
+		return nil, fmt.Errorf("insert attachment: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *AttachmentRepository) GetAttachmentByID(c context.Context, id uuid.UUID) (*Attachment, error) {
+	query := `
+			SELECT id, task_id, user_id, filename, size_bytes, content_type, sha256, storage_key, created_at
+			FROM task_attachments
+			WHERE id = $1
+	`
+
+	var a Attachment
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, query, id).Scan(
+			&a.ID,
+			&a.TaskID,
+			&a.UserID,
+			&a.Filename,
+			&a.Size,
+			&a.ContentType,
+			&a.SHA256,
+			&a.StorageKey,
+			&a.CreatedAt,
+		)
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get attachment by id: %w", err)
+	}
+
+	return &a, nil
+}
+
+func (r *AttachmentRepository) GetAttachmentsByTaskID(c context.Context, taskID uuid.UUID) ([]Attachment, error) {
+	query := `
+			SELECT id, task_id, user_id, filename, size_bytes, content_type, sha256, storage_key, created_at
+			FROM task_attachments
+			WHERE task_id = $1
+			ORDER BY created_at
+	`
+
+	attachments := []Attachment{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, taskID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var a Attachment
+			if err := rows.Scan(&a.ID, &a.TaskID, &a.UserID, &a.Filename, &a.Size, &a.ContentType, &a.SHA256, &a.StorageKey, &a.CreatedAt); err != nil {
+				return err
+			}
+			attachments = append(attachments, a)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get attachments by task id: %w", err)
+	}
+
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) GetUsageByUserID(c context.Context, userID uuid.UUID) (int64, error) {
+	var used int64
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c,
+			"SELECT app_attachment_usage($1)", userID,
+		).Scan(&used)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get attachment usage: %w", err)
+	}
+	return used, nil
+}
+
+func (r *AttachmentRepository) DeleteAttachment(c context.Context, id uuid.UUID) error {
+	result, err := execTenant(c, r.db, "DELETE FROM task_attachments WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("delete attachment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("attachment not found")
+	}
+
+	return nil
+}
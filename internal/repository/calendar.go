@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+type CalendarFeed struct {
+	UserID    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CalendarRepository struct {
+	db *sql.DB
+}
+
+func NewCalendarRepository(db *sql.DB) *CalendarRepository {
+	return &CalendarRepository{db: db}
+}
+
+// SetFeed gives the user's feed a new token, replacing the one it had.
+func (r *CalendarRepository) SetFeed(c context.Context, userID uuid.UUID, tokenHash string) (*CalendarFeed, error) {
+	query := `
+			INSERT INTO calendar_feeds (user_id, token_hash)
+			VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW()
+			RETURNING user_id, created_at
+	`
+
+	var f CalendarFeed
+	if err := r.db.QueryRowContext(c, query, userID, tokenHash).Scan(&f.UserID, &f.CreatedAt); err != nil {
+		return nil, fmt.Errorf("set calendar feed: %w", err)
+	}
+
+	return &f, nil
+}
+
+func (r *CalendarRepository) GetFeed(c context.Context, userID uuid.UUID) (*CalendarFeed, error) {
+	var f CalendarFeed
+	err := r.db.QueryRowContext(c, "SELECT user_id, created_at FROM calendar_feeds WHERE user_id = $1", userID).Scan(&f.UserID, &f.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, fmt.Errorf("get calendar feed: %w", err)
+	}
+
+	return &f, nil
+}
+
+// GetFeedByToken looks up a feed by the hash of its token.
+func (r *CalendarRepository) GetFeedByToken(c context.Context, tokenHash string) (*CalendarFeed, error) {
+	var f CalendarFeed
+	err := r.db.QueryRowContext(c, "SELECT user_id, created_at FROM calendar_feeds WHERE token_hash = $1", tokenHash).Scan(&f.UserID, &f.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, fmt.Errorf("get calendar feed by token: %w", err)
+	}
+
+	return &f, nil
+}
+
+func (r *CalendarRepository) DeleteFeed(c context.Context, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(c, "DELETE FROM calendar_feeds WHERE user_id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("delete calendar feed: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows effected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrCalendarFeedNotFound
+	}
+
+	return nil
+}
+
+// GetDueTasks lists the live, unarchived tasks in scope that are due from the
+// given time on, soonest first. Project and label filters each match any of
+// the values given; empty ones match everything.
+func (r *TaskRepository) GetDueTasks(c context.Context, from time.Time, projectIDs []uuid.UUID, labels []string) ([]LabeledTask, error) {
+	query := `
+			SELECT ` + taskColumns + `, ` + labelsColumn + `
+			FROM tasks
+			WHERE due_at >= $1 AND deleted_at IS NULL AND archived_at IS NULL
+			AND ($2::UUID IS NULL OR workspace_id = $2)
+			AND (cardinality($3::UUID[]) = 0 OR project_id = ANY($3::UUID[]))
+			AND (cardinality($4::TEXT[]) = 0 OR id IN (SELECT task_id FROM task_labels WHERE label = ANY($4::TEXT[])))
+			ORDER BY due_at, id
+	`
+
+	projects := make([]string, len(projectIDs))
+	for i, id := range projectIDs {
+		projects[i] = id.String()
+	}
+	if labels == nil {
+		labels = []string{}
+	}
+
+	tasks := []LabeledTask{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, from, workspaceArg(c), pq.Array(projects), pq.Array(labels))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var labels []string
+			t, err := scanTask(rows, pq.Array(&labels))
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, LabeledTask{Task: *t, Labels: labels})
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get due tasks: %w", err)
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrCommentNotFound = errors.New("comment not found")
+
+type Comment struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	UserID    string    `json:"user_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const commentColumns = `id, task_id, user_id, body, created_at, updated_at`
+
+func scanComment(row rowScanner) (*Comment, error) {
+	var cm Comment
+	if err := row.Scan(&cm.ID, &cm.TaskID, &cm.UserID, &cm.Body, &cm.CreatedAt, &cm.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &cm, nil
+}
+
+// queryComment runs a single-row comment query under the tenant settings from c.
+func (r *TaskRepository) queryComment(c context.Context, query string, args ...any) (*Comment, error) {
+	var cm *Comment
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		cm, err = scanComment(q.QueryRowContext(c, query, args...))
+		return err
+	})
+	return cm, err
+}
+
+func (r *TaskRepository) CreateComment(c context.Context, taskID, userID uuid.UUID, body string) (*Comment, error) {
+	query := `
+			INSERT INTO task_comments (task_id, user_id, body)
+			VALUES ($1, $2, $3)
+			RETURNING ` + commentColumns
+
+	cm, err := r.queryComment(c, query, taskID, userID, body)
+	if err != nil {
+		return nil, fmt.Errorf("create comment: %w", err)
+	}
+
+	return cm, nil
+}
+
+func (r *TaskRepository) GetComments(c context.Context, taskID uuid.UUID) ([]Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM task_comments WHERE task_id = $1 ORDER BY created_at, id`
+
+	comments := []Comment{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, taskID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			cm, err := scanComment(rows)
+			if err != nil {
+				return err
+			}
+			comments = append(comments, *cm)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// UpdateComment changes the body of a comment userID wrote on the task.
+func (r *TaskRepository) UpdateComment(c context.Context, id, taskID, userID uuid.UUID, body string) (*Comment, error) {
+	query := `
+			UPDATE task_comments SET body = $1, updated_at = NOW()
+			WHERE id = $2 AND task_id = $3 AND user_id = $4
+			RETURNING ` + commentColumns
+
+	cm, err := r.queryComment(c, query, body, id, taskID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("update comment: %w", err)
+	}
+
+	return cm, nil
+}
+
+// DeleteComment removes a comment userID wrote on the task.
+func (r *TaskRepository) DeleteComment(c context.Context, id, taskID, userID uuid.UUID) error {
+	result, err := execTenant(c, r.db, "DELETE FROM task_comments WHERE id = $1 AND task_id = $2 AND user_id = $3", id, taskID, userID)
+	if err != nil {
+		return fmt.Errorf("delete comment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
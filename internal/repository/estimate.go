@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type EstimateTotals struct {
+	Tasks            int
+	StoryPoints      int64
+	EstimateSeconds  int64
+	RemainingSeconds int64
+	ActualSeconds    int64
+}
+
+type StatusEstimate struct {
+	Status string
+	EstimateTotals
+}
+
+// GetRollup sums the estimates of a task and all of its subtasks, along with
+// the time tracked against any of them.
+func (r *TaskRepository) GetRollup(c context.Context, taskID uuid.UUID) (*EstimateTotals, error) {
+	query := `
+			WITH RECURSIVE subtree AS (
+				SELECT id, story_points, estimate_seconds, remaining_seconds
+				FROM tasks WHERE id = $1 AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+				UNION
+				SELECT t.id, t.story_points, t.estimate_seconds, t.remaining_seconds
+				FROM tasks t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+			)
+			SELECT
+				COUNT(*),
+				COALESCE(SUM(story_points), 0),
+				COALESCE(SUM(estimate_seconds), 0),
+				COALESCE(SUM(remaining_seconds), 0),
+				COALESCE((
+					SELECT SUM(EXTRACT(EPOCH FROM (COALESCE(e.ended_at, NOW()) - e.started_at)))::BIGINT
+					FROM time_entries e
+					WHERE e.task_id IN (SELECT id FROM subtree)
+				), 0)
+			FROM subtree
+	`
+
+	var t EstimateTotals
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, query, taskID, workspaceArg(c)).Scan(
+			&t.Tasks,
+			&t.StoryPoints,
+			&t.EstimateSeconds,
+			&t.RemainingSeconds,
+			&t.ActualSeconds,
+		)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get estimate rollup: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (r *TaskRepository) GetEstimatesByStatus(c context.Context, userID uuid.UUID) ([]StatusEstimate, error) {
+	query := `
+			SELECT
+				t.status,
+				COUNT(*),
+				COALESCE(SUM(t.story_points), 0),
+				COALESCE(SUM(t.estimate_seconds), 0),
+				COALESCE(SUM(t.remaining_seconds), 0),
+				COALESCE(SUM(a.seconds), 0)
+			FROM tasks t
+			LEFT JOIN (
+				SELECT task_id, SUM(EXTRACT(EPOCH FROM (COALESCE(ended_at, NOW()) - started_at)))::BIGINT AS seconds
+				FROM time_entries
+				GROUP BY task_id
+			) a ON a.task_id = t.id
+			WHERE t.user_id = $1 AND t.deleted_at IS NULL AND ($2::UUID IS NULL OR t.workspace_id = $2)
+			GROUP BY t.status
+			ORDER BY t.status
+	`
+
+	estimates := []StatusEstimate{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, userID, workspaceArg(c))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var e StatusEstimate
+			if err := rows.Scan(&e.Status, &e.Tasks, &e.StoryPoints, &e.EstimateSeconds, &e.RemainingSeconds, &e.ActualSeconds); err != nil {
+				return err
+			}
+			estimates = append(estimates, e)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get estimates by status: %w", err)
+	}
+
+	return estimates, nil
+}
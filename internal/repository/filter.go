@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/0xrishabk/tasktracker/internal/filter"
+)
+
+// compileFilter turns a parsed filter into a SQL condition on the tasks
+// table, passing every value through arg as a query parameter.
+func compileFilter(n filter.Node, arg func(any) string) string {
+	switch n := n.(type) {
+	case filter.And:
+		return joinFilters(n.Terms, " AND ", arg)
+	case filter.Or:
+		return joinFilters(n.Terms, " OR ", arg)
+	case filter.Not:
+		return "NOT " + compileFilter(n.Term, arg)
+	case filter.Cond:
+		return "(" + compileCond(n, arg) + ")"
+	default:
+		panic(fmt.Sprintf("compile filter: unexpected node %T", n))
+	}
+}
+
+func joinFilters(terms []filter.Node, sep string, arg func(any) string) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = compileFilter(t, arg)
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+var filterColumns = map[filter.Field]string{
+	filter.FieldDue:     "tasks.due_at",
+	filter.FieldCreated: "tasks.created_at",
+	filter.FieldUpdated: "tasks.updated_at",
+	filter.FieldPoints:  "tasks.story_points",
+}
+
+func compileCond(c filter.Cond, arg func(any) string) string {
+	switch c.Field {
+	case filter.FieldText:
+		return fmt.Sprintf("tasks.search_vector @@ phraseto_tsquery('english', %s)", arg(c.Values[0]))
+
+	case filter.FieldStatus:
+		return fmt.Sprintf("tasks.status = ANY(%s::TEXT[])", arg(pq.Array(c.Values)))
+
+	case filter.FieldLabel:
+		return fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM task_labels l WHERE l.task_id = tasks.id AND l.label = ANY(%s::TEXT[]))",
+			arg(pq.Array(c.Values)),
+		)
+
+	case filter.FieldAssignee:
+		if c.None {
+			return "NOT EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = tasks.id)"
+		}
+		return "EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = tasks.id AND a.user_id " + compileUser(c, arg) + ")"
+
+	case filter.FieldOwner:
+		return "tasks.user_id " + compileUser(c, arg)
+
+	case filter.FieldProject:
+		if c.None {
+			return "tasks.project_id IS NULL"
+		}
+		return "tasks.project_id = " + arg(c.Value) + "::UUID"
+
+	case filter.FieldDue, filter.FieldCreated, filter.FieldUpdated, filter.FieldPoints:
+		column := filterColumns[c.Field]
+		switch {
+		case c.None:
+			return column + " IS NULL"
+		case c.Any:
+			return column + " IS NOT NULL"
+		case c.Field == filter.FieldPoints:
+			return fmt.Sprintf("%s %s %s", column, sqlOp(c.Op), arg(c.Number))
+		default:
+			return fmt.Sprintf("%s %s %s", column, sqlOp(c.Op), arg(c.Time))
+		}
+
+	case filter.FieldArchived:
+		if c.Bool {
+			return "tasks.archived_at IS NOT NULL"
+		}
+		return "tasks.archived_at IS NULL"
+
+	default:
+		panic(fmt.Sprintf("compile filter: unexpected field %q", c.Field))
+	}
+}
+
+// compileUser is the right-hand side of a comparison against the user a
+// condition names: the caller, a user id, or an email address.
+func compileUser(c filter.Cond, arg func(any) string) string {
+	switch {
+	case c.Me:
+		return "= app_current_user()"
+	case isUUID(c.Value):
+		return "= " + arg(c.Value) + "::UUID"
+	default:
+		return "IN (SELECT id FROM users WHERE email = " + arg(c.Value) + ")"
+	}
+}
+
+func isUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
+
+func sqlOp(op filter.Op) string {
+	if op == filter.OpEq {
+		return "="
+	}
+	return string(op)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/0xrishabk/tasktracker/internal/filter"
+)
+
+func TestCompileFilter(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	const (
+		text     = "tasks.search_vector @@ phraseto_tsquery('english', %s)"
+		assignee = "EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = tasks.id AND a.user_id %s)"
+		userID   = "6f1c1f8e-5b0a-4a59-9c55-6b7a3e1c2d4f"
+	)
+
+	tests := []struct {
+		query string
+		want  string
+		args  []any
+	}{
+		{
+			`status:IN_PROGRESS label:backend due<7d -assignee:me "release"`,
+			"((tasks.status = ANY($1::TEXT[]))" +
+				" AND (EXISTS (SELECT 1 FROM task_labels l WHERE l.task_id = tasks.id AND l.label = ANY($2::TEXT[])))" +
+				" AND (tasks.due_at < $3)" +
+				" AND NOT (" + fmt.Sprintf(assignee, "= app_current_user()") + ")" +
+				" AND (" + fmt.Sprintf(text, "$4") + "))",
+			[]any{pq.Array([]string{"IN_PROGRESS"}), pq.Array([]string{"backend"}), now.Add(7 * 24 * time.Hour), "release"},
+		},
+		{
+			`a b OR c`,
+			"(((" + fmt.Sprintf(text, "$1") + ") AND (" + fmt.Sprintf(text, "$2") + ")) OR (" + fmt.Sprintf(text, "$3") + "))",
+			[]any{"a", "b", "c"},
+		},
+		{
+			`-(a OR b)`,
+			"NOT ((" + fmt.Sprintf(text, "$1") + ") OR (" + fmt.Sprintf(text, "$2") + "))",
+			[]any{"a", "b"},
+		},
+		{
+			`status:DONE,TO_DO`,
+			"(tasks.status = ANY($1::TEXT[]))",
+			[]any{pq.Array([]string{"DONE", "TO_DO"})},
+		},
+		{`assignee:none`, "(NOT EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = tasks.id))", nil},
+		{`assignee:` + userID, "(" + fmt.Sprintf(assignee, "= $1::UUID") + ")", []any{userID}},
+		{`owner:me`, "(tasks.user_id = app_current_user())", nil},
+		{`owner:someone@example.com`, "(tasks.user_id IN (SELECT id FROM users WHERE email = $1))", []any{"someone@example.com"}},
+		{`project:none`, "(tasks.project_id IS NULL)", nil},
+		{`project:` + userID, "(tasks.project_id = $1::UUID)", []any{userID}},
+		{`due:none`, "(tasks.due_at IS NULL)", nil},
+		{`due:any`, "(tasks.due_at IS NOT NULL)", nil},
+		{`created>=2026-01-02`, "(tasks.created_at >= $1)", []any{time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)}},
+		{`updated<=now`, "(tasks.updated_at <= $1)", []any{now}},
+		{`points>3`, "(tasks.story_points > $1)", []any{int64(3)}},
+		{`points:5`, "(tasks.story_points = $1)", []any{int64(5)}},
+		{`points:none`, "(tasks.story_points IS NULL)", nil},
+		{`archived:true`, "(tasks.archived_at IS NOT NULL)", nil},
+		{`archived:false`, "(tasks.archived_at IS NULL)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			n, err := filter.Parse(tt.query, now)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+
+			var args []any
+			got := compileFilter(n, func(v any) string {
+				args = append(args, v)
+				return fmt.Sprintf("$%d", len(args))
+			})
+
+			if got != tt.want {
+				t.Errorf("compileFilter(%q) =\n%s\nwant\n%s", tt.query, got, tt.want)
+			}
+			if len(args) != len(tt.args) {
+				t.Fatalf("compileFilter(%q) used %d parameters, want %d", tt.query, len(args), len(tt.args))
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("compileFilter(%q) parameters = %#v, want %#v", tt.query, args, tt.args)
+			}
+		})
+	}
+}
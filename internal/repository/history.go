@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrEventNotFound = errors.New("task event not found")
+
+// TaskEvent is one recorded change to a task. Values are stored as text; an
+// event with Field "created" marks the task's creation, and one with Field
+// "labels" a label added (NewValue) or removed (OldValue).
+type TaskEvent struct {
+	ID        int64     `json:"id"`
+	TaskID    string    `json:"task_id"`
+	ActorID   *string   `json:"actor_id"`
+	ActorName *string   `json:"actor_name"`
+	Field     string    `json:"field"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetHistory lists a task's recorded changes, newest first.
+func (r *TaskRepository) GetHistory(c context.Context, taskID uuid.UUID) ([]TaskEvent, error) {
+	query := `
+			SELECT e.id, e.task_id, e.actor_id, u.username, e.field, e.old_value, e.new_value, e.created_at
+			FROM task_events e
+			LEFT JOIN users u ON u.id = e.actor_id
+			WHERE e.task_id = $1
+			ORDER BY e.id DESC
+	`
+
+	events := []TaskEvent{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, taskID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var e TaskEvent
+			if err := rows.Scan(&e.ID, &e.TaskID, &e.ActorID, &e.ActorName, &e.Field, &e.OldValue, &e.NewValue, &e.CreatedAt); err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get task history: %w", err)
+	}
+
+	return events, nil
+}
+
+// RevertTask puts the task's content back the way it was right after the
+// given event: every field changed since then gets the old value from its
+// earliest later change. Project, parent, archive and trash moves are left
+// alone since what they pointed at may be gone. The revert is itself recorded
+// as new events.
+func (r *TaskRepository) RevertTask(c context.Context, taskID uuid.UUID, eventID int64) (*Task, error) {
+	query := `
+			WITH later AS (
+				SELECT DISTINCT ON (field) field, old_value
+				FROM task_events
+				WHERE task_id = $1 AND id > $2
+				ORDER BY field, id
+			)
+			UPDATE tasks SET
+				name = COALESCE((SELECT old_value FROM later WHERE field = 'name'), name),
+				description = CASE WHEN EXISTS (SELECT 1 FROM later WHERE field = 'description')
+					THEN (SELECT old_value FROM later WHERE field = 'description') ELSE description END,
+				status = COALESCE((SELECT old_value FROM later WHERE field = 'status'), status),
+				story_points = CASE WHEN EXISTS (SELECT 1 FROM later WHERE field = 'story_points')
+					THEN (SELECT old_value FROM later WHERE field = 'story_points')::INTEGER ELSE story_points END,
+				estimate_seconds = CASE WHEN EXISTS (SELECT 1 FROM later WHERE field = 'estimate_seconds')
+					THEN (SELECT old_value FROM later WHERE field = 'estimate_seconds')::BIGINT ELSE estimate_seconds END,
+				remaining_seconds = CASE WHEN EXISTS (SELECT 1 FROM later WHERE field = 'remaining_seconds')
+					THEN (SELECT old_value FROM later WHERE field = 'remaining_seconds')::BIGINT ELSE remaining_seconds END,
+				due_at = CASE WHEN EXISTS (SELECT 1 FROM later WHERE field = 'due_at')
+					THEN (SELECT old_value FROM later WHERE field = 'due_at')::TIMESTAMPTZ ELSE due_at END,
+				updated_at = NOW()
+			WHERE
+			id = $1 AND deleted_at IS NULL AND ($3::UUID IS NULL OR workspace_id = $3)
+			AND EXISTS (SELECT 1 FROM task_events WHERE id = $2 AND task_id = $1)
+			RETURNING ` + taskColumns
+
+	task, err := r.queryTask(c, query, taskID, eventID, workspaceArg(c))
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+
+		return nil, fmt.Errorf("revert task: %w", err)
+	}
+
+	return task, nil
+}
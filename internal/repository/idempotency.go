@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// IdempotencyRecord is what was stored under an idempotency key. Status is
+// nil while the first request with the key is still being handled.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Status      *int
+	Header      http.Header
+	Body        []byte
+}
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims key for a new request. It returns nil if the claim
+// succeeded, or else the record already stored under the key. Records older
+// than ttl are treated as gone and claimed over.
+func (r *IdempotencyRepository) Reserve(c context.Context, scope, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	query := `
+			INSERT INTO idempotency_keys (scope, key, fingerprint)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (scope, key) DO UPDATE SET
+				fingerprint = EXCLUDED.fingerprint,
+				status = NULL,
+				headers = NULL,
+				body = NULL,
+				created_at = NOW(),
+				completed_at = NULL
+			WHERE idempotency_keys.created_at < NOW() - make_interval(secs => $4)
+			RETURNING TRUE
+	`
+
+	var reserved bool
+	err := r.db.QueryRowContext(c, query, scope, key, fingerprint, ttl.Seconds()).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	var (
+		rec    IdempotencyRecord
+		header []byte
+	)
+	err = r.db.QueryRowContext(c,
+		"SELECT fingerprint, status, headers, body FROM idempotency_keys WHERE scope = $1 AND key = $2",
+		scope, key,
+	).Scan(&rec.Fingerprint, &rec.Status, &header, &rec.Body)
+	if err != nil {
+		return nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+
+	if header != nil {
+		if err := json.Unmarshal(header, &rec.Header); err != nil {
+			return nil, fmt.Errorf("decode idempotent response headers: %w", err)
+		}
+	}
+
+	return &rec, nil
+}
+
+func (r *IdempotencyRepository) Complete(c context.Context, scope, key string, status int, header http.Header, body []byte) error {
+	query := `
+			UPDATE idempotency_keys SET status = $3, headers = $4, body = $5, completed_at = NOW()
+			WHERE scope = $1 AND key = $2
+	`
+
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("encode idempotent response headers: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(c, query, scope, key, status, encoded, body); err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// Release forgets a key so the request can be retried.
+func (r *IdempotencyRepository) Release(c context.Context, scope, key string) error {
+	if _, err := r.db.ExecContext(c, "DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2", scope, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) Purge(c context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(c, "DELETE FROM idempotency_keys WHERE created_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("purge idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
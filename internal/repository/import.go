@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrImportJobNotFound = errors.New("import job not found")
+
+// ImportJob is an import queued to run in the background. Processed counts
+// the tasks inserted so far by the running attempt; nothing is visible until
+// the job has succeeded.
+type ImportJob struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	WorkspaceID string     `json:"workspace_id"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Error       *string    `json:"error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// PendingImport is a claimed job together with the tasks it is to insert.
+type PendingImport struct {
+	ImportJob
+	Tasks json.RawMessage
+}
+
+type ImportRepository struct {
+	db *sql.DB
+}
+
+func NewImportRepository(db *sql.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+const importJobColumns = `
+	id, user_id, workspace_id, format, status, total, processed, error, created_at, started_at, finished_at
+`
+
+func scanImportJob(row rowScanner, extra ...any) (*ImportJob, error) {
+	var j ImportJob
+	dest := []any{
+		&j.ID,
+		&j.UserID,
+		&j.WorkspaceID,
+		&j.Format,
+		&j.Status,
+		&j.Total,
+		&j.Processed,
+		&j.Error,
+		&j.CreatedAt,
+		&j.StartedAt,
+		&j.FinishedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *ImportRepository) CreateJob(c context.Context, j *ImportJob, tasks []byte) (*ImportJob, error) {
+	query := `
+			INSERT INTO import_jobs (user_id, workspace_id, format, tasks, total)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING ` + importJobColumns
+
+	var job *ImportJob
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		job, err = scanImportJob(q.QueryRowContext(c, query, j.UserID, j.WorkspaceID, j.Format, tasks, j.Total))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create import job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *ImportRepository) GetJob(c context.Context, id, userID uuid.UUID) (*ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1 AND user_id = $2`
+
+	var job *ImportJob
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		job, err = scanImportJob(q.QueryRowContext(c, query, id, userID))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrImportJobNotFound
+		}
+		return nil, fmt.Errorf("get import job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimJob leases the oldest job that is waiting, or whose worker let its
+// lease run out. Jobs that have been tried maxAttempts times are failed
+// instead of being tried again. It returns nil when there is nothing to do.
+func (r *ImportRepository) ClaimJob(c context.Context, lease time.Duration, maxAttempts int) (*PendingImport, error) {
+	abandon := `
+			UPDATE import_jobs SET
+				status = 'failed', error = 'the import was interrupted too many times',
+				tasks = NULL, locked_until = NULL, finished_at = NOW()
+			WHERE status = 'running' AND locked_until < NOW() AND attempts >= $1
+	`
+
+	claim := `
+			UPDATE import_jobs SET
+				status = 'running', attempts = attempts + 1, processed = 0,
+				locked_until = NOW() + make_interval(secs => $1), started_at = COALESCE(started_at, NOW())
+			WHERE id = (
+				SELECT id FROM import_jobs
+				WHERE status = 'pending' OR (status = 'running' AND locked_until < NOW())
+				ORDER BY created_at
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + importJobColumns + `, tasks`
+
+	var job *PendingImport
+	err := withTenant(c, r.db, func(q querier) error {
+		if _, err := q.ExecContext(c, abandon, maxAttempts); err != nil {
+			return err
+		}
+
+		var tasks []byte
+		j, err := scanImportJob(q.QueryRowContext(c, claim, lease.Seconds()), &tasks)
+		if err != nil {
+			return err
+		}
+
+		job = &PendingImport{ImportJob: *j, Tasks: tasks}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claim import job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *ImportRepository) SetProgress(c context.Context, id uuid.UUID, processed int) error {
+	if _, err := execTenant(c, r.db, "UPDATE import_jobs SET processed = $2 WHERE id = $1", id, processed); err != nil {
+		return fmt.Errorf("set import progress: %w", err)
+	}
+
+	return nil
+}
+
+// FinishJob records the outcome of a job: succeeded when errMsg is nil,
+// failed otherwise. The tasks it held are dropped either way.
+func (r *ImportRepository) FinishJob(c context.Context, id uuid.UUID, errMsg *string) error {
+	query := `
+			UPDATE import_jobs SET
+				status = CASE WHEN $2::TEXT IS NULL THEN 'succeeded' ELSE 'failed' END,
+				processed = CASE WHEN $2::TEXT IS NULL THEN total ELSE processed END,
+				error = $2, tasks = NULL, locked_until = NULL, finished_at = NOW()
+			WHERE id = $1
+	`
+
+	if _, err := execTenant(c, r.db, query, id, errMsg); err != nil {
+		return fmt.Errorf("finish import job: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeJobs deletes jobs that finished before the given time.
+func (r *ImportRepository) PurgeJobs(c context.Context, before time.Time) (int64, error) {
+	result, err := execTenant(c, r.db, "DELETE FROM import_jobs WHERE finished_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("purge import jobs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// importBatchSize keeps a multi-row insert well under the limit of 65535
+// parameters per statement.
+const importBatchSize = 1000
+
+// CreateTasks inserts tasks in multi-row statements. Every task must come with
+// its id already set, and parents must come before their subtasks. Unlike
+// CreateTask nothing is read back.
+func (r *TaskRepository) CreateTasks(c context.Context, tasks []Task) error {
+	const columns = 12
+
+	for start := 0; start < len(tasks); start += importBatchSize {
+		batch := tasks[start:min(start+importBatchSize, len(tasks))]
+
+		values := make([]string, len(batch))
+		args := make([]any, 0, len(batch)*columns)
+		for i, t := range batch {
+			placeholders := make([]string, columns)
+			for j := range placeholders {
+				placeholders[j] = fmt.Sprintf("$%d", i*columns+j+1)
+			}
+			values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+
+			args = append(args,
+				t.ID, t.Name, t.Description, t.Status, t.UserID, t.WorkspaceID, t.ProjectID,
+				t.ParentID, t.StoryPoints, t.EstimateSeconds, t.RemainingSeconds, t.DueAt,
+			)
+		}
+
+		query := `
+				INSERT INTO tasks (id, name, description, status, user_id, workspace_id, project_id, parent_id, story_points, estimate_seconds, remaining_seconds, due_at)
+				VALUES ` + strings.Join(values, ", ")
+
+		if _, err := execTenant(c, r.db, query, args...); err != nil {
+			return fmt.Errorf("insert tasks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// AddTaskLabels adds labels to many tasks at once: labels[i] goes on
+// taskIDs[i].
+func (r *TaskRepository) AddTaskLabels(c context.Context, taskIDs, labels []string) error {
+	query := `
+			INSERT INTO task_labels (task_id, label)
+			SELECT * FROM UNNEST($1::UUID[], $2::TEXT[])
+			ON CONFLICT (task_id, label) DO NOTHING
+	`
+
+	if _, err := execTenant(c, r.db, query, pq.Array(taskIDs), pq.Array(labels)); err != nil {
+		return fmt.Errorf("add task labels: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvitationInvalid  = errors.New("invitation has expired or was already used")
+	ErrAlreadyMember      = errors.New("user is already a member of this workspace")
+)
+
+type Invitation struct {
+	ID          string     `json:"id"`
+	WorkspaceID string     `json:"workspace_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	InvitedBy   *string    `json:"invited_by"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type InvitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+const invitationColumns = `id, workspace_id, email, role, invited_by, expires_at, accepted_at, created_at`
+
+func scanInvitation(row rowScanner) (*Invitation, error) {
+	var i Invitation
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Email,
+		&i.Role,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// CreateInvitation stores an invitation keyed by the hash of its token; the
+// token itself is never persisted.
+func (r *InvitationRepository) CreateInvitation(c context.Context, i *Invitation, tokenHash string) (*Invitation, error) {
+	query := `
+			INSERT INTO workspace_invitations (workspace_id, email, role, token_hash, invited_by, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING ` + invitationColumns
+
+	created, err := scanInvitation(r.db.QueryRowContext(c, query,
+		i.WorkspaceID, strings.ToLower(i.Email), i.Role, tokenHash, i.InvitedBy, i.ExpiresAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("insert invitation: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *InvitationRepository) GetPendingInvitations(c context.Context, workspaceID uuid.UUID) ([]Invitation, error) {
+	query := `
+			SELECT ` + invitationColumns + `
+			FROM workspace_invitations
+			WHERE workspace_id = $1 AND accepted_at IS NULL AND expires_at > NOW()
+			ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(c, query, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("get pending invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []Invitation{}
+	for rows.Next() {
+		i, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get pending invitations: %w", err)
+		}
+		invitations = append(invitations, *i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get pending invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+func (r *InvitationRepository) RevokeInvitation(c context.Context, workspaceID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(c,
+		"DELETE FROM workspace_invitations WHERE id = $1 AND workspace_id = $2 AND accepted_at IS NULL",
+		id, workspaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("revoke invitation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvitation redeems the invitation with the given token hash for
+// userID and adds them to the workspace. The invitation must be unused, not
+// expired, and addressed to email. Redeeming and joining happen in one
+// transaction so a token can only ever be used once.
+func (r *InvitationRepository) AcceptInvitation(c context.Context, tokenHash string, userID uuid.UUID, email string) (*Invitation, error) {
+	tx, err := r.db.BeginTx(c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin accept invitation: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+			UPDATE workspace_invitations SET accepted_at = NOW(), accepted_by = $2
+			WHERE token_hash = $1 AND accepted_at IS NULL AND expires_at > NOW() AND email = $3
+			RETURNING ` + invitationColumns
+
+	i, err := scanInvitation(tx.QueryRowContext(c, query, tokenHash, userID, strings.ToLower(email)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, fmt.Errorf("accept invitation: %w", err)
+	}
+
+	_, err = tx.ExecContext(c,
+		"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)",
+		i.WorkspaceID, userID, i.Role,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrAlreadyMember
+		}
+		return nil, fmt.Errorf("add workspace member: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit accept invitation: %w", err)
+	}
+
+	return i, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// LabeledTask is a task read together with its labels.
+type LabeledTask struct {
+	Task
+	Labels []string
+}
+
+// labelsColumn selects a task's labels as an array, for scanning into a
+// LabeledTask.
+const labelsColumn = `COALESCE((SELECT array_agg(label ORDER BY label) FROM task_labels WHERE task_id = tasks.id), '{}')`
+
+func (r *TaskRepository) AddLabels(c context.Context, taskID uuid.UUID, labels []string) error {
+	query := `
+			INSERT INTO task_labels (task_id, label)
+			SELECT $1, UNNEST($2::TEXT[])
+			ON CONFLICT (task_id, label) DO NOTHING
+	`
+
+	if _, err := execTenant(c, r.db, query, taskID, pq.Array(labels)); err != nil {
+		return fmt.Errorf("add labels: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TaskRepository) RemoveLabels(c context.Context, taskID uuid.UUID, labels []string) error {
+	query := `DELETE FROM task_labels WHERE task_id = $1 AND label = ANY($2::TEXT[])`
+
+	if _, err := execTenant(c, r.db, query, taskID, pq.Array(labels)); err != nil {
+		return fmt.Errorf("remove labels: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TaskRepository) GetLabels(c context.Context, taskID uuid.UUID) ([]string, error) {
+	labels := []string{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, "SELECT label FROM task_labels WHERE task_id = $1 ORDER BY label", taskID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var label string
+			if err := rows.Scan(&label); err != nil {
+				return err
+			}
+			labels = append(labels, label)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get labels: %w", err)
+	}
+
+	return labels, nil
+}
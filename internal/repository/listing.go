@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/0xrishabk/tasktracker/internal/filter"
+)
+
+// TaskCursor is the position after which a task listing continues. Listings
+// are ordered by (created_at, id), or by status first when grouped by it, in
+// which case Status is the status of the task the cursor points at.
+type TaskCursor struct {
+	CreatedAt time.Time
+	ID        string
+	Status    string
+}
+
+// statusOrder ranks statuses for listings grouped by them. statusRank has to
+// agree with it.
+const statusOrder = "CASE status WHEN 'TO_DO' THEN 0 WHEN 'IN_PROGRESS' THEN 1 WHEN 'DONE' THEN 3 ELSE 2 END"
+
+func statusRank(status string) int {
+	switch status {
+	case "TO_DO":
+		return 0
+	case "IN_PROGRESS":
+		return 1
+	case "DONE":
+		return 3
+	default:
+		return 2
+	}
+}
+
+// TaskQuery narrows and pages a task listing. Zero values don't filter, and a
+// zero Limit returns every matching task.
+type TaskQuery struct {
+	Archived      ArchiveFilter
+	Filter        filter.Node
+	Statuses      []string
+	NameContains  string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	After         *TaskCursor
+	Limit         int
+	// ByStatus groups the tasks by status, open ones first: TO_DO,
+	// IN_PROGRESS, any other status, then DONE. Paging such a listing needs
+	// the Status of the After cursor.
+	ByStatus bool
+}
+
+// listTasks runs query, a task SELECT ending in a WHERE clause that uses
+// args, with the filters, ordering and limit of q appended.
+func (r *TaskRepository) listTasks(c context.Context, op, query string, q TaskQuery, args ...any) ([]Task, error) {
+	query, args = buildTaskQuery(query, q, args)
+	return r.queryTasks(c, op, query, args...)
+}
+
+// buildTaskQuery appends the filters, ordering and limit of q to query.
+func buildTaskQuery(query string, q TaskQuery, args []any) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString(query)
+
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	fmt.Fprintf(&sb, " AND (%[1]s::BOOLEAN IS NULL OR (archived_at IS NOT NULL) = %[1]s)", arg(q.Archived.arg()))
+	if len(q.Statuses) > 0 {
+		fmt.Fprintf(&sb, " AND status = ANY(%s)", arg(pq.Array(q.Statuses)))
+	}
+	if q.NameContains != "" {
+		fmt.Fprintf(&sb, " AND strpos(lower(name), lower(%s)) > 0", arg(q.NameContains))
+	}
+	if q.CreatedAfter != nil {
+		fmt.Fprintf(&sb, " AND created_at >= %s", arg(*q.CreatedAfter))
+	}
+	if q.CreatedBefore != nil {
+		fmt.Fprintf(&sb, " AND created_at < %s", arg(*q.CreatedBefore))
+	}
+	if q.UpdatedAfter != nil {
+		fmt.Fprintf(&sb, " AND updated_at >= %s", arg(*q.UpdatedAfter))
+	}
+	if q.UpdatedBefore != nil {
+		fmt.Fprintf(&sb, " AND updated_at < %s", arg(*q.UpdatedBefore))
+	}
+	if q.Filter != nil {
+		sb.WriteString(" AND " + compileFilter(q.Filter, arg))
+	}
+	if q.After != nil && q.ByStatus {
+		fmt.Fprintf(&sb, " AND (%s, status, created_at, id) > (%s::INTEGER, %s::TEXT, %s, %s::UUID)",
+			statusOrder, arg(statusRank(q.After.Status)), arg(q.After.Status), arg(q.After.CreatedAt), arg(q.After.ID))
+	} else if q.After != nil {
+		fmt.Fprintf(&sb, " AND (created_at, id) > (%s, %s::UUID)", arg(q.After.CreatedAt), arg(q.After.ID))
+	}
+
+	sb.WriteString(" ORDER BY ")
+	if q.ByStatus {
+		sb.WriteString(statusOrder + ", status, ")
+	}
+	sb.WriteString("created_at, id")
+	if q.Limit > 0 {
+		fmt.Fprintf(&sb, " LIMIT %s", arg(q.Limit))
+	}
+
+	return sb.String(), args
+}
+
+// visibleTasks limits a task query to what user $1 can see: the whole
+// workspace $2 when the request is scoped to one, else the tasks they own,
+// are assigned to, watch or have had shared with them.
+const visibleTasks = `
+	deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+	AND (
+		$2::UUID IS NOT NULL
+		OR user_id = $1
+		OR id IN (SELECT task_id FROM task_assignees WHERE user_id = $1)
+		OR id IN (SELECT task_id FROM task_watchers WHERE user_id = $1)
+		OR id IN (SELECT task_id FROM task_shares WHERE user_id = $1)
+		OR project_id IN (SELECT project_id FROM project_shares WHERE user_id = $1)
+	)
+`
+
+// GetVisibleTasks lists every task userID can see that matches q.
+func (r *TaskRepository) GetVisibleTasks(c context.Context, userID uuid.UUID, q TaskQuery) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + visibleTasks
+
+	return r.listTasks(c, "get visible tasks", query, q, userID, workspaceArg(c))
+}
+
+// eachTaskPage is how many tasks EachVisibleTask reads per transaction.
+const eachTaskPage = 500
+
+// EachVisibleTask calls fn with every task userID can see that matches q,
+// along with its labels, a page at a time rather than all at once. Each page
+// is read in a transaction of its own that is over before fn sees any of it,
+// so however slowly fn goes no connection or snapshot is held meanwhile. A
+// task changed part way through may therefore show up as it was before or
+// after the change, or, when grouped by a status it moves out of, twice or
+// not at all.
+func (r *TaskRepository) EachVisibleTask(c context.Context, userID uuid.UUID, q TaskQuery, fn func(t *LabeledTask) error) error {
+	base := `SELECT ` + taskColumns + `, ` + labelsColumn + ` FROM tasks WHERE ` + visibleTasks
+	q.After, q.Limit = nil, eachTaskPage
+
+	for {
+		query, args := buildTaskQuery(base, q, []any{userID, workspaceArg(c)})
+
+		var page []LabeledTask
+		err := withTenant(c, r.db, func(tx querier) error {
+			rows, err := tx.QueryContext(c, query, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var labels []string
+				t, err := scanTask(rows, pq.Array(&labels))
+				if err != nil {
+					return err
+				}
+				page = append(page, LabeledTask{Task: *t, Labels: labels})
+			}
+
+			return rows.Err()
+		})
+		if err != nil {
+			return fmt.Errorf("each visible task: %w", err)
+		}
+
+		for i := range page {
+			if err := fn(&page[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < eachTaskPage {
+			return nil
+		}
+		last := page[len(page)-1]
+		q.After = &TaskCursor{CreatedAt: last.CreatedAt, ID: last.ID, Status: last.Status}
+	}
+}
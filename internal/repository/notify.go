@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// listen holds a connection that LISTENs on channel and calls fn with the
+// payload of every notification, and once with an empty payload as soon as it
+// is listening. It returns when c is done or the connection fails.
+func listen(c context.Context, db *sql.DB, channel string, fn func(payload string)) error {
+	conn, err := db.Conn(c)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", channel, err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(dc any) error {
+		pc := dc.(*stdlib.Conn).Conn()
+
+		if _, err := pc.Exec(c, "LISTEN "+channel); err != nil {
+			return fmt.Errorf("listen on %s: %w", channel, err)
+		}
+		fn("")
+
+		for {
+			n, err := pc.WaitForNotification(c)
+			if err != nil {
+				// Don't hand a connection that may still be listening back
+				// to the pool.
+				if _, uerr := pc.Exec(context.Background(), "UNLISTEN *"); uerr != nil {
+					return driver.ErrBadConn
+				}
+				return fmt.Errorf("wait on %s: %w", channel, err)
+			}
+			fn(n.Payload)
+		}
+	})
+}
+
+// notify sends payload to everyone listening on channel, when the
+// transaction in c commits if there is one.
+func notify(c context.Context, db *sql.DB, channel, payload string) error {
+	_, err := execTenant(c, db, "SELECT pg_notify($1, $2)", channel, payload)
+	if err != nil {
+		return fmt.Errorf("notify %s: %w", channel, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/0xrishabk/tasktracker/internal/events"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// InTx runs fn in a single transaction shared by every repository call made
+// with the context it is given, so a change and its events commit together.
+func (r *OutboxRepository) InTx(c context.Context, fn func(c context.Context) error) error {
+	return inTx(c, r.db, fn)
+}
+
+// Append records ev, joining the transaction in c if there is one. The
+// caller picks the ID; seq and the time come from the database.
+func (r *OutboxRepository) Append(c context.Context, ev events.Event) error {
+	query := `
+			INSERT INTO outbox_events (id, type, aggregate_id, workspace_ids, user_id, data)
+			VALUES ($1, $2, $3, COALESCE($4::UUID[], '{}'), NULLIF($5, '')::UUID, $6)
+	`
+
+	_, err := execTenant(c, r.db, query,
+		ev.ID, ev.Type, ev.AggregateID, pq.Array(ev.WorkspaceIDs), ev.UserID, string(ev.Data),
+	)
+	if err != nil {
+		return fmt.Errorf("append outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// LockDispatch takes the lock that keeps two dispatchers from publishing at
+// once, for as long as the transaction in c lasts. It reports false if
+// another dispatcher holds it.
+func (r *OutboxRepository) LockDispatch(c context.Context) (bool, error) {
+	var locked bool
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, "SELECT pg_try_advisory_xact_lock(hashtext('outbox_dispatch'))").Scan(&locked)
+	})
+	if err != nil {
+		return false, fmt.Errorf("lock outbox dispatch: %w", err)
+	}
+
+	return locked, nil
+}
+
+// GetDue lists unpublished events that are due, in seq order. An event is
+// held back while an earlier one about the same aggregate waits for a retry,
+// which keeps each aggregate's events in order.
+func (r *OutboxRepository) GetDue(c context.Context, limit int) ([]events.Event, error) {
+	query := `
+			SELECT e.seq, e.id, e.type, e.aggregate_id, e.workspace_ids, COALESCE(e.user_id::TEXT, ''), e.data, e.created_at
+			FROM outbox_events e
+			WHERE e.published_at IS NULL AND e.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events b
+				WHERE b.aggregate_id = e.aggregate_id AND b.published_at IS NULL
+				AND b.seq < e.seq AND b.next_attempt_at > NOW()
+			)
+			ORDER BY e.seq
+			LIMIT $1
+	`
+
+	due := []events.Event{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var ev events.Event
+			var data []byte
+			err := rows.Scan(&ev.Seq, &ev.ID, &ev.Type, &ev.AggregateID, pq.Array(&ev.WorkspaceIDs), &ev.UserID, &data, &ev.CreatedAt)
+			if err != nil {
+				return err
+			}
+			ev.Data = data
+			due = append(due, ev)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get due outbox events: %w", err)
+	}
+
+	return due, nil
+}
+
+func (r *OutboxRepository) MarkPublished(c context.Context, seqs []int64) error {
+	if len(seqs) == 0 {
+		return nil
+	}
+
+	_, err := execTenant(c, r.db,
+		"UPDATE outbox_events SET published_at = NOW() WHERE seq = ANY($1)",
+		pq.Array(seqs),
+	)
+	if err != nil {
+		return fmt.Errorf("mark outbox events published: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed counts a failed attempt and holds the event back for a while,
+// doubling from a second after the first failure up to ten minutes.
+func (r *OutboxRepository) MarkFailed(c context.Context, seq int64, cause error) error {
+	query := `
+			UPDATE outbox_events SET
+				attempts = attempts + 1,
+				last_error = $2,
+				next_attempt_at = NOW() + LEAST(INTERVAL '1 second' * power(2, LEAST(attempts, 10)), INTERVAL '10 minutes')
+			WHERE seq = $1
+	`
+
+	_, err := execTenant(c, r.db, query, seq, cause.Error())
+	if err != nil {
+		return fmt.Errorf("mark outbox event failed: %w", err)
+	}
+
+	return nil
+}
+
+// Purge deletes events published before the given time.
+func (r *OutboxRepository) Purge(c context.Context, before time.Time) (int64, error) {
+	result, err := execTenant(c, r.db, "DELETE FROM outbox_events WHERE published_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("purge outbox events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// presenceChannel carries presence and typing notices between servers.
+const presenceChannel = "presence"
+
+// PresenceRepository passes presence notices between server instances. They
+// aren't stored anywhere; each server keeps what it hears in memory.
+type PresenceRepository struct {
+	db *sql.DB
+}
+
+func NewPresenceRepository(db *sql.DB) *PresenceRepository {
+	return &PresenceRepository{db: db}
+}
+
+// Announce sends a notice to every server, this one included. Notices must
+// stay under Postgres' 8000 byte payload limit.
+func (r *PresenceRepository) Announce(c context.Context, notice []byte) error {
+	return notify(c, r.db, presenceChannel, string(notice))
+}
+
+// Listen calls fn with every notice announced, and once with nil as soon as
+// it is listening. It returns when c is done or the connection fails.
+func (r *PresenceRepository) Listen(c context.Context, fn func(notice []byte)) error {
+	return listen(c, r.db, presenceChannel, func(payload string) {
+		if payload == "" {
+			fn(nil)
+			return
+		}
+		fn([]byte(payload))
+	})
+}
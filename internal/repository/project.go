@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrProjectNotFound = errors.New("project not found")
+
+type Project struct {
+	ID          string    `json:"id"`
+	WorkspaceID string    `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   *string   `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ProjectRepository struct {
+	db *sql.DB
+}
+
+func NewProjectRepository(db *sql.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+const projectColumns = `id, workspace_id, name, description, created_by, created_at, updated_at`
+
+func scanProject(row rowScanner) (*Project, error) {
+	var p Project
+	err := row.Scan(
+		&p.ID,
+		&p.WorkspaceID,
+		&p.Name,
+		&p.Description,
+		&p.CreatedBy,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// queryProject runs a single-row project query under the tenant settings from c.
+func (r *ProjectRepository) queryProject(c context.Context, query string, args ...any) (*Project, error) {
+	var p *Project
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		p, err = scanProject(q.QueryRowContext(c, query, args...))
+		return err
+	})
+	return p, err
+}
+
+func (r *ProjectRepository) CreateProject(c context.Context, p *Project) (*Project, error) {
+	query := `
+			INSERT INTO projects (workspace_id, name, description, created_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING ` + projectColumns
+
+	created, err := r.queryProject(c, query, p.WorkspaceID, p.Name, p.Description, p.CreatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("insert project: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *ProjectRepository) GetProjectByID(c context.Context, workspaceID, id uuid.UUID) (*Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1 AND workspace_id = $2`
+
+	p, err := r.queryProject(c, query, id, workspaceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("get project by id: %w", err)
+	}
+
+	return p, nil
+}
+
+// GetProjectByIDUnscoped looks a project up without knowing its workspace,
+// for callers that reach it some other way, such as a share link.
+func (r *ProjectRepository) GetProjectByIDUnscoped(c context.Context, id uuid.UUID) (*Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
+
+	p, err := r.queryProject(c, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("get project by id: %w", err)
+	}
+
+	return p, nil
+}
+
+func (r *ProjectRepository) GetProjects(c context.Context, workspaceID uuid.UUID) ([]Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE workspace_id = $1 ORDER BY name`
+
+	projects := []Project{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, workspaceID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			p, err := scanProject(rows)
+			if err != nil {
+				return err
+			}
+			projects = append(projects, *p)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+func (r *ProjectRepository) UpdateProject(c context.Context, workspaceID, id uuid.UUID, name, description *string) (*Project, error) {
+	query := `
+			UPDATE projects SET
+				name = COALESCE($1, name),
+				description = COALESCE($2, description),
+				updated_at = NOW()
+			WHERE id = $3 AND workspace_id = $4
+			RETURNING ` + projectColumns
+
+	p, err := r.queryProject(c, query, name, description, id, workspaceID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("update project: %w", err)
+	}
+
+	return p, nil
+}
+
+func (r *ProjectRepository) DeleteProject(c context.Context, workspaceID, id uuid.UUID) error {
+	result, err := execTenant(c, r.db, "DELETE FROM projects WHERE id = $1 AND workspace_id = $2", id, workspaceID)
+	if err != nil {
+		return fmt.Errorf("delete project: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type (
+	userKey      struct{}
+	workspaceKey struct{}
+	systemKey    struct{}
+	txKey        struct{}
+)
+
+// WithUser records the authenticated user that row-level security policies
+// should evaluate queries made with the returned context against.
+func WithUser(c context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(c, userKey{}, userID)
+}
+
+func UserFromContext(c context.Context) (uuid.UUID, bool) {
+	id, ok := c.Value(userKey{}).(uuid.UUID)
+	return id, ok
+}
+
+// WithWorkspace scopes every task query made with the returned context to a
+// single workspace.
+func WithWorkspace(c context.Context, workspaceID uuid.UUID) context.Context {
+	return context.WithValue(c, workspaceKey{}, workspaceID)
+}
+
+func WorkspaceFromContext(c context.Context) (uuid.UUID, bool) {
+	id, ok := c.Value(workspaceKey{}).(uuid.UUID)
+	return id, ok
+}
+
+// WithSystem marks work that isn't done on behalf of any one user, such as
+// background jobs, so that row-level security lets it see every tenant.
+func WithSystem(c context.Context) context.Context {
+	return context.WithValue(c, systemKey{}, true)
+}
+
+// workspaceArg is the query argument for the "($n::UUID IS NULL OR
+// workspace_id = $n)" filter: NULL when the request isn't workspace-scoped.
+func workspaceArg(c context.Context) any {
+	if id, ok := WorkspaceFromContext(c); ok {
+		return id
+	}
+	return nil
+}
+
+type querier interface {
+	ExecContext(c context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(c context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(c context.Context, query string, args ...any) *sql.Row
+}
+
+// withTenant runs fn in a transaction whose row-level security settings
+// (app.user_id, app.workspace_id, app.bypass_rls) come from c. The settings
+// are transaction-local, so nothing leaks back into the pool. Without a user
+// or the system marker the policies hide every tenant's rows.
+func withTenant(c context.Context, db *sql.DB, fn func(q querier) error) error {
+	// Inside inTx everything joins the transaction that is already open.
+	if q, ok := c.Value(txKey{}).(querier); ok {
+		return fn(q)
+	}
+
+	tx, err := db.BeginTx(c, nil)
+	if err != nil {
+		return fmt.Errorf("begin tenant transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID, workspaceID, bypass string
+	if id, ok := UserFromContext(c); ok {
+		userID = id.String()
+	}
+	if id, ok := WorkspaceFromContext(c); ok {
+		workspaceID = id.String()
+	}
+	if system, _ := c.Value(systemKey{}).(bool); system {
+		bypass = "on"
+	}
+
+	_, err = tx.ExecContext(c,
+		"SELECT set_config('app.user_id', $1, true), set_config('app.workspace_id', $2, true), set_config('app.bypass_rls', $3, true)",
+		userID, workspaceID, bypass,
+	)
+	if err != nil {
+		return fmt.Errorf("set tenant: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// execTenant runs a single statement under the tenant settings from c.
+func execTenant(c context.Context, db *sql.DB, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := withTenant(c, db, func(q querier) (err error) {
+		result, err = q.ExecContext(c, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// inTx runs fn with a context that makes every repository call share one
+// tenant transaction, committed only if fn returns nil.
+func inTx(c context.Context, db *sql.DB, fn func(c context.Context) error) error {
+	return withTenant(c, db, func(q querier) error {
+		return fn(context.WithValue(c, txKey{}, q))
+	})
+}
+
+// Savepoint runs fn inside a savepoint of the transaction opened by InTx, so
+// that if fn fails only its own work is undone and the transaction carries on.
+// Outside a transaction it just runs fn.
+func Savepoint(c context.Context, fn func(c context.Context) error) error {
+	q, ok := c.Value(txKey{}).(querier)
+	if !ok {
+		return fn(c)
+	}
+
+	if _, err := q.ExecContext(c, "SAVEPOINT item"); err != nil {
+		return fmt.Errorf("savepoint: %w", err)
+	}
+
+	if err := fn(c); err != nil {
+		if _, rerr := q.ExecContext(c, "ROLLBACK TO SAVEPOINT item"); rerr != nil {
+			return fmt.Errorf("rollback to savepoint: %w", rerr)
+		}
+		return err
+	}
+
+	if _, err := q.ExecContext(c, "RELEASE SAVEPOINT item"); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+	return nil
+}
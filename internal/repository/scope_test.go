@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testDB opens the database named by TEST_CONNECTION_STRING, which must have
+// every migration applied and be reached as a role without BYPASSRLS, the way
+// the server reaches it. Without it the test is skipped.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_CONNECTION_STRING")
+	if dsn == "" {
+		t.Skip("TEST_CONNECTION_STRING is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping database: %v", err)
+	}
+	return db
+}
+
+type tenant struct {
+	userID, workspaceID, taskID uuid.UUID
+}
+
+// createTenant adds a user, whose personal workspace comes from a trigger,
+// with a task in that workspace that has an attachment, a label, a comment, a
+// share, a public link and the events the task triggers write.
+func createTenant(t *testing.T, c context.Context, db *sql.DB) tenant {
+	t.Helper()
+
+	var tn tenant
+	suffix := uuid.NewString()
+	err := withTenant(WithSystem(c), db, func(q querier) error {
+		if err := q.QueryRowContext(c,
+			"INSERT INTO users (username, email) VALUES ($1, $2) RETURNING id",
+			"rls-"+suffix, "rls-"+suffix+"@example.com",
+		).Scan(&tn.userID); err != nil {
+			return err
+		}
+
+		if err := q.QueryRowContext(c,
+			"SELECT id FROM workspaces WHERE personal AND created_by = $1", tn.userID,
+		).Scan(&tn.workspaceID); err != nil {
+			return err
+		}
+
+		if err := q.QueryRowContext(c,
+			"INSERT INTO tasks (name, status, user_id, workspace_id) VALUES ('secret', 'TO_DO', $1, $2) RETURNING id",
+			tn.userID, tn.workspaceID,
+		).Scan(&tn.taskID); err != nil {
+			return err
+		}
+
+		var friendID uuid.UUID
+		if err := q.QueryRowContext(c,
+			"INSERT INTO users (username, email) VALUES ($1, $2) RETURNING id",
+			"rls-friend-"+suffix, "rls-friend-"+suffix+"@example.com",
+		).Scan(&friendID); err != nil {
+			return err
+		}
+
+		for _, stmt := range []struct {
+			query string
+			args  []any
+		}{
+			{`INSERT INTO task_attachments (task_id, user_id, filename, size_bytes, content_type, sha256, storage_key)
+				VALUES ($1, $2, 'secret.txt', 6, 'text/plain', '', $3)`, []any{tn.taskID, tn.userID, "rls/" + suffix}},
+			{"INSERT INTO task_labels (task_id, label) VALUES ($1, 'secret')", []any{tn.taskID}},
+			{"INSERT INTO task_comments (task_id, user_id, body) VALUES ($1, $2, 'secret')", []any{tn.taskID, tn.userID}},
+			{"INSERT INTO task_shares (task_id, user_id, permission, shared_by) VALUES ($1, $2, 'viewer', $3)", []any{tn.taskID, friendID, tn.userID}},
+			{"INSERT INTO share_links (task_id, token_hash, created_by) VALUES ($1, $2, $3)", []any{tn.taskID, "rls-" + suffix, tn.userID}},
+		} {
+			if _, err := q.ExecContext(c, stmt.query, stmt.args...); err != nil {
+				return err
+			}
+		}
+
+		t.Cleanup(func() {
+			_, err := execTenant(WithSystem(context.Background()), db, "DELETE FROM users WHERE id IN ($1, $2)", tn.userID, friendID)
+			if err != nil {
+				t.Errorf("clean up tenant: %v", err)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+
+	return tn
+}
+
+// TestWithTenantHidesOtherTenants runs queries that deliberately leave out any
+// user or workspace filter, as a forgotten WHERE clause would, and checks that
+// row-level security alone keeps another tenant's rows out.
+func TestWithTenantHidesOtherTenants(t *testing.T) {
+	db := testDB(t)
+	c := context.Background()
+
+	a := createTenant(t, c, db)
+	b := createTenant(t, c, db)
+
+	queries := map[string]string{
+		"tasks":       "SELECT id FROM tasks",
+		"attachments": "SELECT task_id FROM task_attachments",
+		"labels":      "SELECT task_id FROM task_labels",
+		"comments":    "SELECT task_id FROM task_comments",
+		"shares":      "SELECT task_id FROM task_shares",
+		"links":       "SELECT task_id FROM share_links",
+		"events":      "SELECT task_id FROM task_events",
+	}
+
+	scopes := map[string]context.Context{
+		"user":               WithUser(c, a.userID),
+		"own workspace":      WithWorkspace(WithUser(c, a.userID), a.workspaceID),
+		"other's workspace":  WithWorkspace(WithUser(c, a.userID), b.workspaceID),
+		"no user":            c,
+		"no user, workspace": WithWorkspace(c, b.workspaceID),
+	}
+
+	for scope, sc := range scopes {
+		for table, query := range queries {
+			t.Run(scope+"/"+table, func(t *testing.T) {
+				var seen []uuid.UUID
+				err := withTenant(sc, db, func(q querier) error {
+					rows, err := q.QueryContext(sc, query)
+					if err != nil {
+						return err
+					}
+					defer rows.Close()
+
+					for rows.Next() {
+						var id uuid.UUID
+						if err := rows.Scan(&id); err != nil {
+							return err
+						}
+						seen = append(seen, id)
+					}
+					return rows.Err()
+				})
+				if err != nil {
+					t.Fatalf("query: %v", err)
+				}
+
+				sawOwn := false
+				for _, id := range seen {
+					if id == b.taskID {
+						t.Fatalf("saw a row of user B's task %s", b.taskID)
+					}
+					sawOwn = sawOwn || id == a.taskID
+				}
+
+				// Make sure the query isn't just coming back empty: user A
+				// still sees their own rows whenever they are in scope.
+				_, signedIn := UserFromContext(sc)
+				ws, scoped := WorkspaceFromContext(sc)
+				if wantOwn := signedIn && (!scoped || ws == a.workspaceID); sawOwn != wantOwn {
+					t.Errorf("saw user A's own rows = %v, want %v", sawOwn, wantOwn)
+				}
+			})
+		}
+	}
+}
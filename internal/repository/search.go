@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Highlighted fragments of a search hit are wrapped in these markers, which
+// can't appear in task text typed by a user, so callers can escape the text
+// and mark the matches up safely afterwards.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+type TaskSearchHit struct {
+	Task
+	Rank          float64
+	NameHighlight string
+	Snippet       string
+}
+
+// SearchTasks ranks the tasks userID can see against tsquery, a to_tsquery
+// expression. The snippet is taken from the description and the comments.
+func (r *TaskRepository) SearchTasks(c context.Context, userID uuid.UUID, tsquery string, archived ArchiveFilter, limit int) ([]TaskSearchHit, error) {
+	query := `
+		SELECT ` + taskColumns + `, ts_rank(search_vector, q) AS rank,
+			ts_headline('english', name, q, $5),
+			ts_headline('english', concat_ws(E'\n', description, (
+				SELECT string_agg(body, E'\n' ORDER BY created_at) FROM task_comments WHERE task_id = tasks.id
+			)), q, $5::TEXT || ', MaxFragments=2')
+		FROM tasks, to_tsquery('english', $3) q
+		WHERE search_vector @@ q AND ` + visibleTasks + `
+		AND ($4::BOOLEAN IS NULL OR (archived_at IS NOT NULL) = $4)
+		ORDER BY rank DESC, created_at DESC
+		LIMIT $6
+	`
+	options := fmt.Sprintf(`StartSel="%s", StopSel="%s"`, HighlightStart, HighlightStop)
+
+	hits := []TaskSearchHit{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, userID, workspaceArg(c), tsquery, archived.arg(), options, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var h TaskSearchHit
+			err := rows.Scan(
+				&h.ID,
+				&h.Name,
+				&h.Description,
+				&h.Status,
+				&h.UserID,
+				&h.WorkspaceID,
+				&h.ProjectID,
+				&h.ParentID,
+				&h.StoryPoints,
+				&h.EstimateSeconds,
+				&h.RemainingSeconds,
+				&h.DueAt,
+				&h.Version,
+				&h.CreatedAt,
+				&h.UpdatedAt,
+				&h.ArchivedAt,
+				&h.DeletedAt,
+				&h.Rank,
+				&h.NameHighlight,
+				&h.Snippet,
+			)
+			if err != nil {
+				return err
+			}
+			hits = append(hits, h)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search tasks: %w", err)
+	}
+
+	return hits, nil
+}
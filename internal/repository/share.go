@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrShareNotFound     = errors.New("share not found")
+	ErrShareLinkNotFound = errors.New("share link not found")
+)
+
+type Share struct {
+	UserID     string    `json:"user_id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	Permission string    `json:"permission"`
+	SharedBy   *string   `json:"shared_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ShareLink struct {
+	ID           string     `json:"id"`
+	TaskID       *string    `json:"task_id"`
+	ProjectID    *string    `json:"project_id"`
+	PasswordHash *string    `json:"-"`
+	HasPassword  bool       `json:"has_password"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedBy    *string    `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type ShareRepository struct {
+	db *sql.DB
+}
+
+func NewShareRepository(db *sql.DB) *ShareRepository {
+	return &ShareRepository{db: db}
+}
+
+// shareTables maps the kind of shared resource to its table and key column.
+var shareTables = map[string][2]string{
+	"task":    {"task_shares", "task_id"},
+	"project": {"project_shares", "project_id"},
+}
+
+// UpsertShare grants userID the permission on a task or project, replacing
+// whatever permission they had before.
+func (r *ShareRepository) UpsertShare(c context.Context, kind string, id, userID uuid.UUID, permission string, sharedBy uuid.UUID) error {
+	t := shareTables[kind]
+	query := fmt.Sprintf(`
+			INSERT INTO %[1]s (%[2]s, user_id, permission, shared_by)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (%[2]s, user_id) DO UPDATE SET permission = EXCLUDED.permission, shared_by = EXCLUDED.shared_by
+	`, t[0], t[1])
+
+	if _, err := execTenant(c, r.db, query, id, userID, permission, sharedBy); err != nil {
+		return fmt.Errorf("upsert %s share: %w", kind, err)
+	}
+
+	return nil
+}
+
+func (r *ShareRepository) RemoveShare(c context.Context, kind string, id, userID uuid.UUID) error {
+	t := shareTables[kind]
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 AND user_id = $2", t[0], t[1])
+
+	result, err := execTenant(c, r.db, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("remove %s share: %w", kind, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrShareNotFound
+	}
+
+	return nil
+}
+
+func (r *ShareRepository) GetShares(c context.Context, kind string, id uuid.UUID) ([]Share, error) {
+	t := shareTables[kind]
+	query := fmt.Sprintf(`
+			SELECT u.id, u.username, u.email, s.permission, s.shared_by, s.created_at
+			FROM %s s
+			JOIN users u ON u.id = s.user_id
+			WHERE s.%s = $1
+			ORDER BY s.created_at
+	`, t[0], t[1])
+
+	shares := []Share{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var s Share
+			if err := rows.Scan(&s.UserID, &s.Username, &s.Email, &s.Permission, &s.SharedBy, &s.CreatedAt); err != nil {
+				return err
+			}
+			shares = append(shares, s)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get %s shares: %w", kind, err)
+	}
+
+	return shares, nil
+}
+
+const shareLinkColumns = `
+	id, task_id, project_id, password_hash, password_hash IS NOT NULL,
+	expires_at, revoked_at, created_by, created_at
+`
+
+func scanShareLink(row rowScanner) (*ShareLink, error) {
+	var l ShareLink
+	err := row.Scan(
+		&l.ID,
+		&l.TaskID,
+		&l.ProjectID,
+		&l.PasswordHash,
+		&l.HasPassword,
+		&l.ExpiresAt,
+		&l.RevokedAt,
+		&l.CreatedBy,
+		&l.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// queryLink runs a single-row link query under the tenant settings from c.
+func (r *ShareRepository) queryLink(c context.Context, query string, args ...any) (*ShareLink, error) {
+	var l *ShareLink
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		l, err = scanShareLink(q.QueryRowContext(c, query, args...))
+		return err
+	})
+	return l, err
+}
+
+// CreateLink stores a public link keyed by the hash of its token; the token
+// itself is never persisted.
+func (r *ShareRepository) CreateLink(c context.Context, l *ShareLink, tokenHash string) (*ShareLink, error) {
+	query := `
+			INSERT INTO share_links (task_id, project_id, token_hash, password_hash, expires_at, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING ` + shareLinkColumns
+
+	created, err := r.queryLink(c, query,
+		l.TaskID, l.ProjectID, tokenHash, l.PasswordHash, l.ExpiresAt, l.CreatedBy,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert share link: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *ShareRepository) GetLinks(c context.Context, kind string, id uuid.UUID) ([]ShareLink, error) {
+	query := `
+			SELECT ` + shareLinkColumns + `
+			FROM share_links
+			WHERE ` + shareTables[kind][1] + ` = $1
+			ORDER BY created_at
+	`
+
+	links := []ShareLink{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			l, err := scanShareLink(rows)
+			if err != nil {
+				return err
+			}
+			links = append(links, *l)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get share links: %w", err)
+	}
+
+	return links, nil
+}
+
+func (r *ShareRepository) RevokeLink(c context.Context, kind string, id, linkID uuid.UUID) error {
+	query := `
+			UPDATE share_links SET revoked_at = NOW()
+			WHERE id = $1 AND ` + shareTables[kind][1] + ` = $2 AND revoked_at IS NULL
+	`
+
+	result, err := execTenant(c, r.db, query, linkID, id)
+	if err != nil {
+		return fmt.Errorf("revoke share link: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrShareLinkNotFound
+	}
+
+	return nil
+}
+
+// GetActiveLink looks up a link by token hash, ignoring links that were
+// revoked or have expired. Nobody is signed in when a link is opened, so c
+// must be a system context.
+func (r *ShareRepository) GetActiveLink(c context.Context, tokenHash string) (*ShareLink, error) {
+	query := `
+			SELECT ` + shareLinkColumns + `
+			FROM share_links
+			WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	l, err := r.queryLink(c, query, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, fmt.Errorf("get share link: %w", err)
+	}
+
+	return l, nil
+}
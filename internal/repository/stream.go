@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// streamChannel is the LISTEN/NOTIFY channel that announces new stream events.
+const streamChannel = "task_stream"
+
+// TaskStreamEvent is one entry in the live task stream. Audience lists the
+// users who may see it.
+type TaskStreamEvent struct {
+	ID          int64           `json:"id"`
+	Event       string          `json:"event"`
+	TaskID      string          `json:"task_id"`
+	WorkspaceID *string         `json:"workspace_id"`
+	Audience    []string        `json:"-"`
+	Data        json.RawMessage `json:"data"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type StreamRepository struct {
+	db *sql.DB
+}
+
+func NewStreamRepository(db *sql.DB) *StreamRepository {
+	return &StreamRepository{db: db}
+}
+
+const streamEventColumns = `id, event, task_id, workspace_id, audience, data, created_at`
+
+func scanStreamEvent(row rowScanner) (*TaskStreamEvent, error) {
+	var e TaskStreamEvent
+	var data []byte
+	err := row.Scan(&e.ID, &e.Event, &e.TaskID, &e.WorkspaceID, pq.Array(&e.Audience), &data, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	e.Data = data
+	return &e, nil
+}
+
+// Record logs a task event and notifies every listening server once the
+// transaction in c commits.
+func (r *StreamRepository) Record(c context.Context, event string, taskID uuid.UUID, workspaceID, ownerID *uuid.UUID, data json.RawMessage) error {
+	err := withTenant(c, r.db, func(q querier) error {
+		var id int64
+		return q.QueryRowContext(c,
+			"SELECT record_task_stream_event($1, $2, $3, $4, $5)",
+			event, taskID, workspaceID, ownerID, string(data),
+		).Scan(&id)
+	})
+	if err != nil {
+		return fmt.Errorf("record task stream event: %w", err)
+	}
+
+	return nil
+}
+
+// LatestID is the id of the newest stream event, or 0 if there are none.
+func (r *StreamRepository) LatestID(c context.Context) (int64, error) {
+	var id int64
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, "SELECT COALESCE(MAX(id), 0) FROM task_stream_events").Scan(&id)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get latest task stream event: %w", err)
+	}
+
+	return id, nil
+}
+
+// OldestID is the id of the oldest stream event still kept, or 0 if there
+// are none.
+func (r *StreamRepository) OldestID(c context.Context) (int64, error) {
+	var id int64
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, "SELECT COALESCE(MIN(id), 0) FROM task_stream_events").Scan(&id)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get oldest task stream event: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetSince lists stream events after the given id, oldest first. With a user
+// it only lists those the user is in the audience of, and with a workspace
+// only those in it.
+func (r *StreamRepository) GetSince(c context.Context, afterID int64, userID, workspaceID *uuid.UUID, limit int) ([]TaskStreamEvent, error) {
+	query := `
+			SELECT ` + streamEventColumns + `
+			FROM task_stream_events
+			WHERE id > $1 AND ($2::UUID IS NULL OR $2 = ANY(audience))
+			AND ($3::UUID IS NULL OR workspace_id = $3)
+			ORDER BY id
+			LIMIT $4
+	`
+
+	list := []TaskStreamEvent{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, afterID, userID, workspaceID, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			e, err := scanStreamEvent(rows)
+			if err != nil {
+				return err
+			}
+			list = append(list, *e)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get task stream events: %w", err)
+	}
+
+	return list, nil
+}
+
+// Purge deletes stream events logged before the given time.
+func (r *StreamRepository) Purge(c context.Context, before time.Time) (int64, error) {
+	result, err := execTenant(c, r.db, "DELETE FROM task_stream_events WHERE created_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("purge task stream events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// Listen calls notify for every announcement of new stream events, and once
+// as soon as it is listening so the caller can catch up on anything it missed
+// before. It returns when c is done or the connection fails.
+func (r *StreamRepository) Listen(c context.Context, notify func()) error {
+	return listen(c, r.db, streamChannel, func(string) { notify() })
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ChangeSet is one page of changes for a sync. Token is the change token to
+// pass as since next time: every change made after it was taken has a later
+// one.
+type ChangeSet struct {
+	Tasks   []LabeledTask
+	Deleted []string
+	Token   string
+}
+
+// GetChangesSince lists, in id order and after the given task id, the tasks
+// changed at or after the since token, or every live task when since is nil.
+// Tasks in the trash are included so their deletion can be reported; tasks
+// deleted for good come back as ids in Deleted, on the first page only.
+//
+// Tokens are transaction ids rather than timestamps. A token is the oldest
+// transaction still running when it was taken, so a change committed after
+// one sync started can never hide behind it; the cost is that a change may
+// be handed out twice.
+func (r *TaskRepository) GetChangesSince(c context.Context, since *string, after *uuid.UUID, limit int) (*ChangeSet, error) {
+	query := `
+			SELECT ` + taskColumns + `, ` + labelsColumn + `
+			FROM tasks
+			WHERE (
+				($1::xid8 IS NULL AND deleted_at IS NULL)
+				OR id IN (SELECT task_id FROM task_events WHERE xid >= $1::xid8)
+			)
+			AND ($2::UUID IS NULL OR workspace_id = $2)
+			AND ($3::UUID IS NULL OR id > $3)
+			ORDER BY id
+			LIMIT $4
+	`
+
+	set := &ChangeSet{Tasks: []LabeledTask{}, Deleted: []string{}}
+	err := withTenant(c, r.db, func(q querier) error {
+		err := q.QueryRowContext(c, "SELECT pg_snapshot_xmin(pg_current_snapshot())::TEXT").Scan(&set.Token)
+		if err != nil {
+			return err
+		}
+
+		rows, err := q.QueryContext(c, query, since, workspaceArg(c), after, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var labels []string
+			t, err := scanTask(rows, pq.Array(&labels))
+			if err != nil {
+				return err
+			}
+			set.Tasks = append(set.Tasks, LabeledTask{Task: *t, Labels: labels})
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if since == nil || after != nil {
+			return nil
+		}
+
+		rows, err = q.QueryContext(c,
+			"SELECT task_id FROM task_tombstones WHERE xid >= $1::xid8 AND ($2::UUID IS NULL OR workspace_id = $2) ORDER BY task_id",
+			since, workspaceArg(c),
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			set.Deleted = append(set.Deleted, id)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get changes since: %w", err)
+	}
+
+	return set, nil
+}
+
+// SyncToken is the change token GetChangesSince would hand out right now.
+func (r *TaskRepository) SyncToken(c context.Context) (string, error) {
+	var token string
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, "SELECT pg_snapshot_xmin(pg_current_snapshot())::TEXT").Scan(&token)
+	})
+	if err != nil {
+		return "", fmt.Errorf("get sync token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ChangedFieldsSince reports which of the given fields of a task were changed
+// at or after the since token, with when each last changed. Changes made by
+// the transactions in ignore don't count.
+func (r *TaskRepository) ChangedFieldsSince(c context.Context, taskID uuid.UUID, since string, fields, ignore []string) (map[string]time.Time, error) {
+	query := `
+			SELECT field, MAX(created_at)
+			FROM task_events
+			WHERE task_id = $1 AND xid >= $2::xid8 AND field = ANY($3::TEXT[])
+			AND NOT (xid = ANY(COALESCE($4::xid8[], '{}')))
+			GROUP BY field
+	`
+
+	changed := map[string]time.Time{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, taskID, since, pq.Array(fields), pq.Array(ignore))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var field string
+			var at time.Time
+			if err := rows.Scan(&field, &at); err != nil {
+				return err
+			}
+			changed[field] = at
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get changed fields: %w", err)
+	}
+
+	return changed, nil
+}
+
+// CurrentTxID is the id of the transaction in c, as ChangedFieldsSince takes
+// it to ignore.
+func (r *TaskRepository) CurrentTxID(c context.Context) (string, error) {
+	var id string
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, "SELECT pg_current_xact_id()::TEXT").Scan(&id)
+	})
+	if err != nil {
+		return "", fmt.Errorf("get transaction id: %w", err)
+	}
+
+	return id, nil
+}
@@ -5,19 +5,36 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrVersionConflict = errors.New("task was changed by someone else")
+)
+
 type Task struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	UserID      string    `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Status           string     `json:"status"`
+	UserID           string     `json:"user_id"`
+	WorkspaceID      string     `json:"workspace_id"`
+	ProjectID        *string    `json:"project_id"`
+	ParentID         *string    `json:"parent_id"`
+	StoryPoints      *int       `json:"story_points"`
+	EstimateSeconds  *int64     `json:"estimate_seconds"`
+	RemainingSeconds *int64     `json:"remaining_seconds"`
+	DueAt            *time.Time `json:"due_at"`
+	Version          int64      `json:"version"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	ArchivedAt       *time.Time `json:"archived_at"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
 }
 
 type TaskRepository struct {
@@ -28,87 +45,154 @@ func NewTaskRepository(db *sql.DB) *TaskRepository {
 	return &TaskRepository{db: db}
 }
 
+const taskColumns = `
+	id, name, COALESCE(description, ''), status, user_id, workspace_id, project_id,
+	parent_id, story_points, estimate_seconds, remaining_seconds, due_at, version, created_at, updated_at, archived_at, deleted_at
+`
+
+// scanTask reads the columns in taskColumns, followed by any extra columns the
+// query selects into the given destinations.
+func scanTask(row rowScanner, extra ...any) (*Task, error) {
+	var t Task
+	dest := []any{
+		&t.ID,
+		&t.Name,
+		&t.Description,
+		&t.Status,
+		&t.UserID,
+		&t.WorkspaceID,
+		&t.ProjectID,
+		&t.ParentID,
+		&t.StoryPoints,
+		&t.EstimateSeconds,
+		&t.RemainingSeconds,
+		&t.DueAt,
+		&t.Version,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.ArchivedAt,
+		&t.DeletedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
 func (r *TaskRepository) GetTaskByID(c context.Context, taskID uuid.UUID) (*Task, error) {
 	query := `
-			SELECT id, name, description, status, created_at, updated_at
+			SELECT ` + taskColumns + `
 			FROM tasks
-			WHERE id = $1
+			WHERE id = $1 AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
 	`
-	var task Task
-	err := r.db.QueryRowContext(c, query, taskID).Scan(
-		&task.ID,
-		&task.Name,
-		&task.Description,
-		&task.Status,
-		&task.CreatedAt,
-		&task.Description,
-	)
+	task, err := r.queryTask(c, query, taskID, workspaceArg(c))
 
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
 		return nil, fmt.Errorf("get task by id: %v", err)
 	}
 
-	return &task, nil
+	return task, nil
 }
 
-func (r *TaskRepository) GetTasks(c context.Context) ([]Task, error) {
+// GetTasks lists every task in scope, optionally narrowed to a single project.
+func (r *TaskRepository) GetTasks(c context.Context, projectID *uuid.UUID, q TaskQuery) ([]Task, error) {
 	query := `
-			SELECT id, name, description, status, created_at, updated_at
+			SELECT ` + taskColumns + `
 			FROM tasks
+			WHERE deleted_at IS NULL AND ($1::UUID IS NULL OR workspace_id = $1)
+			AND ($2::UUID IS NULL OR project_id = $2)
 	`
 
-	var tasks []Task
+	return r.listTasks(c, "get tasks", query, q, workspaceArg(c), projectID)
+}
 
-	rows, err := r.db.QueryContext(c, query)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("get tasks: %v", err)
-	}
-	defer rows.Close()
+func (r *TaskRepository) GetTasksByUserID(c context.Context, userID uuid.UUID, q TaskQuery) ([]Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE user_id = $1 AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+	`
+	return r.listTasks(c, "get tasks by user id", query, q, userID, workspaceArg(c))
+}
 
-	for rows.Next() {
-		var t Task
-		if err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.Status, &t.CreatedAt, &t.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("get tasks: %v", err)
-		}
-		tasks = append(tasks, t)
-	}
+func (r *TaskRepository) GetTasksByAssignee(c context.Context, userID uuid.UUID, q TaskQuery) ([]Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE id IN (SELECT task_id FROM task_assignees WHERE user_id = $1)
+		AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+	`
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("get tasks: %v", err)
-	}
-	return tasks, nil
+	return r.listTasks(c, "get tasks by assignee", query, q, userID, workspaceArg(c))
 }
 
-func (r *TaskRepository) GetTasksByUserID(c context.Context, userID uuid.UUID) ([]Task, error) {
+func (r *TaskRepository) GetTasksByWatcher(c context.Context, userID uuid.UUID, q TaskQuery) ([]Task, error) {
 	query := `
-		SELECT id, name, description, status, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
-		WHERE user_id = $1
+		WHERE id IN (SELECT task_id FROM task_watchers WHERE user_id = $1)
+		AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
 	`
-	var tasks []Task
 
-	rows, err := r.db.QueryContext(c, query, userID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("get tasks by user id: %v", err)
-	}
-	defer rows.Close()
+	return r.listTasks(c, "get tasks by watcher", query, q, userID, workspaceArg(c))
+}
 
-	for rows.Next() {
-		var t Task
-		if err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.Status, &t.CreatedAt, &t.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("get tasks by user id: %v", err)
+// GetTasksBySharedUser lists tasks shared with the user, directly or through
+// their project.
+func (r *TaskRepository) GetTasksBySharedUser(c context.Context, userID uuid.UUID, q TaskQuery) ([]Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE (
+			id IN (SELECT task_id FROM task_shares WHERE user_id = $1)
+			OR project_id IN (SELECT project_id FROM project_shares WHERE user_id = $1)
+		)
+		AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+	`
+
+	return r.listTasks(c, "get tasks by shared user", query, q, userID, workspaceArg(c))
+}
+
+// InTx runs fn in a single transaction shared by every repository call made
+// with the context it is given.
+func (r *TaskRepository) InTx(c context.Context, fn func(c context.Context) error) error {
+	return inTx(c, r.db, fn)
+}
+
+// queryTask runs a single-row task query under the tenant settings from c.
+func (r *TaskRepository) queryTask(c context.Context, query string, args ...any) (*Task, error) {
+	var task *Task
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		task, err = scanTask(q.QueryRowContext(c, query, args...))
+		return err
+	})
+	return task, err
+}
+
+func (r *TaskRepository) queryTasks(c context.Context, op, query string, args ...any) ([]Task, error) {
+	tasks := []Task{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			t, err := scanTask(rows)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, *t)
 		}
-		tasks = append(tasks, t)
-	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("get tasks by user id: %v", err)
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
 	}
 
 	return tasks, nil
@@ -116,114 +200,150 @@ func (r *TaskRepository) GetTasksByUserID(c context.Context, userID uuid.UUID) (
 
 func (r *TaskRepository) CreateTask(c context.Context, task *Task) (*Task, error) {
 	query := `
-			INSERT INTO tasks (name, description, status, user_id)
-			VALUES ($1, $2, $3, $4)
-			RETURNING id, created_at, updated_at
-	`
+			INSERT INTO tasks (id, name, description, status, user_id, workspace_id, project_id, parent_id, story_points, estimate_seconds, remaining_seconds, due_at)
+			VALUES (COALESCE($1::UUID, gen_random_uuid()), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			RETURNING ` + taskColumns
+
+	// Offline clients pick the ids of the tasks they create themselves.
+	var id *string
+	if task.ID != "" {
+		id = &task.ID
+	}
 
-	err := r.db.QueryRowContext(c, query, task.Name, task.Description, task.Status, task.UserID).Scan(
-		&task.ID,
-		&task.CreatedAt,
-		&task.UpdatedAt,
+	created, err := r.queryTask(c, query,
+		id, task.Name, task.Description, task.Status, task.UserID, task.WorkspaceID, task.ProjectID,
+		task.ParentID, task.StoryPoints, task.EstimateSeconds, task.RemainingSeconds, task.DueAt,
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("insert task: %v", err)
 	}
 
-	return task, nil
+	return created, nil
 }
 
-func (r *TaskRepository) UpdateName(c context.Context, taskID uuid.UUID, name string) (*Task, error) {
-	query := `
-			UPDATE tasks SET name = $1, updated_at = NOW()
-			WHERE
-			id = $2
-			RETURNING id, name, description, status, created_at, updated_at
-	`
-	var task Task
-	err := r.db.QueryRowContext(c, query, name, taskID).Scan(
-		&task.ID,
-		&task.Name,
-		&task.Description,
-		&task.Status,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+// TaskUpdate maps the columns UpdateTask should change to their new values. A
+// nil value sets the column to NULL.
+type TaskUpdate map[string]any
+
+var updatableTaskColumns = map[string]bool{
+	"name":              true,
+	"description":       true,
+	"status":            true,
+	"project_id":        true,
+	"parent_id":         true,
+	"story_points":      true,
+	"estimate_seconds":  true,
+	"remaining_seconds": true,
+	"due_at":            true,
+}
 
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("no task found")
+// UpdateTask applies all the changes in a single statement. Given a version,
+// it only goes through if the task is still at that version and reports
+// ErrVersionConflict otherwise.
+func (r *TaskRepository) UpdateTask(c context.Context, taskID uuid.UUID, changes TaskUpdate, version *int64) (*Task, error) {
+	columns := make([]string, 0, len(changes))
+	for column := range changes {
+		if !updatableTaskColumns[column] {
+			return nil, fmt.Errorf("update task: unknown column %q", column)
 		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
 
-		return nil, err
+	sets := make([]string, 0, len(columns)+1)
+	args := make([]any, 0, len(columns)+3)
+	for _, column := range columns {
+		args = append(args, changes[column])
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
 	}
+	sets = append(sets, "updated_at = NOW()")
 
-	return &task, nil
-}
+	args = append(args, taskID, workspaceArg(c), version)
+	n := len(args)
 
-func (r *TaskRepository) UpdateDescription(c context.Context, taskID uuid.UUID, desc string) (*Task, error) {
-	query := `
-			UPDATE tasks SET description = $1, updated_at = NOW()
+	query := fmt.Sprintf(`
+			UPDATE tasks SET %s
 			WHERE
-			id = $2
-			RETURNING id, name, description, status, created_at, updated_at
-	`
+			id = $%d AND deleted_at IS NULL AND ($%d::UUID IS NULL OR workspace_id = $%d)
+			AND ($%d::BIGINT IS NULL OR version = $%d)
+			RETURNING `, strings.Join(sets, ", "), n-2, n-1, n-1, n, n) + taskColumns
+
+	var task *Task
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		task, err = scanTask(q.QueryRowContext(c, query, args...))
+		if !errors.Is(err, sql.ErrNoRows) || version == nil {
+			return err
+		}
 
-	var task Task
-	err := r.db.QueryRowContext(c, query, desc, taskID).Scan(
-		&task.ID,
-		&task.Name,
-		&task.Description,
-		&task.Status,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+		// Tell a stale version apart from a task that is gone.
+		var exists bool
+		err = q.QueryRowContext(c,
+			"SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND deleted_at IS NULL AND ($2::UUID IS NULL OR workspace_id = $2))",
+			taskID, workspaceArg(c),
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrVersionConflict
+		}
+		return sql.ErrNoRows
+	})
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("no task found")
+			return nil, ErrTaskNotFound
+		}
+		if errors.Is(err, ErrVersionConflict) {
+			return nil, err
 		}
 
-		return nil, err
+		return nil, fmt.Errorf("update task: %v", err)
 	}
 
-	return &task, nil
+	return task, nil
 }
 
-func (r *TaskRepository) UpdateStatus(c context.Context, taskID uuid.UUID, status string) (*Task, error) {
+// IsDescendant reports whether candidate sits somewhere below taskID in the
+// subtask tree (or is taskID itself).
+func (r *TaskRepository) IsDescendant(c context.Context, taskID, candidate uuid.UUID) (bool, error) {
 	query := `
-			UPDATE tasks SET status = $1, updated_at = NOW()
-			WHERE
-			id = $2
-			RETURNING id, name, description, status, created_at, updated_at
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM tasks WHERE id = $1 AND deleted_at IS NULL AND ($3::UUID IS NULL OR workspace_id = $3)
+				UNION
+				SELECT t.id FROM tasks t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+			)
+			SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)
 	`
 
-	var task Task
-	err := r.db.QueryRowContext(c, query, status, taskID).Scan(
-		&task.ID,
-		&task.Name,
-		&task.Description,
-		&task.Status,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
-
+	var found bool
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, query, taskID, candidate, workspaceArg(c)).Scan(&found)
+	})
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("no task found")
-		}
-
-		return nil, err
+		return false, fmt.Errorf("check subtask tree: %v", err)
 	}
 
-	return &task, nil
+	return found, nil
 }
 
-func (r *TaskRepository) DeleteTask(c context.Context, taskID uuid.UUID) error {
-	result, err := r.db.ExecContext(c, "DELETE FROM tasks WHERE id = $1", taskID)
+// TrashTask moves a task and its subtasks to the trash. They all share one
+// deleted_at, which is how RestoreTask knows to bring them back together.
+func (r *TaskRepository) TrashTask(c context.Context, taskID, userID uuid.UUID) error {
+	query := `
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM tasks WHERE id = $1 AND deleted_at IS NULL AND ($3::UUID IS NULL OR workspace_id = $3)
+				UNION
+				SELECT t.id FROM tasks t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+			)
+			UPDATE tasks SET deleted_at = NOW(), deleted_by = $2
+			WHERE id IN (SELECT id FROM subtree)
+	`
+
+	result, err := execTenant(c, r.db, query, taskID, userID, workspaceArg(c))
 	if err != nil {
-		return fmt.Errorf("delete user: %v", err)
+		return fmt.Errorf("trash task: %v", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -232,7 +352,7 @@ func (r *TaskRepository) DeleteTask(c context.Context, taskID uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("task not found")
+		return ErrTaskNotFound
 	}
 
 	return nil
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrTimerRunning      = errors.New("a timer is already running")
+	ErrNoRunningTimer    = errors.New("no timer is running")
+	ErrTimeEntryNotFound = errors.New("time entry not found")
+)
+
+type TimeEntry struct {
+	ID              string     `json:"id"`
+	TaskID          string     `json:"task_id"`
+	UserID          string     `json:"user_id"`
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	Note            string     `json:"note"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type TimeTotal struct {
+	Key     string `json:"key"`
+	Label   string `json:"label"`
+	Seconds int64  `json:"seconds"`
+}
+
+type TimesheetCell struct {
+	TaskID   string    `json:"task_id"`
+	TaskName string    `json:"task_name"`
+	Day      time.Time `json:"day"`
+	Seconds  int64     `json:"seconds"`
+}
+
+type TimeEntryRepository struct {
+	db *sql.DB
+}
+
+func NewTimeEntryRepository(db *sql.DB) *TimeEntryRepository {
+	return &TimeEntryRepository{db: db}
+}
+
+// Running timers count up to the current moment.
+const timeEntryColumns = `
+	id, task_id, user_id, started_at, ended_at,
+	EXTRACT(EPOCH FROM (COALESCE(ended_at, NOW()) - started_at))::BIGINT,
+	note, created_at, updated_at
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTimeEntry(row rowScanner) (*TimeEntry, error) {
+	var e TimeEntry
+	err := row.Scan(
+		&e.ID,
+		&e.TaskID,
+		&e.UserID,
+		&e.StartedAt,
+		&e.EndedAt,
+		&e.DurationSeconds,
+		&e.Note,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// queryEntry runs a single-row time entry query under the tenant settings from c.
+func (r *TimeEntryRepository) queryEntry(c context.Context, query string, args ...any) (*TimeEntry, error) {
+	var e *TimeEntry
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		e, err = scanTimeEntry(q.QueryRowContext(c, query, args...))
+		return err
+	})
+	return e, err
+}
+
+func (r *TimeEntryRepository) StartTimer(c context.Context, taskID, userID uuid.UUID, note string) (*TimeEntry, error) {
+	query := `
+			INSERT INTO time_entries (task_id, user_id, started_at, note)
+			VALUES ($1, $2, NOW(), $3)
+			RETURNING ` + timeEntryColumns
+
+	e, err := r.queryEntry(c, query, taskID, userID, note)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrTimerRunning
+		}
+		return nil, fmt.Errorf("start timer: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) StopTimer(c context.Context, userID uuid.UUID) (*TimeEntry, error) {
+	query := `
+			UPDATE time_entries SET ended_at = NOW(), updated_at = NOW()
+			WHERE user_id = $1 AND ended_at IS NULL
+			RETURNING ` + timeEntryColumns
+
+	e, err := r.queryEntry(c, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRunningTimer
+		}
+		return nil, fmt.Errorf("stop timer: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) GetRunningTimer(c context.Context, userID uuid.UUID) (*TimeEntry, error) {
+	query := `SELECT ` + timeEntryColumns + ` FROM time_entries WHERE user_id = $1 AND ended_at IS NULL`
+
+	e, err := r.queryEntry(c, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get running timer: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) CreateEntry(c context.Context, e *TimeEntry) (*TimeEntry, error) {
+	query := `
+			INSERT INTO time_entries (task_id, user_id, started_at, ended_at, note)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING ` + timeEntryColumns
+
+	created, err := r.queryEntry(c, query, e.TaskID, e.UserID, e.StartedAt, e.EndedAt, e.Note)
+	if err != nil {
+		return nil, fmt.Errorf("insert time entry: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *TimeEntryRepository) GetEntryByID(c context.Context, id uuid.UUID) (*TimeEntry, error) {
+	query := `SELECT ` + timeEntryColumns + ` FROM time_entries WHERE id = $1`
+
+	e, err := r.queryEntry(c, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTimeEntryNotFound
+		}
+		return nil, fmt.Errorf("get time entry by id: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) GetEntriesByTaskID(c context.Context, taskID uuid.UUID) ([]TimeEntry, error) {
+	query := `SELECT ` + timeEntryColumns + ` FROM time_entries WHERE task_id = $1 ORDER BY started_at`
+
+	entries := []TimeEntry{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, taskID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			e, err := scanTimeEntry(rows)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, *e)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get time entries by task id: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *TimeEntryRepository) UpdateEntry(c context.Context, id uuid.UUID, startedAt time.Time, endedAt *time.Time, note string) (*TimeEntry, error) {
+	query := `
+			UPDATE time_entries SET started_at = $1, ended_at = $2, note = $3, updated_at = NOW()
+			WHERE id = $4
+			RETURNING ` + timeEntryColumns
+
+	e, err := r.queryEntry(c, query, startedAt, endedAt, note, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTimeEntryNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrTimerRunning
+		}
+		return nil, fmt.Errorf("update time entry: %w", err)
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) DeleteEntry(c context.Context, id uuid.UUID) error {
+	result, err := execTenant(c, r.db, "DELETE FROM time_entries WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("delete time entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrTimeEntryNotFound
+	}
+
+	return nil
+}
+
+// GetTotalsByTask sums the user's tracked time per task for entries started in [from, to).
+func (r *TimeEntryRepository) GetTotalsByTask(c context.Context, userID uuid.UUID, from, to time.Time) ([]TimeTotal, error) {
+	query := `
+			SELECT t.id::TEXT, t.name,
+				SUM(EXTRACT(EPOCH FROM (COALESCE(e.ended_at, NOW()) - e.started_at)))::BIGINT
+			FROM time_entries e
+			JOIN tasks t ON t.id = e.task_id
+			WHERE e.user_id = $1 AND e.started_at >= $2 AND e.started_at < $3 AND t.deleted_at IS NULL
+			GROUP BY t.id, t.name
+			ORDER BY t.name
+	`
+
+	return r.queryTotals(c, "get time totals by task", query, userID, from, to)
+}
+
+// GetTotalsByProject sums the user's tracked time per project for entries
+// started in [from, to). Time on tasks outside any project is keyed "".
+func (r *TimeEntryRepository) GetTotalsByProject(c context.Context, userID uuid.UUID, from, to time.Time) ([]TimeTotal, error) {
+	query := `
+			SELECT COALESCE(p.id::TEXT, ''), COALESCE(p.name, 'No project'),
+				SUM(EXTRACT(EPOCH FROM (COALESCE(e.ended_at, NOW()) - e.started_at)))::BIGINT
+			FROM time_entries e
+			JOIN tasks t ON t.id = e.task_id
+			LEFT JOIN projects p ON p.id = t.project_id
+			WHERE e.user_id = $1 AND e.started_at >= $2 AND e.started_at < $3 AND t.deleted_at IS NULL
+			GROUP BY p.id, p.name
+			ORDER BY p.name NULLS LAST
+	`
+
+	return r.queryTotals(c, "get time totals by project", query, userID, from, to)
+}
+
+// GetTotalsByDay sums the user's tracked time per calendar day in tz, keyed
+// by the day each entry started.
+func (r *TimeEntryRepository) GetTotalsByDay(c context.Context, userID uuid.UUID, from, to time.Time, tz string) ([]TimeTotal, error) {
+	query := `
+			SELECT d::TEXT, TO_CHAR(d, 'Dy'), s
+			FROM (
+				SELECT (e.started_at AT TIME ZONE $4)::DATE AS d,
+					SUM(EXTRACT(EPOCH FROM (COALESCE(e.ended_at, NOW()) - e.started_at)))::BIGINT AS s
+				FROM time_entries e
+				JOIN tasks t ON t.id = e.task_id
+				WHERE e.user_id = $1 AND e.started_at >= $2 AND e.started_at < $3 AND t.deleted_at IS NULL
+				GROUP BY 1
+			) totals
+			ORDER BY d
+	`
+
+	return r.queryTotals(c, "get time totals by day", query, userID, from, to, tz)
+}
+
+func (r *TimeEntryRepository) queryTotals(c context.Context, op, query string, args ...any) ([]TimeTotal, error) {
+	totals := []TimeTotal{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var t TimeTotal
+			if err := rows.Scan(&t.Key, &t.Label, &t.Seconds); err != nil {
+				return err
+			}
+			totals = append(totals, t)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return totals, nil
+}
+
+// GetTimesheet returns one cell per (task, day in tz) with tracked time for
+// entries started in [from, to).
+func (r *TimeEntryRepository) GetTimesheet(c context.Context, userID uuid.UUID, from, to time.Time, tz string) ([]TimesheetCell, error) {
+	query := `
+			SELECT t.id, t.name, (e.started_at AT TIME ZONE $4)::DATE,
+				SUM(EXTRACT(EPOCH FROM (COALESCE(e.ended_at, NOW()) - e.started_at)))::BIGINT
+			FROM time_entries e
+			JOIN tasks t ON t.id = e.task_id
+			WHERE e.user_id = $1 AND e.started_at >= $2 AND e.started_at < $3 AND t.deleted_at IS NULL
+			GROUP BY t.id, t.name, 3
+			ORDER BY t.name, 3
+	`
+
+	var cells []TimesheetCell
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, userID, from, to, tz)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var cell TimesheetCell
+			if err := rows.Scan(&cell.TaskID, &cell.TaskName, &cell.Day, &cell.Seconds); err != nil {
+				return err
+			}
+			cells = append(cells, cell)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get timesheet: %w", err)
+	}
+
+	return cells, nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetTrash lists the trashed tasks the user owns or trashed themselves. Only
+// the task that was trashed is listed, not the subtasks that went with it.
+func (r *TaskRepository) GetTrash(c context.Context, userID uuid.UUID) ([]Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks t
+		WHERE t.deleted_at IS NOT NULL
+		AND (t.user_id = $1 OR t.deleted_by = $1)
+		AND ($2::UUID IS NULL OR t.workspace_id = $2)
+		AND NOT EXISTS (
+			SELECT 1 FROM tasks p WHERE p.id = t.parent_id AND p.deleted_at = t.deleted_at
+		)
+		ORDER BY t.deleted_at DESC
+	`
+
+	return r.queryTasks(c, "get trash", query, userID, workspaceArg(c))
+}
+
+// RestoreTask brings a trashed task back along with the subtasks that were
+// trashed with it. If its parent is still in the trash the task is restored
+// as a top-level task instead.
+func (r *TaskRepository) RestoreTask(c context.Context, taskID uuid.UUID) (*Task, error) {
+	query := `
+			WITH RECURSIVE subtree AS (
+				SELECT id, deleted_at FROM tasks
+				WHERE id = $1 AND deleted_at IS NOT NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+				UNION
+				SELECT t.id, t.deleted_at FROM tasks t JOIN subtree s ON t.parent_id = s.id
+				WHERE t.deleted_at = s.deleted_at
+			)
+			UPDATE tasks SET
+				deleted_at = NULL,
+				deleted_by = NULL,
+				parent_id = CASE
+					WHEN id = $1 AND EXISTS (
+						SELECT 1 FROM tasks p WHERE p.id = tasks.parent_id AND p.deleted_at IS NOT NULL
+					) THEN NULL
+					ELSE parent_id
+				END,
+				updated_at = NOW()
+			WHERE id IN (SELECT id FROM subtree)
+	`
+
+	result, err := execTenant(c, r.db, query, taskID, workspaceArg(c))
+	if err != nil {
+		return nil, fmt.Errorf("restore task: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("get rows effected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrTaskNotFound
+	}
+
+	return r.GetTaskByID(c, taskID)
+}
+
+// DeleteTrashedTask permanently deletes a trashed task and everything below
+// it. It returns the storage keys of the attachments that went with them so
+// the caller can remove the blobs.
+func (r *TaskRepository) DeleteTrashedTask(c context.Context, taskID uuid.UUID) ([]string, error) {
+	keysQuery := `
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM tasks
+				WHERE id = $1 AND deleted_at IS NOT NULL AND ($2::UUID IS NULL OR workspace_id = $2)
+				UNION
+				SELECT t.id FROM tasks t JOIN subtree s ON t.parent_id = s.id
+			)
+			SELECT storage_key FROM task_attachments WHERE task_id IN (SELECT id FROM subtree)
+	`
+	deleteQuery := `DELETE FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL AND ($2::UUID IS NULL OR workspace_id = $2)`
+
+	var keys []string
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		keys, err = storageKeys(c, q, keysQuery, taskID, workspaceArg(c))
+		if err != nil {
+			return err
+		}
+
+		result, err := q.ExecContext(c, deleteQuery, taskID, workspaceArg(c))
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrTaskNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("delete trashed task: %v", err)
+	}
+
+	return keys, nil
+}
+
+// PurgeTrash permanently deletes every task in scope that was trashed before
+// cutoff. Like DeleteTrashedTask it returns the attachment storage keys.
+func (r *TaskRepository) PurgeTrash(c context.Context, cutoff time.Time) (int64, []string, error) {
+	keysQuery := `
+			SELECT a.storage_key FROM task_attachments a
+			JOIN tasks t ON t.id = a.task_id
+			WHERE t.deleted_at < $1
+	`
+
+	var (
+		purged int64
+		keys   []string
+	)
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		keys, err = storageKeys(c, q, keysQuery, cutoff)
+		if err != nil {
+			return err
+		}
+
+		result, err := q.ExecContext(c, "DELETE FROM tasks WHERE deleted_at < $1", cutoff)
+		if err != nil {
+			return err
+		}
+
+		purged, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("purge trash: %v", err)
+	}
+
+	return purged, keys, nil
+}
+
+func storageKeys(c context.Context, q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
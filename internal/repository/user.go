@@ -39,6 +39,7 @@ func (r *UserRepository) GetUserByID(c context.Context, id uuid.UUID) (*User, er
 	err := r.db.QueryRowContext(c, query, id).Scan(
 		&user.ID,
 		&user.Username,
+		&user.Email,
 		&user.PasswordHash,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -86,6 +87,7 @@ func (r *UserRepository) GetUserByEmail(c context.Context, email string) (*User,
 	err := r.db.QueryRowContext(c, query, email).Scan(
 		&user.ID,
 		&user.Username,
+		&user.Email,
 		&user.PasswordHash,
 		&user.CreatedAt,
 		&user.UpdatedAt,
@@ -107,12 +109,14 @@ func (r *UserRepository) CreateUser(c context.Context, user *User) (*User, error
 			VALUES ($1, $2, $3)
 			RETURNING id, created_at, updated_at
 	`
-	err := r.db.QueryRowContext(
-		c, query, user.Username, user.Email, user.PasswordHash).Scan(
-		&user.ID,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(
+			c, query, user.Username, user.Email, user.PasswordHash).Scan(
+			&user.ID,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+	})
 
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
@@ -134,7 +138,7 @@ func (r *UserRepository) CountUsers(c context.Context) (int, error) {
 }
 
 func (r *UserRepository) DeleteUser(c context.Context, id uuid.UUID) error {
-	result, err := r.db.ExecContext(c, "DELETE FROM users WHERE id = $1", id)
+	result, err := execTenant(c, r.db, "DELETE FROM users WHERE id = $1", id)
 	if err != nil {
 		return fmt.Errorf("delete user: %w", err)
 	}
@@ -160,14 +164,16 @@ func (r *UserRepository) UpdateUsername(c context.Context, id uuid.UUID, usernam
 	`
 
 	var user User
-	err := r.db.QueryRowContext(c, query, username, id).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c, query, username, id).Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.PasswordHash,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+	})
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -181,3 +187,24 @@ func (r *UserRepository) UpdateUsername(c context.Context, id uuid.UUID, usernam
 
 	return &user, nil
 }
+
+// GetAutoArchiveDays returns how many days a user's finished tasks are kept
+// before they are archived automatically, or nil if they haven't opted in.
+func (r *UserRepository) GetAutoArchiveDays(c context.Context, userID uuid.UUID) (*int, error) {
+	var days *int
+	err := r.db.QueryRowContext(c, "SELECT auto_archive_days FROM users WHERE id = $1", userID).Scan(&days)
+	if err != nil {
+		return nil, fmt.Errorf("get auto archive days: %w", err)
+	}
+
+	return days, nil
+}
+
+func (r *UserRepository) SetAutoArchiveDays(c context.Context, userID uuid.UUID, days *int) error {
+	_, err := r.db.ExecContext(c, "UPDATE users SET auto_archive_days = $1, updated_at = NOW() WHERE id = $2", days, userID)
+	if err != nil {
+		return fmt.Errorf("set auto archive days: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrViewNotFound  = errors.New("view not found")
+	ErrViewNameTaken = errors.New("you already have a view with this name")
+)
+
+type SavedView struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	WorkspaceID *string   `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Query       string    `json:"query"`
+	Shared      bool      `json:"shared"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ViewRepository struct {
+	db *sql.DB
+}
+
+func NewViewRepository(db *sql.DB) *ViewRepository {
+	return &ViewRepository{db: db}
+}
+
+const viewColumns = `id, user_id, workspace_id, name, query, shared, created_at, updated_at`
+
+func scanView(row rowScanner) (*SavedView, error) {
+	var v SavedView
+	err := row.Scan(
+		&v.ID,
+		&v.UserID,
+		&v.WorkspaceID,
+		&v.Name,
+		&v.Query,
+		&v.Shared,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// queryView runs a single-row view query under the tenant settings from c.
+func (r *ViewRepository) queryView(c context.Context, query string, args ...any) (*SavedView, error) {
+	var v *SavedView
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		v, err = scanView(q.QueryRowContext(c, query, args...))
+		return err
+	})
+	return v, err
+}
+
+func viewError(op string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrViewNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrViewNameTaken
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func (r *ViewRepository) CreateView(c context.Context, v *SavedView) (*SavedView, error) {
+	query := `
+			INSERT INTO saved_views (user_id, workspace_id, name, query, shared)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING ` + viewColumns
+
+	created, err := r.queryView(c, query, v.UserID, v.WorkspaceID, v.Name, v.Query, v.Shared)
+	if err != nil {
+		return nil, viewError("insert view", err)
+	}
+
+	return created, nil
+}
+
+func (r *ViewRepository) GetViewByID(c context.Context, id uuid.UUID) (*SavedView, error) {
+	v, err := r.queryView(c, `SELECT `+viewColumns+` FROM saved_views WHERE id = $1`, id)
+	if err != nil {
+		return nil, viewError("get view by id", err)
+	}
+
+	return v, nil
+}
+
+// GetViews lists the user's own views and the views shared in workspaces they
+// belong to, narrowed to the request's workspace if it is scoped to one.
+func (r *ViewRepository) GetViews(c context.Context, userID uuid.UUID) ([]SavedView, error) {
+	query := `
+			SELECT ` + viewColumns + `
+			FROM saved_views
+			WHERE (
+				user_id = $1
+				OR (shared AND workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = $1))
+			)
+			AND ($2::UUID IS NULL OR workspace_id = $2)
+			ORDER BY name, id
+	`
+
+	views := []SavedView{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, userID, workspaceArg(c))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			v, err := scanView(rows)
+			if err != nil {
+				return err
+			}
+			views = append(views, *v)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get views: %w", err)
+	}
+
+	return views, nil
+}
+
+// UpdateView replaces the view's fields, provided userID owns it.
+func (r *ViewRepository) UpdateView(c context.Context, v *SavedView) (*SavedView, error) {
+	query := `
+			UPDATE saved_views SET workspace_id = $3, name = $4, query = $5, shared = $6, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+			RETURNING ` + viewColumns
+
+	updated, err := r.queryView(c, query, v.ID, v.UserID, v.WorkspaceID, v.Name, v.Query, v.Shared)
+	if err != nil {
+		return nil, viewError("update view", err)
+	}
+
+	return updated, nil
+}
+
+func (r *ViewRepository) DeleteView(c context.Context, id, userID uuid.UUID) error {
+	result, err := execTenant(c, r.db, "DELETE FROM saved_views WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("delete view: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrViewNotFound
+	}
+
+	return nil
+}
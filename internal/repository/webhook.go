@@ -0,0 +1,447 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/0xrishabk/tasktracker/internal/events"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+type Webhook struct {
+	ID                  string     `json:"id"`
+	UserID              string     `json:"user_id"`
+	WorkspaceID         *string    `json:"workspace_id"`
+	URL                 string     `json:"url"`
+	Secret              string     `json:"secret,omitempty"`
+	Events              []string   `json:"events"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus *int            `json:"response_status"`
+	ResponseBody   *string         `json:"response_body"`
+	Error          *string         `json:"error"`
+	DurationMS     *int            `json:"duration_ms"`
+	CreatedAt      time.Time       `json:"created_at"`
+	CompletedAt    *time.Time      `json:"completed_at"`
+}
+
+// PendingDelivery is a claimed delivery together with where to send it.
+type PendingDelivery struct {
+	ID        string
+	WebhookID string
+	Event     string
+	Payload   []byte
+	Attempts  int
+	URL       string
+	Secret    string
+}
+
+// DeliveryAttempt is the outcome of one try at sending a delivery. Error is
+// set when no response came back.
+type DeliveryAttempt struct {
+	Status   *int
+	Body     *string
+	Error    *string
+	Duration time.Duration
+}
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookColumns = `
+	id, user_id, workspace_id, url, secret, events, active, consecutive_failures, disabled_at, created_at, updated_at
+`
+
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	var w Webhook
+	err := row.Scan(
+		&w.ID,
+		&w.UserID,
+		&w.WorkspaceID,
+		&w.URL,
+		&w.Secret,
+		pq.Array(&w.Events),
+		&w.Active,
+		&w.ConsecutiveFailures,
+		&w.DisabledAt,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+const deliveryColumns = `
+	id, webhook_id, event_id, event, payload, status, attempts, next_attempt_at,
+	response_status, response_body, error, duration_ms, created_at, completed_at
+`
+
+func scanDelivery(row rowScanner) (*WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.Event,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.ResponseStatus,
+		&d.ResponseBody,
+		&d.Error,
+		&d.DurationMS,
+		&d.CreatedAt,
+		&d.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// queryWebhook runs a single-row webhook query under the tenant settings from c.
+func (r *WebhookRepository) queryWebhook(c context.Context, query string, args ...any) (*Webhook, error) {
+	var w *Webhook
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		w, err = scanWebhook(q.QueryRowContext(c, query, args...))
+		return err
+	})
+	return w, err
+}
+
+func (r *WebhookRepository) queryDelivery(c context.Context, query string, args ...any) (*WebhookDelivery, error) {
+	var d *WebhookDelivery
+	err := withTenant(c, r.db, func(q querier) (err error) {
+		d, err = scanDelivery(q.QueryRowContext(c, query, args...))
+		return err
+	})
+	return d, err
+}
+
+func (r *WebhookRepository) CreateWebhook(c context.Context, w *Webhook) (*Webhook, error) {
+	query := `
+			INSERT INTO webhooks (user_id, workspace_id, url, secret, events)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING ` + webhookColumns
+
+	created, err := r.queryWebhook(c, query, w.UserID, w.WorkspaceID, w.URL, w.Secret, pq.Array(w.Events))
+	if err != nil {
+		return nil, fmt.Errorf("insert webhook: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *WebhookRepository) GetWebhooks(c context.Context, userID uuid.UUID) ([]Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 ORDER BY created_at`
+
+	webhooks := []Webhook{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, userID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			w, err := scanWebhook(rows)
+			if err != nil {
+				return err
+			}
+			webhooks = append(webhooks, *w)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) GetWebhookByID(c context.Context, id, userID uuid.UUID) (*Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1 AND user_id = $2`
+
+	w, err := r.queryWebhook(c, query, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("get webhook by id: %w", err)
+	}
+
+	return w, nil
+}
+
+// UpdateWebhook saves the URL, events and active flag. Turning a webhook back
+// on clears its failure count.
+func (r *WebhookRepository) UpdateWebhook(c context.Context, w *Webhook) (*Webhook, error) {
+	query := `
+			UPDATE webhooks SET
+				url = $3,
+				events = $4,
+				consecutive_failures = CASE WHEN $5 AND NOT active THEN 0 ELSE consecutive_failures END,
+				disabled_at = CASE WHEN $5 THEN NULL ELSE COALESCE(disabled_at, NOW()) END,
+				active = $5,
+				updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+			RETURNING ` + webhookColumns
+
+	updated, err := r.queryWebhook(c, query, w.ID, w.UserID, w.URL, pq.Array(w.Events), w.Active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("update webhook: %w", err)
+	}
+
+	return updated, nil
+}
+
+func (r *WebhookRepository) DeleteWebhook(c context.Context, id, userID uuid.UUID) error {
+	result, err := execTenant(c, r.db, "DELETE FROM webhooks WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Enqueue queues ev for every webhook subscribed to its type that covers
+// one of its workspaces or is a personal webhook of its user. An event that
+// was already queued for a webhook isn't queued again.
+func (r *WebhookRepository) Enqueue(c context.Context, ev events.Event) (int, error) {
+	var queued int
+	err := withTenant(c, r.db, func(q querier) error {
+		return q.QueryRowContext(c,
+			"SELECT enqueue_webhook_deliveries($1, $2, $3, $4, COALESCE($5::UUID[], '{}'), NULLIF($6, '')::UUID)",
+			ev.ID, ev.Type, string(ev.Data), ev.CreatedAt, pq.Array(ev.WorkspaceIDs), ev.UserID,
+		).Scan(&queued)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+
+	return queued, nil
+}
+
+// EnqueueFor queues a delivery of event to a single webhook, whether or not
+// it subscribes to the event.
+func (r *WebhookRepository) EnqueueFor(c context.Context, webhookID uuid.UUID, event string, data any) (*WebhookDelivery, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	query := `
+			WITH event AS (SELECT gen_random_uuid() AS id)
+			INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+			SELECT $1, event.id, $2, jsonb_build_object('id', event.id, 'event', $2::TEXT, 'created_at', NOW(), 'data', $3::JSONB)
+			FROM event
+			RETURNING ` + deliveryColumns
+
+	d, err := r.queryDelivery(c, query, webhookID, event, string(body))
+	if err != nil {
+		return nil, fmt.Errorf("enqueue webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+// Redeliver queues a fresh copy of a past delivery. The original stays in the
+// log as it was.
+func (r *WebhookRepository) Redeliver(c context.Context, webhookID, deliveryID uuid.UUID) (*WebhookDelivery, error) {
+	query := `
+			INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+			SELECT webhook_id, event_id, event, payload
+			FROM webhook_deliveries
+			WHERE id = $1 AND webhook_id = $2
+			RETURNING ` + deliveryColumns
+
+	d, err := r.queryDelivery(c, query, deliveryID, webhookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("redeliver webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+// GetDeliveries returns the webhook's most recent deliveries, newest first.
+func (r *WebhookRepository) GetDeliveries(c context.Context, webhookID uuid.UUID, limit int) ([]WebhookDelivery, error) {
+	query := `
+			SELECT ` + deliveryColumns + `
+			FROM webhook_deliveries
+			WHERE webhook_id = $1
+			ORDER BY created_at DESC, id
+			LIMIT $2
+	`
+
+	deliveries := []WebhookDelivery{}
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, webhookID, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			d, err := scanDelivery(rows)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, *d)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDue locks up to limit deliveries that are due on active webhooks for
+// lease, so that other server instances leave them alone while they are sent.
+func (r *WebhookRepository) ClaimDue(c context.Context, limit int, lease time.Duration) ([]PendingDelivery, error) {
+	query := `
+			UPDATE webhook_deliveries d
+			SET locked_until = NOW() + make_interval(secs => $2)
+			FROM webhooks w
+			WHERE w.id = d.webhook_id AND d.id IN (
+				SELECT d2.id
+				FROM webhook_deliveries d2
+				JOIN webhooks w2 ON w2.id = d2.webhook_id
+				WHERE d2.status = 'pending' AND d2.next_attempt_at <= NOW()
+				AND (d2.locked_until IS NULL OR d2.locked_until < NOW())
+				AND w2.active
+				ORDER BY d2.next_attempt_at
+				LIMIT $1
+				FOR UPDATE OF d2 SKIP LOCKED
+			)
+			RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret
+	`
+
+	var claimed []PendingDelivery
+	err := withTenant(c, r.db, func(q querier) error {
+		rows, err := q.QueryContext(c, query, limit, lease.Seconds())
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var d PendingDelivery
+			if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Attempts, &d.URL, &d.Secret); err != nil {
+				return err
+			}
+			claimed = append(claimed, d)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// RecordSuccess logs a successful attempt and resets the webhook's failures.
+func (r *WebhookRepository) RecordSuccess(c context.Context, d PendingDelivery, a DeliveryAttempt) error {
+	return inTx(c, r.db, func(c context.Context) error {
+		query := `
+				UPDATE webhook_deliveries SET
+					status = 'succeeded', attempts = attempts + 1, response_status = $2, response_body = $3,
+					error = NULL, duration_ms = $4, completed_at = NOW(), locked_until = NULL
+				WHERE id = $1
+		`
+		if _, err := execTenant(c, r.db, query, d.ID, a.Status, a.Body, a.Duration.Milliseconds()); err != nil {
+			return fmt.Errorf("record webhook success: %w", err)
+		}
+
+		if _, err := execTenant(c, r.db, "UPDATE webhooks SET consecutive_failures = 0 WHERE id = $1", d.WebhookID); err != nil {
+			return fmt.Errorf("reset webhook failures: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RecordFailure logs a failed attempt. The delivery is retried at retryAt, or
+// given up on when retryAt is nil. The webhook is switched off once it has
+// failed disableAfter times in a row.
+func (r *WebhookRepository) RecordFailure(c context.Context, d PendingDelivery, a DeliveryAttempt, retryAt *time.Time, disableAfter int) error {
+	return inTx(c, r.db, func(c context.Context) error {
+		query := `
+				UPDATE webhook_deliveries SET
+					status = CASE WHEN $5::TIMESTAMPTZ IS NULL THEN 'failed' ELSE 'pending' END,
+					attempts = attempts + 1, response_status = $2, response_body = $3, error = $4,
+					duration_ms = $6, next_attempt_at = COALESCE($5, next_attempt_at),
+					completed_at = CASE WHEN $5::TIMESTAMPTZ IS NULL THEN NOW() END,
+					locked_until = NULL
+				WHERE id = $1
+		`
+		if _, err := execTenant(c, r.db, query, d.ID, a.Status, a.Body, a.Error, retryAt, a.Duration.Milliseconds()); err != nil {
+			return fmt.Errorf("record webhook failure: %w", err)
+		}
+
+		query = `
+				UPDATE webhooks SET
+					consecutive_failures = consecutive_failures + 1,
+					active = active AND consecutive_failures + 1 < $2,
+					disabled_at = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN NOW() ELSE disabled_at END
+				WHERE id = $1
+		`
+		if _, err := execTenant(c, r.db, query, d.WebhookID, disableAfter); err != nil {
+			return fmt.Errorf("count webhook failure: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrMemberNotFound    = errors.New("member not found")
+)
+
+type Workspace struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Personal  bool      `json:"personal"`
+	Role      string    `json:"role,omitempty"`
+	CreatedBy *string   `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID string    `json:"workspace_id"`
+	UserID      string    `json:"user_id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type WorkspaceRepository struct {
+	db *sql.DB
+}
+
+func NewWorkspaceRepository(db *sql.DB) *WorkspaceRepository {
+	return &WorkspaceRepository{db: db}
+}
+
+// CreateWorkspace inserts the workspace and makes its creator the owner in a
+// single transaction.
+func (r *WorkspaceRepository) CreateWorkspace(c context.Context, name string, userID uuid.UUID) (*Workspace, error) {
+	tx, err := r.db.BeginTx(c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin create workspace: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+			INSERT INTO workspaces (name, created_by)
+			VALUES ($1, $2)
+			RETURNING id, name, personal, created_by, created_at, updated_at
+	`
+
+	var w Workspace
+	err = tx.QueryRowContext(c, query, name, userID).Scan(
+		&w.ID,
+		&w.Name,
+		&w.Personal,
+		&w.CreatedBy,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert workspace: %w", err)
+	}
+
+	_, err = tx.ExecContext(c,
+		"INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, 'owner')",
+		w.ID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert workspace owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit create workspace: %w", err)
+	}
+
+	w.Role = "owner"
+	return &w, nil
+}
+
+func (r *WorkspaceRepository) GetWorkspacesByUserID(c context.Context, userID uuid.UUID) ([]Workspace, error) {
+	query := `
+			SELECT w.id, w.name, w.personal, m.role, w.created_by, w.created_at, w.updated_at
+			FROM workspaces w
+			JOIN workspace_members m ON m.workspace_id = w.id
+			WHERE m.user_id = $1
+			ORDER BY w.personal DESC, w.name
+	`
+
+	rows, err := r.db.QueryContext(c, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get workspaces by user id: %w", err)
+	}
+	defer rows.Close()
+
+	workspaces := []Workspace{}
+	for rows.Next() {
+		var w Workspace
+		if err := rows.Scan(&w.ID, &w.Name, &w.Personal, &w.Role, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("get workspaces by user id: %w", err)
+		}
+		workspaces = append(workspaces, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get workspaces by user id: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+func (r *WorkspaceRepository) GetWorkspaceByID(c context.Context, id uuid.UUID) (*Workspace, error) {
+	query := `
+			SELECT id, name, personal, created_by, created_at, updated_at
+			FROM workspaces
+			WHERE id = $1
+	`
+
+	var w Workspace
+	err := r.db.QueryRowContext(c, query, id).Scan(
+		&w.ID,
+		&w.Name,
+		&w.Personal,
+		&w.CreatedBy,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("get workspace by id: %w", err)
+	}
+
+	return &w, nil
+}
+
+func (r *WorkspaceRepository) GetPersonalWorkspaceID(c context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(c,
+		"SELECT id FROM workspaces WHERE personal AND created_by = $1", userID,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrWorkspaceNotFound
+		}
+		return uuid.Nil, fmt.Errorf("get personal workspace: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *WorkspaceRepository) UpdateWorkspaceName(c context.Context, id uuid.UUID, name string) (*Workspace, error) {
+	query := `
+			UPDATE workspaces SET name = $1, updated_at = NOW()
+			WHERE id = $2
+			RETURNING id, name, personal, created_by, created_at, updated_at
+	`
+
+	var w Workspace
+	err := r.db.QueryRowContext(c, query, name, id).Scan(
+		&w.ID,
+		&w.Name,
+		&w.Personal,
+		&w.CreatedBy,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("update workspace name: %w", err)
+	}
+
+	return &w, nil
+}
+
+func (r *WorkspaceRepository) DeleteWorkspace(c context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(c, "DELETE FROM workspaces WHERE id = $1 AND NOT personal", id)
+	if err != nil {
+		return fmt.Errorf("delete workspace: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrWorkspaceNotFound
+	}
+
+	return nil
+}
+
+// GetRole returns the user's role in the workspace, or "" if they aren't a member.
+func (r *WorkspaceRepository) GetRole(c context.Context, workspaceID, userID uuid.UUID) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(c,
+		"SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2",
+		workspaceID, userID,
+	).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("get workspace role: %w", err)
+	}
+
+	return role, nil
+}
+
+func (r *WorkspaceRepository) GetMembers(c context.Context, workspaceID uuid.UUID) ([]WorkspaceMember, error) {
+	query := `
+			SELECT m.workspace_id, u.id, u.username, u.email, m.role, m.created_at
+			FROM workspace_members m
+			JOIN users u ON u.id = m.user_id
+			WHERE m.workspace_id = $1
+			ORDER BY m.created_at
+	`
+
+	rows, err := r.db.QueryContext(c, query, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("get workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []WorkspaceMember{}
+	for rows.Next() {
+		var m WorkspaceMember
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Username, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("get workspace members: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get workspace members: %w", err)
+	}
+
+	return members, nil
+}
+
+func (r *WorkspaceRepository) CountOwners(c context.Context, workspaceID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(c,
+		"SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = 'owner'", workspaceID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count workspace owners: %w", err)
+	}
+	return count, nil
+}
+
+func (r *WorkspaceRepository) UpdateMemberRole(c context.Context, workspaceID, userID uuid.UUID, role string) error {
+	result, err := r.db.ExecContext(c,
+		"UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND user_id = $3",
+		role, workspaceID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("update member role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) RemoveMember(c context.Context, workspaceID, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(c,
+		"DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2",
+		workspaceID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("remove member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
@@ -1,25 +1,56 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/0xrishabk/tasktracker/internal/handler"
+	"github.com/0xrishabk/tasktracker/internal/middleware"
+	"github.com/0xrishabk/tasktracker/internal/service"
 )
 
-func (s *Server) RegisterRoutes(taskHandler *handler.TaskHandler, userHandler *handler.UserHandler) http.Handler {
-	r := gin.Default()
+func (s *Server) RegisterRoutes(taskHandler *handler.TaskHandler, userHandler *handler.UserHandler, attachmentHandler *handler.AttachmentHandler, timeEntryHandler *handler.TimeEntryHandler, assignmentHandler *handler.AssignmentHandler, workspaceHandler *handler.WorkspaceHandler, projectHandler *handler.ProjectHandler, shareHandler *handler.ShareHandler, trashHandler *handler.TrashHandler, archiveHandler *handler.ArchiveHandler, viewHandler *handler.ViewHandler, webhookHandler *handler.WebhookHandler, streamHandler *handler.StreamHandler, collabHandler *handler.CollabHandler, calendarHandler *handler.CalendarHandler, caldavHandler *handler.CalDAVHandler, importHandler *handler.ImportHandler, userService *service.UserService, workspaceService *service.WorkspaceService, idempotencyService *service.IdempotencyService) http.Handler {
+	r := newRouter(s.trustedProxies)
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "If-Match", "X-Share-Password"},
+		ExposeHeaders:    []string{"ETag", "Idempotent-Replayed", "Link"},
 		AllowCredentials: true,
 	}))
+	r.Use(middleware.Idempotency(idempotencyService))
 
 	intializeUserRoutes(r, userHandler)
-	initializeTaskRoutes(r, taskHandler)
+	initializeAutoArchiveRoutes(r, archiveHandler)
+	initializeTimeEntryRoutes(r, timeEntryHandler)
+	initializeWorkspaceRoutes(r, workspaceHandler, projectHandler, shareHandler, workspaceService)
+	initializePublicShareRoutes(r, shareHandler)
+	initializeViewRoutes(r, viewHandler)
+	initializeWebhookRoutes(r, webhookHandler)
+	initializeCollabRoutes(r, collabHandler)
+	initializeCalendarRoutes(r, calendarHandler)
+	initializeCalDAVRoutes(r, caldavHandler, userService, workspaceService)
+
+	// Task routes are served both unscoped and scoped to a workspace the
+	// caller belongs to.
+	for _, task := range []*gin.RouterGroup{
+		r.Group("/api/task"),
+		r.Group("/api/workspaces/:workspaceID/task", middleware.JWTAuth(), middleware.Workspace(workspaceService)),
+	} {
+		initializeTaskRoutes(task, taskHandler)
+		initializeAttachmentRoutes(task, attachmentHandler)
+		initializeTaskTimeRoutes(task, timeEntryHandler)
+		initializeAssignmentRoutes(task, assignmentHandler)
+		initializeTaskShareRoutes(task, shareHandler)
+		initializeTrashRoutes(task, trashHandler)
+		initializeArchiveRoutes(task, archiveHandler)
+		initializeStreamRoutes(task, streamHandler)
+		initializeImportRoutes(task, importHandler)
+	}
 
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -42,14 +73,238 @@ func intializeUserRoutes(r *gin.Engine, h *handler.UserHandler) {
 	user.POST("/register", h.CreateUser)
 	user.POST("/login", h.Login)
 	user.DELETE("/:id", h.Delete)
+
+	user.POST("/app-passwords", middleware.JWTAuth(), h.CreateAppPassword)
+	user.GET("/app-passwords", middleware.JWTAuth(), h.GetAppPasswords)
+	user.DELETE("/app-passwords/:id", middleware.JWTAuth(), h.DeleteAppPassword)
+}
+
+// newRouter builds the engine the routes hang off. The client IP that rate
+// limits are keyed on is only read from X-Forwarded-For and similar headers
+// when the request comes from one of trustedProxies, so that clients can't
+// pick a fresh IP, and with it a fresh limit, for every request.
+func newRouter(trustedProxies []string) *gin.Engine {
+	r := gin.Default()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		msg := fmt.Sprintf("Error while setting trusted proxies: %s", err.Error())
+		panic(msg)
+	}
+	return r
+}
+
+func initializeTaskRoutes(task *gin.RouterGroup, h *handler.TaskHandler) {
+	task.POST("/", middleware.JWTAuth(), h.CreateTask)
+	task.POST("/bulk", middleware.JWTAuth(), h.Bulk)
+	task.GET("/sync", middleware.JWTAuth(), h.Sync)
+	task.POST("/sync", middleware.JWTAuth(), h.PushChanges)
+	task.GET("/all-task", middleware.JWTAuthOptional(), h.GetAllTasks)
+	task.GET("/id/:id", middleware.JWTAuth(), h.GetTaskByID)
+	task.GET("/user", middleware.JWTAuthOptional(), h.GetTasks)
+	task.GET("/me", middleware.JWTAuth(), h.GetMyTasks)
+	task.GET("/search", middleware.JWTAuth(), h.Search)
+	task.GET("/filter", middleware.JWTAuth(), h.Filter)
+	task.GET("/export", middleware.JWTAuth(), middleware.Deadline(10*time.Minute), h.Export)
+	task.GET("/estimates", middleware.JWTAuth(), h.GetEstimateSummary)
+	task.GET("/:id/estimate", middleware.JWTAuth(), h.GetEstimate)
+	task.GET("/:id/history", middleware.JWTAuth(), h.GetHistory)
+	task.POST("/:id/revert", middleware.JWTAuth(), h.Revert)
+	task.GET("/:id/labels", middleware.JWTAuth(), h.GetLabels)
+	task.POST("/:id/labels", middleware.JWTAuth(), h.AddLabels)
+	task.DELETE("/:id/labels/:label", middleware.JWTAuth(), h.RemoveLabel)
+	task.GET("/:id/comments", middleware.JWTAuth(), h.GetComments)
+	task.POST("/:id/comments", middleware.JWTAuth(), h.AddComment)
+	task.PATCH("/:id/comments/:commentID", middleware.JWTAuth(), h.UpdateComment)
+	task.DELETE("/:id/comments/:commentID", middleware.JWTAuth(), h.DeleteComment)
+	task.PATCH("/:id", middleware.JWTAuth(), h.UpdateTaskDetails)
+	task.DELETE("/:id", middleware.JWTAuth(), h.DeleteTask)
+}
+
+func initializeImportRoutes(task *gin.RouterGroup, h *handler.ImportHandler) {
+	// Reading and validating a large file can outlive the server-wide timeouts.
+	task.POST("/import", middleware.JWTAuth(), middleware.Deadline(2*time.Minute), h.Import)
+	task.GET("/import/:jobID", middleware.JWTAuth(), h.GetJob)
+}
+
+func initializeAttachmentRoutes(task *gin.RouterGroup, h *handler.AttachmentHandler) {
+	// Uploads and downloads can easily outlive the server-wide 10s timeouts.
+	attachment := task.Group("/:id/attachments", middleware.JWTAuth(), middleware.Deadline(10*time.Minute))
+
+	attachment.POST("/", h.Upload)
+	attachment.GET("/", h.GetAttachments)
+	attachment.GET("/:attachmentID", h.Download)
+	attachment.DELETE("/:attachmentID", h.DeleteAttachment)
+}
+
+func initializeTaskTimeRoutes(task *gin.RouterGroup, h *handler.TimeEntryHandler) {
+	task = task.Group("/:id", middleware.JWTAuth())
+
+	task.POST("/timer/start", h.StartTimer)
+	task.POST("/time", h.CreateEntry)
+	task.GET("/time", h.GetTaskTime)
+}
+
+func initializeTimeEntryRoutes(r *gin.Engine, h *handler.TimeEntryHandler) {
+	entry := r.Group("/api/time", middleware.JWTAuth())
+
+	entry.GET("/timer", h.GetRunningTimer)
+	entry.POST("/timer/stop", h.StopTimer)
+	entry.GET("/totals", h.GetTotals)
+	entry.GET("/timesheet", h.GetTimesheet)
+	entry.PATCH("/:entryID", h.UpdateEntry)
+	entry.DELETE("/:entryID", h.DeleteEntry)
+}
+
+func initializeAssignmentRoutes(task *gin.RouterGroup, h *handler.AssignmentHandler) {
+	task = task.Group("/:id", middleware.JWTAuth())
+
+	task.GET("/assignees", h.GetAssignees)
+	task.POST("/assignees", h.Assign)
+	task.DELETE("/assignees/:userID", h.Unassign)
+	task.GET("/watchers", h.GetWatchers)
+	task.POST("/watch", h.Watch)
+	task.DELETE("/watch", h.Unwatch)
+}
+
+func initializeTrashRoutes(task *gin.RouterGroup, h *handler.TrashHandler) {
+	task.GET("/trash", middleware.JWTAuth(), h.GetTrash)
+	task.POST("/:id/restore", middleware.JWTAuth(), h.Restore)
+	task.DELETE("/:id/permanent", middleware.JWTAuth(), h.DeleteForever)
+}
+
+func initializeArchiveRoutes(task *gin.RouterGroup, h *handler.ArchiveHandler) {
+	task.POST("/archive", middleware.JWTAuth(), h.ArchiveDone)
+	task.POST("/:id/archive", middleware.JWTAuth(), h.Archive)
+	task.DELETE("/:id/archive", middleware.JWTAuth(), h.Unarchive)
+}
+
+func initializeAutoArchiveRoutes(r *gin.Engine, h *handler.ArchiveHandler) {
+	settings := r.Group("/api/user/me/auto-archive", middleware.JWTAuth())
+
+	settings.GET("/", h.GetAutoArchive)
+	settings.PUT("/", h.SetAutoArchive)
 }
 
-func initializeTaskRoutes(r *gin.Engine, h *handler.TaskHandler) {
-	task := r.Group("/api/task")
+func initializeViewRoutes(r *gin.Engine, h *handler.ViewHandler) {
+	view := r.Group("/api/views", middleware.JWTAuth())
+
+	view.POST("/", h.CreateView)
+	view.GET("/", h.GetViews)
+	view.GET("/:id", h.GetView)
+	view.PUT("/:id", h.UpdateView)
+	view.DELETE("/:id", h.DeleteView)
+	view.GET("/:id/tasks", h.GetViewTasks)
+}
+
+func initializeStreamRoutes(task *gin.RouterGroup, h *handler.StreamHandler) {
+	task.GET("/stream", middleware.JWTAuth(), h.Stream)
+}
+
+func initializeCollabRoutes(r *gin.Engine, h *handler.CollabHandler) {
+	r.GET("/api/ws", middleware.JWTAuth(), h.Connect)
+}
+
+func initializeCalendarRoutes(r *gin.Engine, h *handler.CalendarHandler) {
+	calendar := r.Group("/api/calendar")
+
+	calendar.GET("/feed", middleware.JWTAuth(), h.GetFeed)
+	calendar.POST("/feed", middleware.JWTAuth(), h.RegenerateFeed)
+	calendar.DELETE("/feed", middleware.JWTAuth(), h.DeleteFeed)
+	calendar.GET("/ics/:token", middleware.RateLimit(30, time.Minute), h.Feed)
+}
+
+// CalDAV clients sign in with the account's email and an app password, and
+// most of them are particular about trailing slashes, so collections answer
+// with and without one. A sync makes a burst of requests, so the rate limit
+// only holds back clients hammering the server or guessing passwords.
+func initializeCalDAVRoutes(r *gin.Engine, h *handler.CalDAVHandler, userService *service.UserService, workspaceService *service.WorkspaceService) {
+	r.GET("/.well-known/caldav", h.WellKnown)
+	r.Handle("PROPFIND", "/.well-known/caldav", h.WellKnown)
+
+	dav := r.Group("/dav", middleware.RateLimit(300, time.Minute), middleware.BasicAuth(userService, "tasktracker"))
+	for _, path := range []string{"", "/", "/principals/me", "/principals/me/"} {
+		dav.OPTIONS(path, h.Options)
+		dav.Handle("PROPFIND", path, h.Root)
+	}
+	for _, path := range []string{"/calendars", "/calendars/"} {
+		dav.OPTIONS(path, h.Options)
+		dav.Handle("PROPFIND", path, h.Home)
+	}
+
+	calendar := dav.Group("/calendars/:workspaceID", middleware.Workspace(workspaceService))
+	for _, path := range []string{"", "/"} {
+		calendar.OPTIONS(path, h.Options)
+		calendar.Handle("PROPFIND", path, h.Calendar)
+		calendar.Handle("REPORT", path, h.Report)
+	}
+	calendar.OPTIONS("/:object", h.Options)
+	calendar.Handle("PROPFIND", "/:object", h.PropfindObject)
+	calendar.GET("/:object", h.GetObject)
+	calendar.HEAD("/:object", h.GetObject)
+	calendar.PUT("/:object", h.PutObject)
+	calendar.DELETE("/:object", h.DeleteObject)
+}
+
+func initializeWebhookRoutes(r *gin.Engine, h *handler.WebhookHandler) {
+	webhook := r.Group("/api/webhooks", middleware.JWTAuth())
+
+	webhook.POST("/", h.CreateWebhook)
+	webhook.GET("/", h.GetWebhooks)
+	webhook.GET("/:id", h.GetWebhook)
+	webhook.PATCH("/:id", h.UpdateWebhook)
+	webhook.DELETE("/:id", h.DeleteWebhook)
+	webhook.GET("/:id/deliveries", h.GetDeliveries)
+	webhook.POST("/:id/deliveries/:deliveryID/redeliver", h.Redeliver)
+	webhook.POST("/:id/ping", h.Ping)
+}
+
+func initializeTaskShareRoutes(task *gin.RouterGroup, h *handler.ShareHandler) {
+	task = task.Group("/:id", middleware.JWTAuth())
+
+	task.GET("/shares", h.GetTaskShares)
+	task.POST("/shares", h.ShareTask)
+	task.DELETE("/shares/:userID", h.UnshareTask)
+	task.GET("/links", h.GetTaskLinks)
+	task.POST("/links", h.CreateTaskLink)
+	task.DELETE("/links/:linkID", h.RevokeTaskLink)
+}
+
+func initializePublicShareRoutes(r *gin.Engine, h *handler.ShareHandler) {
+	// Link tokens are unguessable, but the limit keeps anyone from trying.
+	r.GET("/api/share/:token", middleware.RateLimit(30, time.Minute), h.OpenLink)
+}
+
+func initializeWorkspaceRoutes(r *gin.Engine, h *handler.WorkspaceHandler, ph *handler.ProjectHandler, sh *handler.ShareHandler, workspaceService *service.WorkspaceService) {
+	r.POST("/api/invitations/accept", middleware.JWTAuth(), h.AcceptInvitation)
+
+	workspaces := r.Group("/api/workspaces", middleware.JWTAuth())
+
+	workspaces.GET("/", h.GetWorkspaces)
+	workspaces.POST("/", h.CreateWorkspace)
+
+	workspace := workspaces.Group("/:workspaceID", middleware.Workspace(workspaceService))
+
+	workspace.GET("/", h.GetWorkspace)
+	workspace.PATCH("/", h.UpdateWorkspace)
+	workspace.DELETE("/", h.DeleteWorkspace)
+
+	workspace.GET("/members", h.GetMembers)
+	workspace.PATCH("/members/:userID", h.UpdateMemberRole)
+	workspace.DELETE("/members/:userID", h.RemoveMember)
+
+	workspace.GET("/invitations", h.GetInvitations)
+	workspace.POST("/invitations", h.Invite)
+	workspace.DELETE("/invitations/:invitationID", h.RevokeInvitation)
+
+	workspace.GET("/projects", ph.GetProjects)
+	workspace.POST("/projects", ph.CreateProject)
+	workspace.GET("/projects/:projectID", ph.GetProject)
+	workspace.PATCH("/projects/:projectID", ph.UpdateProject)
+	workspace.DELETE("/projects/:projectID", ph.DeleteProject)
 
-	task.POST("/", h.CreateTask)
-	task.GET("/all-task", h.GetAllTasks)
-	task.GET("/id/:id", h.GetTaskByID)
-	task.GET("/user", h.GetTasks)
-	task.DELETE("/:id", h.DeleteTask)
+	workspace.GET("/projects/:projectID/shares", sh.GetProjectShares)
+	workspace.POST("/projects/:projectID/shares", sh.ShareProject)
+	workspace.DELETE("/projects/:projectID/shares/:userID", sh.UnshareProject)
+	workspace.GET("/projects/:projectID/links", sh.GetProjectLinks)
+	workspace.POST("/projects/:projectID/links", sh.CreateProjectLink)
+	workspace.DELETE("/projects/:projectID/links/:linkID", sh.RevokeProjectLink)
 }
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/0xrishabk/tasktracker/internal/middleware"
+)
+
+// limited serves a route allowing three requests a minute per client.
+func limited(trustedProxies []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := newRouter(trustedProxies)
+	r.GET("/limited", middleware.RateLimit(3, time.Minute), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func get(r http.Handler, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	req.Header.Set("X-Real-IP", forwardedFor)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimitIgnoresForwardedFor(t *testing.T) {
+	r := limited(nil)
+
+	for i := 1; i <= 4; i++ {
+		want := http.StatusNoContent
+		if i == 4 {
+			want = http.StatusTooManyRequests
+		}
+		if got := get(r, "203.0.113.7:5000", fmt.Sprintf("198.51.100.%d", i)); got != want {
+			t.Fatalf("request %d = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestRateLimitTrustedProxy(t *testing.T) {
+	r := limited([]string{"10.0.0.0/8"})
+
+	// Behind a trusted proxy each forwarded client gets its own limit...
+	for i := 1; i <= 4; i++ {
+		if got := get(r, "10.0.0.2:5000", fmt.Sprintf("198.51.100.%d", i)); got != http.StatusNoContent {
+			t.Fatalf("request %d through the proxy = %d, want %d", i, got, http.StatusNoContent)
+		}
+	}
+
+	// ...but the header is still ignored from anyone else.
+	for i := 1; i <= 4; i++ {
+		want := http.StatusNoContent
+		if i == 4 {
+			want = http.StatusTooManyRequests
+		}
+		if got := get(r, "203.0.113.7:5000", fmt.Sprintf("192.0.2.%d", i)); got != want {
+			t.Fatalf("direct request %d = %d, want %d", i, got, want)
+		}
+	}
+}
@@ -1,30 +1,37 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/0xrishabk/tasktracker/db"
+	"github.com/0xrishabk/tasktracker/internal/events"
 	"github.com/0xrishabk/tasktracker/internal/handler"
+	"github.com/0xrishabk/tasktracker/internal/mailer"
 	"github.com/0xrishabk/tasktracker/internal/repository"
 	"github.com/0xrishabk/tasktracker/internal/service"
+	"github.com/0xrishabk/tasktracker/internal/storage"
 )
 
 type Server struct {
-	ip   string
-	port int
+	ip             string
+	port           int
+	trustedProxies []string
 }
 
 func NewServer() *http.Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	ip := os.Getenv("IP")
 	srv := &Server{
-		ip:   ip,
-		port: port,
+		ip:             ip,
+		port:           port,
+		trustedProxies: parseList(os.Getenv("TRUSTED_PROXIES")),
 	}
 
 	fmt.Printf("Initialized server with: %s:%d\n", srv.ip, srv.port)
@@ -35,18 +42,107 @@ func NewServer() *http.Server {
 		panic(msg)
 	}
 
+	store, err := storage.NewBlobStore()
+	if err != nil {
+		msg := fmt.Sprintf("Error while creating blob store: %s", err.Error())
+		panic(msg)
+	}
+
+	mail, err := mailer.NewMailer()
+	if err != nil {
+		msg := fmt.Sprintf("Error while creating mailer: %s", err.Error())
+		panic(msg)
+	}
+
+	sink, err := events.NewSink(os.Getenv("EVENT_SINK"))
+	if err != nil {
+		msg := fmt.Sprintf("Error while creating event sink: %s", err.Error())
+		panic(msg)
+	}
+	bus := events.NewBus()
+
+	webhookNetworks, err := service.ParseWebhookNetworks(os.Getenv("WEBHOOK_ALLOWED_NETWORKS"))
+	if err != nil {
+		msg := fmt.Sprintf("Error while reading webhook networks: %s", err.Error())
+		panic(msg)
+	}
+
+	maxUpload, _ := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_BYTES"), 10, 64)
+	uploadQuota, _ := strconv.ParseInt(os.Getenv("ATTACHMENT_QUOTA_BYTES"), 10, 64)
+	trashDays, _ := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	idempotencyHours, _ := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS"))
+
 	taskRepo := repository.NewTaskRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	timeEntryRepo := repository.NewTimeEntryRepository(db)
+	assignmentRepo := repository.NewAssignmentRepository(db)
+	workspaceRepo := repository.NewWorkspaceRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	shareRepo := repository.NewShareRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	viewRepo := repository.NewViewRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	streamRepo := repository.NewStreamRepository(db)
+	presenceRepo := repository.NewPresenceRepository(db)
+	calendarRepo := repository.NewCalendarRepository(db)
+	importRepo := repository.NewImportRepository(db)
 
-	taskService := service.NewTaskService(taskRepo, userRepo)
-	userService := service.NewUserService(userRepo)
+	workspaceService := service.NewWorkspaceService(workspaceRepo, invitationRepo, userRepo, mail, os.Getenv("APP_URL"))
+	webhookService := service.NewWebhookService(webhookRepo, workspaceService, webhookNetworks)
+	streamService := service.NewStreamService(streamRepo)
+	collabService := service.NewCollabService(presenceRepo, taskRepo, projectRepo, streamService)
+	outboxService := service.NewOutboxService(outboxRepo, bus, webhookService, streamService, sink)
+	taskService := service.NewTaskService(taskRepo, userRepo, workspaceRepo, projectRepo, outboxService)
+	userService := service.NewUserService(userRepo, workspaceRepo, outboxService)
+	attachmentService := service.NewAttachmentService(attachmentRepo, taskRepo, store, maxUpload, uploadQuota)
+	timeEntryService := service.NewTimeEntryService(timeEntryRepo, taskRepo)
+	assignmentService := service.NewAssignmentService(assignmentRepo, taskRepo, userRepo)
+	projectService := service.NewProjectService(projectRepo, workspaceService)
+	trashService := service.NewTrashService(taskRepo, store, time.Duration(trashDays)*24*time.Hour)
+	archiveService := service.NewArchiveService(taskRepo, userRepo)
+	shareService := service.NewShareService(shareRepo, taskRepo, projectRepo, userRepo, workspaceService, os.Getenv("APP_URL"))
+	viewService := service.NewViewService(viewRepo, workspaceService, taskService)
+	calendarService := service.NewCalendarService(calendarRepo, taskRepo, os.Getenv("API_URL"))
+	importService := service.NewImportService(importRepo, taskRepo, workspaceRepo, taskService)
+	caldavService := service.NewCalDAVService(taskService, taskRepo, workspaceService)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, time.Duration(idempotencyHours)*time.Hour)
 
 	taskHandler := handler.NewTaskHandler(taskService)
 	userHandler := handler.NewUserHandler(userService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+	timeEntryHandler := handler.NewTimeEntryHandler(timeEntryService)
+	assignmentHandler := handler.NewAssignmentHandler(assignmentService)
+	workspaceHandler := handler.NewWorkspaceHandler(workspaceService)
+	projectHandler := handler.NewProjectHandler(projectService)
+	shareHandler := handler.NewShareHandler(shareService)
+	trashHandler := handler.NewTrashHandler(trashService)
+	archiveHandler := handler.NewArchiveHandler(archiveService)
+	viewHandler := handler.NewViewHandler(viewService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	streamHandler := handler.NewStreamHandler(streamService)
+	collabHandler := handler.NewCollabHandler(collabService, os.Getenv("APP_URL"))
+	calendarHandler := handler.NewCalendarHandler(calendarService)
+	caldavHandler := handler.NewCalDAVHandler(caldavService)
+	importHandler := handler.NewImportHandler(importService)
+
+	go trashService.RunPurger(context.Background(), time.Hour)
+	go archiveService.RunAutoArchiver(context.Background(), time.Hour)
+	go idempotencyService.RunPurger(context.Background(), time.Hour)
+	go webhookService.RunDispatcher(context.Background(), 5*time.Second)
+	go outboxService.RunDispatcher(context.Background(), time.Second)
+	go outboxService.RunPurger(context.Background(), time.Hour)
+	go streamService.Run(context.Background())
+	go streamService.RunPurger(context.Background(), time.Hour)
+	go collabService.Run(context.Background())
+	go importService.RunImporter(context.Background(), 5*time.Second)
+	go importService.RunPurger(context.Background(), time.Hour)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      srv.RegisterRoutes(taskHandler, userHandler),
+		Handler:      srv.RegisterRoutes(taskHandler, userHandler, attachmentHandler, timeEntryHandler, assignmentHandler, workspaceHandler, projectHandler, shareHandler, trashHandler, archiveHandler, viewHandler, webhookHandler, streamHandler, collabHandler, calendarHandler, caldavHandler, importHandler, userService, workspaceService, idempotencyService),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -54,3 +150,14 @@ func NewServer() *http.Server {
 
 	return server
 }
+
+// parseList splits a comma-separated setting, dropping empty entries.
+func parseList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const maxAppPasswordName = 100
+
+// CreateAppPassword issues a password for a client such as a calendar app,
+// to be used with the account's email in place of its own password. The
+// returned password is not shown again.
+func (s *UserService) CreateAppPassword(c context.Context, userID string, req model.RequestCreateAppPassword) (*model.ResponseAppPassword, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("UserService.CreateAppPassword - Starting attempt to create app password for user: %s", userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("UserService.CreateAppPassword - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxAppPasswordName {
+		return nil, fmt.Errorf("%w: name is required and must be at most %d characters", ErrInvalidRequest, maxAppPasswordName)
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.userRepo.CreateAppPassword(c, uid, name, tokenHash)
+	if err != nil {
+		log.Printf("UserService.CreateAppPassword - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("UserService.CreateAppPassword - Successfully created app password: %s", p.ID)
+
+	res := newAppPasswordResponse(p)
+	res.Password = token
+	return res, nil
+}
+
+func (s *UserService) GetAppPasswords(c context.Context, userID string) ([]model.ResponseAppPassword, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("UserService.GetAppPasswords - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	passwords, err := s.userRepo.GetAppPasswords(c, uid)
+	if err != nil {
+		log.Printf("UserService.GetAppPasswords - Database error: %v", err)
+		return nil, err
+	}
+
+	res := make([]model.ResponseAppPassword, 0, len(passwords))
+	for i := range passwords {
+		res = append(res, *newAppPasswordResponse(&passwords[i]))
+	}
+	return res, nil
+}
+
+func (s *UserService) DeleteAppPassword(c context.Context, userID, passwordID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("UserService.DeleteAppPassword - UUID parsing error: %v", err)
+		return err
+	}
+
+	pid, err := uuid.Parse(passwordID)
+	if err != nil {
+		return repository.ErrAppPasswordNotFound
+	}
+
+	return s.userRepo.DeleteAppPassword(c, pid, uid)
+}
+
+// AuthenticateAppPassword signs in a client with the account's email and one
+// of its app passwords. App passwords are random tokens, so a plain hash
+// lookup is enough to check them.
+func (s *UserService) AuthenticateAppPassword(c context.Context, email, password string) (*repository.User, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, err := s.userRepo.UseAppPassword(c, email, hashToken(password))
+	if err != nil {
+		log.Printf("UserService.AuthenticateAppPassword - App password check failed for: %s: %v", email, err)
+		return nil, fmt.Errorf("invalid email or app password")
+	}
+
+	return user, nil
+}
+
+func newAppPasswordResponse(p *repository.AppPassword) *model.ResponseAppPassword {
+	return &model.ResponseAppPassword{
+		ID:         p.ID,
+		Name:       p.Name,
+		LastUsedAt: p.LastUsedAt,
+		CreatedAt:  p.CreatedAt,
+	}
+}
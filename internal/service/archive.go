@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+type ArchiveService struct {
+	taskRepo *repository.TaskRepository
+	userRepo *repository.UserRepository
+	timeout  time.Duration
+}
+
+func NewArchiveService(taskRepo *repository.TaskRepository, userRepo *repository.UserRepository) *ArchiveService {
+	return &ArchiveService{
+		taskRepo: taskRepo,
+		userRepo: userRepo,
+		timeout:  time.Duration(2) * time.Second,
+	}
+}
+
+// parseArchiveFilter reads the "archived" query parameter of task listings:
+// empty hides archived tasks, "include" shows them too, "only" shows nothing else.
+func parseArchiveFilter(archived string) (repository.ArchiveFilter, error) {
+	switch archived {
+	case "":
+		return repository.ExcludeArchived, nil
+	case "include":
+		return repository.IncludeArchived, nil
+	case "only":
+		return repository.OnlyArchived, nil
+	default:
+		return 0, fmt.Errorf("%w: archived must be include or only", ErrInvalidRequest)
+	}
+}
+
+func (s *ArchiveService) Archive(c context.Context, userID, taskID string) (*model.ResponseCreateTask, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ArchiveService.Archive - Starting attempt to archive task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("ArchiveService.Archive - Access check failed: %v", err)
+		return nil, err
+	}
+
+	task, err := s.taskRepo.ArchiveTask(c, tid)
+	if err != nil {
+		log.Printf("ArchiveService.Archive - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ArchiveService.Archive - Successfully archived task: %s", taskID)
+	return newTaskResponse(task), nil
+}
+
+func (s *ArchiveService) Unarchive(c context.Context, userID, taskID string) (*model.ResponseCreateTask, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ArchiveService.Unarchive - Starting attempt to unarchive task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("ArchiveService.Unarchive - Access check failed: %v", err)
+		return nil, err
+	}
+
+	task, err := s.taskRepo.UnarchiveTask(c, tid)
+	if err != nil {
+		log.Printf("ArchiveService.Unarchive - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ArchiveService.Unarchive - Successfully unarchived task: %s", taskID)
+	return newTaskResponse(task), nil
+}
+
+// ArchiveDone archives the user's done tasks that haven't been touched for
+// the given number of days.
+func (s *ArchiveService) ArchiveDone(c context.Context, userID string, req model.RequestArchiveDone) (*model.ResponseArchiveDone, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ArchiveService.ArchiveDone - Starting attempt to archive done tasks for user: %s", userID)
+
+	if req.OlderThanDays < 0 {
+		return nil, fmt.Errorf("%w: older_than_days must not be negative", ErrInvalidRequest)
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("ArchiveService.ArchiveDone - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	before := time.Now().AddDate(0, 0, -req.OlderThanDays)
+
+	n, err := s.taskRepo.ArchiveStale(c, uid, statusDone, before)
+	if err != nil {
+		log.Printf("ArchiveService.ArchiveDone - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ArchiveService.ArchiveDone - Archived %d tasks for user: %s", n, userID)
+	return &model.ResponseArchiveDone{Archived: n}, nil
+}
+
+func (s *ArchiveService) GetAutoArchive(c context.Context, userID string) (*model.AutoArchiveSettings, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.userRepo.GetAutoArchiveDays(c, uid)
+	if err != nil {
+		log.Printf("ArchiveService.GetAutoArchive - Database error: %v", err)
+		return nil, err
+	}
+
+	return &model.AutoArchiveSettings{Days: days}, nil
+}
+
+func (s *ArchiveService) SetAutoArchive(c context.Context, userID string, req model.AutoArchiveSettings) (*model.AutoArchiveSettings, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ArchiveService.SetAutoArchive - Updating automatic archival for user: %s", userID)
+
+	if req.Days != nil && *req.Days <= 0 {
+		return nil, fmt.Errorf("%w: days must be positive", ErrInvalidRequest)
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetAutoArchiveDays(c, uid, req.Days); err != nil {
+		log.Printf("ArchiveService.SetAutoArchive - Database error: %v", err)
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// AutoArchive archives done tasks for every user who opted in, each by their
+// own threshold.
+func (s *ArchiveService) AutoArchive(c context.Context) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	n, err := s.taskRepo.AutoArchive(repository.WithSystem(c), statusDone)
+	if err != nil {
+		log.Printf("ArchiveService.AutoArchive - Database error: %v", err)
+		return err
+	}
+
+	if n > 0 {
+		log.Printf("ArchiveService.AutoArchive - Archived %d tasks", n)
+	}
+	return nil
+}
+
+// RunAutoArchiver calls AutoArchive every interval until c is done.
+func (s *ArchiveService) RunAutoArchiver(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = s.AutoArchive(c)
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+type AssignmentService struct {
+	assignmentRepo *repository.AssignmentRepository
+	taskRepo       *repository.TaskRepository
+	userRepo       *repository.UserRepository
+	timeout        time.Duration
+}
+
+func NewAssignmentService(assignmentRepo *repository.AssignmentRepository, taskRepo *repository.TaskRepository, userRepo *repository.UserRepository) *AssignmentService {
+	return &AssignmentService{
+		assignmentRepo: assignmentRepo,
+		taskRepo:       taskRepo,
+		userRepo:       userRepo,
+		timeout:        time.Duration(2) * time.Second,
+	}
+}
+
+// Assign adds a user, looked up by ID or email, to the task's assignees. Only
+// the task owner may hand out assignments.
+func (s *AssignmentService) Assign(c context.Context, actorID, taskID string, req model.RequestAssignTask) ([]repository.TaskPerson, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("AssignmentService.Assign - Starting attempt to assign task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessOwner)
+	if err != nil {
+		log.Printf("AssignmentService.Assign - Access check failed: %v", err)
+		return nil, err
+	}
+
+	user, err := lookupUser(c, s.userRepo, req.UserID, req.Email)
+	if err != nil {
+		log.Printf("AssignmentService.Assign - Assignee lookup failed: %v", err)
+		return nil, err
+	}
+
+	if err := s.assignmentRepo.AddAssignee(c, tid, user.ID, uuid.MustParse(actorID)); err != nil {
+		log.Printf("AssignmentService.Assign - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("AssignmentService.Assign - Assigned %s to task: %s", user.ID.String(), taskID)
+	return s.assignmentRepo.GetAssignees(c, tid)
+}
+
+// Unassign removes an assignee. Owners can remove anyone; assignees can only
+// take themselves off the task.
+func (s *AssignmentService) Unassign(c context.Context, actorID, taskID, userID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("AssignmentService.Unassign - Starting attempt to unassign %s from task: %s", userID, taskID)
+
+	need := accessOwner
+	if actorID == userID {
+		need = accessWrite
+	}
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, need)
+	if err != nil {
+		log.Printf("AssignmentService.Unassign - Access check failed: %v", err)
+		return err
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("AssignmentService.Unassign - UUID parsing error: %v", err)
+		return err
+	}
+
+	if err := s.assignmentRepo.RemoveAssignee(c, tid, uid); err != nil {
+		log.Printf("AssignmentService.Unassign - Database error: %v", err)
+		return err
+	}
+
+	log.Printf("AssignmentService.Unassign - Unassigned %s from task: %s", userID, taskID)
+	return nil
+}
+
+func (s *AssignmentService) GetAssignees(c context.Context, actorID, taskID string) ([]repository.TaskPerson, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessRead)
+	if err != nil {
+		log.Printf("AssignmentService.GetAssignees - Access check failed: %v", err)
+		return nil, err
+	}
+
+	people, err := s.assignmentRepo.GetAssignees(c, tid)
+	if err != nil {
+		log.Printf("AssignmentService.GetAssignees - Database error: %v", err)
+		return nil, err
+	}
+
+	return people, nil
+}
+
+func (s *AssignmentService) Watch(c context.Context, actorID, taskID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("AssignmentService.Watch - User %s starting to watch task: %s", actorID, taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessRead)
+	if err != nil {
+		log.Printf("AssignmentService.Watch - Access check failed: %v", err)
+		return err
+	}
+
+	if err := s.assignmentRepo.AddWatcher(c, tid, uuid.MustParse(actorID)); err != nil {
+		log.Printf("AssignmentService.Watch - Database error: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Unwatch needs no access check: anyone may stop watching, even after losing
+// access to the task.
+func (s *AssignmentService) Unwatch(c context.Context, actorID, taskID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("AssignmentService.Unwatch - User %s no longer watching task: %s", actorID, taskID)
+
+	tid, err := uuid.Parse(taskID)
+	if err != nil {
+		log.Printf("AssignmentService.Unwatch - UUID parsing error: %v", err)
+		return err
+	}
+
+	uid, err := uuid.Parse(actorID)
+	if err != nil {
+		log.Printf("AssignmentService.Unwatch - UUID parsing error: %v", err)
+		return err
+	}
+
+	if err := s.assignmentRepo.RemoveWatcher(c, tid, uid); err != nil {
+		log.Printf("AssignmentService.Unwatch - Database error: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *AssignmentService) GetWatchers(c context.Context, actorID, taskID string) ([]repository.TaskPerson, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessRead)
+	if err != nil {
+		log.Printf("AssignmentService.GetWatchers - Access check failed: %v", err)
+		return nil, err
+	}
+
+	people, err := s.assignmentRepo.GetWatchers(c, tid)
+	if err != nil {
+		log.Printf("AssignmentService.GetWatchers - Database error: %v", err)
+		return nil, err
+	}
+
+	return people, nil
+}
+
+// lookupUser finds the user named by a request, by ID if one is given and by
+// email otherwise.
+func lookupUser(c context.Context, userRepo *repository.UserRepository, userID, email string) (*repository.User, error) {
+	var (
+		user *repository.User
+		err  error
+	)
+
+	switch {
+	case userID != "":
+		uid, perr := uuid.Parse(userID)
+		if perr != nil {
+			return nil, fmt.Errorf("%w: user_id must be a valid id", ErrInvalidRequest)
+		}
+		user, err = userRepo.GetUserByID(c, uid)
+	case email != "":
+		user, err = userRepo.GetUserByEmail(c, email)
+	default:
+		return nil, fmt.Errorf("%w: user_id or email is required", ErrInvalidRequest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+	"github.com/0xrishabk/tasktracker/internal/storage"
+)
+
+const (
+	defaultAttachmentMaxSize = 25 << 20
+	defaultAttachmentQuota   = 1 << 30
+)
+
+type AttachmentService struct {
+	attachmentRepo *repository.AttachmentRepository
+	taskRepo       *repository.TaskRepository
+	store          storage.BlobStore
+	maxSize        int64
+	quota          int64
+	timeout        time.Duration
+}
+
+func NewAttachmentService(attachmentRepo *repository.AttachmentRepository, taskRepo *repository.TaskRepository, store storage.BlobStore, maxSize, quota int64) *AttachmentService {
+	if maxSize <= 0 {
+		maxSize = defaultAttachmentMaxSize
+	}
+	if quota <= 0 {
+		quota = defaultAttachmentQuota
+	}
+
+	return &AttachmentService{
+		attachmentRepo: attachmentRepo,
+		taskRepo:       taskRepo,
+		store:          store,
+		maxSize:        maxSize,
+		quota:          quota,
+		timeout:        time.Duration(2) * time.Second,
+	}
+}
+
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+func (s *AttachmentService) Upload(c context.Context, userID, taskID string, file *multipart.FileHeader) (*repository.Attachment, error) {
+	log.Printf("AttachmentService.Upload - Starting upload of %s to task: %s", file.Filename, taskID)
+
+	tid, err := s.authorize(c, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("AttachmentService.Upload - Task lookup failed: %v", err)
+		return nil, err
+	}
+
+	if file.Size > s.maxSize {
+		log.Printf("AttachmentService.Upload - File too large: %d bytes", file.Size)
+		return nil, ErrFileTooLarge
+	}
+
+	used, err := s.usage(c, userID)
+	if err != nil {
+		log.Printf("AttachmentService.Upload - Database error: %v", err)
+		return nil, err
+	}
+
+	if used+file.Size > s.quota {
+		log.Printf("AttachmentService.Upload - Quota exceeded for user: %s", userID)
+		return nil, repository.ErrQuotaExceeded
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		log.Printf("AttachmentService.Upload - Failed to open upload: %v", err)
+		return nil, fmt.Errorf("failed to read upload: %v", err)
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		log.Printf("AttachmentService.Upload - Failed to read upload: %v", err)
+		return nil, fmt.Errorf("failed to read upload: %v", err)
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), f), hash)
+	key := fmt.Sprintf("attachments/%s/%s", userID, uuid.NewString())
+
+	if err := s.store.Put(c, key, body, file.Size, contentType); err != nil {
+		log.Printf("AttachmentService.Upload - Blob store error: %v", err)
+		return nil, fmt.Errorf("failed to store attachment: %v", err)
+	}
+
+	a := &repository.Attachment{
+		TaskID:      tid.String(),
+		UserID:      userID,
+		Filename:    cleanFilename(file.Filename),
+		Size:        file.Size,
+		ContentType: contentType,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+	}
+
+	dc, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	attachment, err := s.attachmentRepo.CreateAttachment(dc, a, s.quota)
+	if err != nil {
+		log.Printf("AttachmentService.Upload - Database error: %v", err)
+		if err := s.store.Delete(context.Background(), key); err != nil {
+			log.Printf("AttachmentService.Upload - Failed to clean up blob %s: %v", key, err)
+		}
+		return nil, err
+	}
+
+	log.Printf("AttachmentService.Upload - Upload was successful: %s", attachment.ID)
+	return attachment, nil
+}
+
+func (s *AttachmentService) GetAttachments(c context.Context, userID, taskID string) ([]repository.Attachment, error) {
+	log.Printf("AttachmentService.GetAttachments - Starting attempt to fetch attachments for task: %s", taskID)
+
+	tid, err := s.authorize(c, userID, taskID, accessRead)
+	if err != nil {
+		log.Printf("AttachmentService.GetAttachments - Task lookup failed: %v", err)
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	attachments, err := s.attachmentRepo.GetAttachmentsByTaskID(c, tid)
+	if err != nil {
+		log.Printf("AttachmentService.GetAttachments - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("AttachmentService.GetAttachments - Successfully fetched attachments for task: %s", taskID)
+	return attachments, nil
+}
+
+// Open returns the attachment metadata and a seekable reader over its content.
+// The caller must close the reader.
+func (s *AttachmentService) Open(c context.Context, userID, taskID, attachmentID string) (*repository.Attachment, io.ReadSeekCloser, error) {
+	log.Printf("AttachmentService.Open - Starting attempt to open attachment: %s", attachmentID)
+
+	a, err := s.attachment(c, userID, taskID, attachmentID, accessRead)
+	if err != nil {
+		log.Printf("AttachmentService.Open - Attachment lookup failed: %v", err)
+		return nil, nil, err
+	}
+
+	blob, err := s.store.Open(c, a.StorageKey, a.Size)
+	if err != nil {
+		log.Printf("AttachmentService.Open - Blob store error: %v", err)
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+
+	return a, blob, nil
+}
+
+func (s *AttachmentService) DeleteAttachment(c context.Context, userID, taskID, attachmentID string) error {
+	log.Printf("AttachmentService.DeleteAttachment - Starting attempt to delete attachment: %s", attachmentID)
+
+	a, err := s.attachment(c, userID, taskID, attachmentID, accessWrite)
+	if err != nil {
+		log.Printf("AttachmentService.DeleteAttachment - Attachment lookup failed: %v", err)
+		return err
+	}
+
+	dc, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.attachmentRepo.DeleteAttachment(dc, uuid.MustParse(a.ID)); err != nil {
+		log.Printf("AttachmentService.DeleteAttachment - Database error: %v", err)
+		return err
+	}
+
+	if err := s.store.Delete(c, a.StorageKey); err != nil {
+		log.Printf("AttachmentService.DeleteAttachment - Failed to delete blob %s: %v", a.StorageKey, err)
+	}
+
+	log.Printf("AttachmentService.DeleteAttachment - Successfully deleted attachment: %s", attachmentID)
+	return nil
+}
+
+func (s *AttachmentService) authorize(c context.Context, userID, taskID string, need accessLevel) (uuid.UUID, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return authorizeTask(c, s.taskRepo, userID, taskID, need)
+}
+
+func (s *AttachmentService) attachment(c context.Context, userID, taskID, attachmentID string, need accessLevel) (*repository.Attachment, error) {
+	tid, err := s.authorize(c, userID, taskID, need)
+	if err != nil {
+		return nil, err
+	}
+
+	aid, err := uuid.Parse(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	a, err := s.attachmentRepo.GetAttachmentByID(c, aid)
+	if err != nil {
+		return nil, err
+	}
+
+	if a == nil || a.TaskID != tid.String() {
+		return nil, ErrAttachmentNotFound
+	}
+
+	return a, nil
+}
+
+func (s *AttachmentService) usage(c context.Context, userID string) (int64, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.attachmentRepo.GetUsageByUserID(c, uid)
+}
+
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+	return name
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	bulkAtomic     = "atomic"
+	bulkBestEffort = "best_effort"
+
+	maxBulkOperations = 100
+)
+
+const (
+	bulkOK         = "ok"
+	bulkFailed     = "failed"
+	bulkSkipped    = "skipped"
+	bulkRolledBack = "rolled_back"
+)
+
+var errBulkAborted = errors.New("bulk operation aborted")
+
+// Bulk applies a batch of task operations in one transaction. Each operation
+// runs in its own savepoint, so in best-effort mode a failure only undoes that
+// operation; in atomic mode the first failure rolls everything back.
+func (s *TaskService) Bulk(c context.Context, userID string, req model.RequestBulk) (*model.ResponseBulk, error) {
+	log.Printf("TaskService.Bulk - Starting %d operations for user: %s", len(req.Operations), userID)
+
+	if req.Mode == "" {
+		req.Mode = bulkAtomic
+	}
+	if req.Mode != bulkAtomic && req.Mode != bulkBestEffort {
+		return nil, fmt.Errorf("%w: mode must be atomic or best_effort", ErrInvalidRequest)
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxBulkOperations {
+		return nil, fmt.Errorf("%w: between 1 and %d operations are allowed", ErrInvalidRequest, maxBulkOperations)
+	}
+
+	res := &model.ResponseBulk{
+		Mode:    req.Mode,
+		Results: make([]model.BulkResult, len(req.Operations)),
+	}
+
+	failed := -1
+	err := s.taskRepo.InTx(c, func(c context.Context) error {
+		for i, op := range req.Operations {
+			r := &res.Results[i]
+			r.Index, r.Op = i, op.Op
+
+			if failed >= 0 && req.Mode == bulkAtomic {
+				r.Status = bulkSkipped
+				continue
+			}
+
+			r.Err = repository.Savepoint(c, func(c context.Context) error {
+				return s.applyBulk(c, userID, op, r)
+			})
+			if r.Err != nil {
+				log.Printf("TaskService.Bulk - Operation %d (%s) failed: %v", i, op.Op, r.Err)
+				r.Status = bulkFailed
+				if failed < 0 {
+					failed = i
+				}
+				continue
+			}
+
+			r.Status = bulkOK
+		}
+
+		if failed >= 0 && req.Mode == bulkAtomic {
+			return errBulkAborted
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkAborted) {
+		log.Printf("TaskService.Bulk - Database error: %v", err)
+		return nil, err
+	}
+
+	res.Committed = err == nil
+	if !res.Committed {
+		for i := 0; i < failed; i++ {
+			res.Results[i].Status = bulkRolledBack
+			res.Results[i].Task = nil
+			res.Results[i].Labels = nil
+		}
+	}
+
+	log.Printf("TaskService.Bulk - Finished operations for user %s, committed: %t", userID, res.Committed)
+	return res, nil
+}
+
+func (s *TaskService) applyBulk(c context.Context, userID string, op model.BulkOperation, r *model.BulkResult) (err error) {
+	switch op.Op {
+	case "create":
+		if op.Task == nil {
+			return fmt.Errorf("%w: create needs a task", ErrInvalidRequest)
+		}
+		req := *op.Task
+		req.UserID = userID
+		r.Task, err = s.CreateTask(c, req)
+	case "update":
+		if op.Patch == nil {
+			return fmt.Errorf("%w: update needs a patch", ErrInvalidRequest)
+		}
+		r.Task, err = s.UpdateTaskDetails(c, userID, op.ID, op.Patch, op.Version)
+	case "status":
+		status := op.Status
+		patch := &model.RequestUpdateTask{Status: model.Optional[string]{Set: true, Value: &status}}
+		r.Task, err = s.UpdateTaskDetails(c, userID, op.ID, patch, op.Version)
+	case "move":
+		if !op.ProjectID.Set {
+			return fmt.Errorf("%w: move needs a project_id, or null to leave the project", ErrInvalidRequest)
+		}
+		patch := &model.RequestUpdateTask{ProjectID: op.ProjectID}
+		r.Task, err = s.UpdateTaskDetails(c, userID, op.ID, patch, op.Version)
+	case "add_labels":
+		r.Labels, err = s.AddLabels(c, userID, op.ID, op.Labels)
+	case "remove_labels":
+		r.Labels, err = s.RemoveLabels(c, userID, op.ID, op.Labels)
+	case "delete":
+		err = s.DeleteTask(c, userID, op.ID)
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidRequest, op.Op)
+	}
+
+	return err
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/ical"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+// maxCalendarObjects caps how many tasks one CalDAV listing or sync returns.
+const maxCalendarObjects = 10000
+
+// davSyncTokenPrefix turns a change token into the URI that WebDAV sync
+// tokens have to be.
+const davSyncTokenPrefix = "urn:x-tasktracker:sync:"
+
+// davNamespace seeds the ids of tasks that CalDAV clients create under names
+// of their own.
+var davNamespace = uuid.MustParse("3f0c1b7e-6a1d-4c55-9d9e-6b1f0f2a9c41")
+
+// CalDAVService serves the tasks of a workspace as a CalDAV calendar of
+// VTODOs. The workspace comes from the context, as for the workspace-scoped
+// task routes.
+type CalDAVService struct {
+	taskService      *TaskService
+	taskRepo         *repository.TaskRepository
+	workspaceService *WorkspaceService
+	timeout          time.Duration
+}
+
+func NewCalDAVService(taskService *TaskService, taskRepo *repository.TaskRepository, workspaceService *WorkspaceService) *CalDAVService {
+	return &CalDAVService{
+		taskService:      taskService,
+		taskRepo:         taskRepo,
+		workspaceService: workspaceService,
+		timeout:          time.Duration(2) * time.Second,
+	}
+}
+
+// Calendars lists the workspaces the user belongs to, one calendar each.
+func (s *CalDAVService) Calendars(c context.Context, userID string) ([]repository.Workspace, error) {
+	return s.workspaceService.GetWorkspaces(c, userID)
+}
+
+func (s *CalDAVService) Calendar(c context.Context, userID, workspaceID string) (*repository.Workspace, error) {
+	return s.workspaceService.GetWorkspace(c, userID, workspaceID)
+}
+
+// SyncToken is the calendar's current sync token. It changes whenever one of
+// its tasks may have, which makes it do as a CTag too.
+func (s *CalDAVService) SyncToken(c context.Context) (string, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	token, err := s.taskRepo.SyncToken(c)
+	if err != nil {
+		return "", err
+	}
+	return davSyncTokenPrefix + token, nil
+}
+
+// Objects lists the calendar's live, unarchived tasks.
+func (s *CalDAVService) Objects(c context.Context) ([]model.CalendarObject, error) {
+	objects, _, _, err := s.Changes(c, "")
+	return objects, err
+}
+
+// Changes lists the tasks changed since the sync token and the ids of those
+// that left the calendar, by being deleted or archived, along with the token
+// to sync from next time. An empty token lists everything.
+func (s *CalDAVService) Changes(c context.Context, token string) ([]model.CalendarObject, []string, string, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	var since *string
+	if token != "" {
+		xid, ok := strings.CutPrefix(token, davSyncTokenPrefix)
+		if _, err := strconv.ParseUint(xid, 10, 64); !ok || err != nil {
+			return nil, nil, "", ErrInvalidSyncToken
+		}
+		since = &xid
+	}
+
+	set, err := s.taskRepo.GetChangesSince(c, since, nil, maxCalendarObjects)
+	if err != nil {
+		log.Printf("CalDAVService.Changes - Database error: %v", err)
+		return nil, nil, "", err
+	}
+
+	objects := []model.CalendarObject{}
+	deleted := set.Deleted
+	for i := range set.Tasks {
+		t := &set.Tasks[i]
+		if t.DeletedAt != nil || t.ArchivedAt != nil {
+			deleted = append(deleted, t.ID)
+			continue
+		}
+		objects = append(objects, *newCalendarObject(t))
+	}
+
+	return objects, deleted, davSyncTokenPrefix + set.Token, nil
+}
+
+// Object returns the task behind a resource name.
+func (s *CalDAVService) Object(c context.Context, userID, name string) (*model.CalendarObject, error) {
+	t, err := s.labeledTask(c, userID, davTaskID(name))
+	if err != nil {
+		return nil, err
+	}
+	return newCalendarObject(t), nil
+}
+
+// PutObject creates or replaces the task behind a resource name from a
+// VTODO. Replacing only touches the fields that differ from what the task
+// would render as, so statuses of our own that iCalendar has no word for
+// survive a round trip. ifMatch and ifNoneMatch are the conditional request
+// headers; created reports whether a new task was made.
+func (s *CalDAVService) PutObject(c context.Context, userID, name string, body io.Reader, ifMatch, ifNoneMatch string) (obj *model.CalendarObject, created bool, err error) {
+	log.Printf("CalDAVService.PutObject - Starting attempt to store %s for user: %s", name, userID)
+
+	todo, err := parseTodo(body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tid := davTaskID(name)
+	err = s.taskRepo.InTx(c, func(c context.Context) error {
+		current, err := s.labeledTask(c, userID, tid)
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			if ifMatch != "" {
+				return ErrPreconditionFailed
+			}
+			created = true
+			return s.createTodo(c, userID, tid, todo)
+		}
+		if err != nil {
+			return err
+		}
+
+		if ifNoneMatch == "*" || (ifMatch != "" && ifMatch != "*" && ifMatch != newCalendarObject(current).ETag) {
+			return ErrPreconditionFailed
+		}
+		return s.updateTodo(c, userID, current, todo)
+	})
+	if err != nil {
+		log.Printf("CalDAVService.PutObject - Failed to store %s: %v", name, err)
+		return nil, false, err
+	}
+
+	obj, err = s.Object(c, userID, tid.String())
+	return obj, created, err
+}
+
+// DeleteObject deletes the task behind a resource name, which like any
+// deletion moves it to the trash.
+func (s *CalDAVService) DeleteObject(c context.Context, userID, name, ifMatch string) error {
+	tid := davTaskID(name)
+	return s.taskRepo.InTx(c, func(c context.Context) error {
+		current, err := s.labeledTask(c, userID, tid)
+		if err != nil {
+			return err
+		}
+
+		if ifMatch != "" && ifMatch != "*" && ifMatch != newCalendarObject(current).ETag {
+			return ErrPreconditionFailed
+		}
+		return s.taskService.DeleteTask(c, userID, tid.String())
+	})
+}
+
+func (s *CalDAVService) createTodo(c context.Context, userID string, taskID uuid.UUID, todo *todoFields) error {
+	id := taskID.String()
+	req := model.RequestCreateTask{
+		ID:          &id,
+		Name:        todo.name,
+		Description: todo.description,
+		Status:      todo.status,
+		UserID:      userID,
+		DueAt:       todo.due,
+	}
+
+	if _, err := s.taskService.CreateTask(c, req); err != nil {
+		return err
+	}
+	if len(todo.labels) > 0 {
+		if _, err := s.taskService.AddLabels(c, userID, id, todo.labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CalDAVService) updateTodo(c context.Context, userID string, current *repository.LabeledTask, todo *todoFields) error {
+	var patch model.RequestUpdateTask
+	changed := false
+	if todo.name != current.Name {
+		patch.Name = model.Optional[string]{Set: true, Value: &todo.name}
+		changed = true
+	}
+	if todo.description != current.Description {
+		patch.Description = model.Optional[string]{Set: true, Value: &todo.description}
+		changed = true
+	}
+	if todoStatus(current.Status) != todoStatus(todo.status) {
+		patch.Status = model.Optional[string]{Set: true, Value: &todo.status}
+		changed = true
+	}
+	if (todo.due == nil) != (current.DueAt == nil) || (todo.due != nil && !todo.due.Equal(*current.DueAt)) {
+		patch.DueAt = model.Optional[time.Time]{Set: true, Value: todo.due}
+		changed = true
+	}
+
+	if changed {
+		if _, err := s.taskService.UpdateTaskDetails(c, userID, current.ID, &patch, &current.Version); err != nil {
+			return err
+		}
+	}
+
+	add, remove := diffLabels(current.Labels, todo.labels)
+	if len(add) > 0 {
+		if _, err := s.taskService.AddLabels(c, userID, current.ID, add); err != nil {
+			return err
+		}
+	}
+	if len(remove) > 0 {
+		if _, err := s.taskService.RemoveLabels(c, userID, current.ID, remove); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CalDAVService) labeledTask(c context.Context, userID string, taskID uuid.UUID) (*repository.LabeledTask, error) {
+	task, err := s.taskService.GetTaskByID(c, userID, taskID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := s.taskService.GetLabels(c, userID, taskID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.LabeledTask{Task: *task, Labels: labels}, nil
+}
+
+// davTaskID maps a resource name onto the task it stands for. Tasks are
+// served under their own ids, but clients creating a task pick a name of
+// their own, which is turned into an id the same way every time.
+func davTaskID(name string) uuid.UUID {
+	base := strings.TrimSuffix(name, ".ics")
+	if id, err := uuid.Parse(base); err == nil {
+		return id
+	}
+	return uuid.NewSHA1(davNamespace, []byte(base))
+}
+
+// newCalendarObject renders a task as a resource. Its entity tag is a hash
+// of the data, since labels can change without the task's version moving.
+func newCalendarObject(t *repository.LabeledTask) *model.CalendarObject {
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf)
+	beginCalendar(w, "")
+	writeTodo(w, t, time.UTC)
+	w.End("VCALENDAR")
+
+	sum := sha256.Sum256(buf.Bytes())
+	return &model.CalendarObject{
+		ID:   t.ID,
+		ETag: `"` + hex.EncodeToString(sum[:16]) + `"`,
+		Data: buf.Bytes(),
+	}
+}
+
+// todoFields is what a task takes from a VTODO. Anything else in it, such as
+// alarms, priorities or recurrence rules, is dropped.
+type todoFields struct {
+	name        string
+	description string
+	status      string
+	due         *time.Time
+	labels      []string
+}
+
+func parseTodo(body io.Reader) (*todoFields, error) {
+	cal, err := ical.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	todo := cal.Child("VTODO")
+	if cal.Name != "VCALENDAR" || todo == nil {
+		return nil, fmt.Errorf("%w: only calendars holding a VTODO can be stored", ErrInvalidRequest)
+	}
+
+	f := &todoFields{
+		name:        todo.Text("SUMMARY"),
+		description: todo.Text("DESCRIPTION"),
+		status:      taskStatus(todo.Text("STATUS"), todo.Prop("COMPLETED") != nil),
+	}
+	if f.name == "" {
+		return nil, fmt.Errorf("%w: a VTODO needs a SUMMARY", ErrInvalidRequest)
+	}
+
+	if p := todo.Prop("DUE"); p != nil {
+		due, _, err := p.Time()
+		if err != nil {
+			return nil, fmt.Errorf("%w: DUE is not a valid date", ErrInvalidRequest)
+		}
+		f.due = &due
+	}
+
+	if labels := todo.TextList("CATEGORIES"); len(labels) > 0 {
+		if f.labels, err = cleanLabels(labels); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// taskStatus maps a VTODO status back onto a task status.
+func taskStatus(status string, completed bool) string {
+	switch {
+	case status == "COMPLETED", status == "" && completed:
+		return statusDone
+	case status == "IN-PROCESS":
+		return "IN_PROGRESS"
+	default:
+		return "TO_DO"
+	}
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/ical"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	calendarEvent = "event"
+	calendarTodo  = "todo"
+
+	// feedPastDays is how far back a feed reaches for tasks that were due.
+	feedPastDays = 90
+)
+
+type CalendarService struct {
+	calendarRepo *repository.CalendarRepository
+	taskRepo     *repository.TaskRepository
+	apiURL       string
+	timeout      time.Duration
+}
+
+func NewCalendarService(calendarRepo *repository.CalendarRepository, taskRepo *repository.TaskRepository, apiURL string) *CalendarService {
+	return &CalendarService{
+		calendarRepo: calendarRepo,
+		taskRepo:     taskRepo,
+		apiURL:       strings.TrimRight(apiURL, "/"),
+		timeout:      time.Duration(2) * time.Second,
+	}
+}
+
+func (s *CalendarService) GetFeed(c context.Context, userID string) (*model.ResponseCalendarFeed, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("CalendarService.GetFeed - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	feed, err := s.calendarRepo.GetFeed(c, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ResponseCalendarFeed{CreatedAt: feed.CreatedAt}, nil
+}
+
+// RegenerateFeed gives the user's feed a new secret URL. Whatever was
+// subscribed to the old one stops getting updates.
+func (s *CalendarService) RegenerateFeed(c context.Context, userID string) (*model.ResponseCalendarFeed, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("CalendarService.RegenerateFeed - Starting attempt to regenerate feed for user: %s", userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("CalendarService.RegenerateFeed - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	feed, err := s.calendarRepo.SetFeed(c, uid, tokenHash)
+	if err != nil {
+		log.Printf("CalendarService.RegenerateFeed - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CalendarService.RegenerateFeed - Successfully regenerated feed for user: %s", userID)
+
+	return &model.ResponseCalendarFeed{
+		URL:       fmt.Sprintf("%s/api/calendar/ics/%s.ics", s.apiURL, token),
+		CreatedAt: feed.CreatedAt,
+	}, nil
+}
+
+func (s *CalendarService) DeleteFeed(c context.Context, userID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("CalendarService.DeleteFeed - UUID parsing error: %v", err)
+		return err
+	}
+
+	return s.calendarRepo.DeleteFeed(c, uid)
+}
+
+// Feed renders the calendar behind a feed token: the tasks its owner can see
+// that have a due date, as of now. Nobody is signed in, so the token is the
+// only authorization and the tasks are read as the feed's owner.
+func (s *CalendarService) Feed(c context.Context, token string, req model.RequestCalendarFeed) ([]byte, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	feed, err := s.calendarRepo.GetFeedByToken(c, hashToken(token))
+	if err != nil {
+		log.Printf("CalendarService.Feed - Feed lookup failed: %v", err)
+		return nil, err
+	}
+
+	if req.Type == "" {
+		req.Type = calendarEvent
+	}
+	if req.Type != calendarEvent && req.Type != calendarTodo {
+		return nil, fmt.Errorf("%w: type must be event or todo", ErrInvalidRequest)
+	}
+
+	loc, err := loadLocation(req.TZ)
+	if err != nil {
+		return nil, err
+	}
+
+	projectIDs := make([]uuid.UUID, 0, len(req.Project))
+	for _, p := range req.Project {
+		pid, err := uuid.Parse(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: project must be a project id", ErrInvalidRequest)
+		}
+		projectIDs = append(projectIDs, pid)
+	}
+
+	c = repository.WithUser(c, uuid.MustParse(feed.UserID))
+
+	from := time.Now().AddDate(0, 0, -feedPastDays)
+	tasks, err := s.taskRepo.GetDueTasks(c, from, projectIDs, req.Label)
+	if err != nil {
+		log.Printf("CalendarService.Feed - Database error: %v", err)
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := ical.NewWriter(&buf)
+	beginCalendar(w, "Tasks")
+	if req.TZ != "" {
+		w.Prop("X-WR-TIMEZONE", loc.String())
+	}
+	w.Prop("REFRESH-INTERVAL", "PT1H", "VALUE=DURATION")
+	w.Prop("X-PUBLISHED-TTL", "PT1H")
+	for i := range tasks {
+		if req.Type == calendarTodo {
+			writeTodo(w, &tasks[i], loc)
+		} else {
+			writeEvent(w, &tasks[i], loc)
+		}
+	}
+	w.End("VCALENDAR")
+
+	if err := w.Err(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func beginCalendar(w *ical.Writer, name string) {
+	w.Begin("VCALENDAR")
+	w.Prop("VERSION", "2.0")
+	w.Prop("PRODID", "-//tasktracker//Tasks//EN")
+	w.Prop("CALSCALE", "GREGORIAN")
+	w.Text("X-WR-CALNAME", name)
+}
+
+// writeEvent writes a task as a VEVENT at its due time. Deadlines don't
+// take up time, so the event leaves the calendar free.
+func writeEvent(w *ical.Writer, t *repository.LabeledTask, loc *time.Location) {
+	w.Begin("VEVENT")
+	writeTaskProps(w, t)
+	writeDue(w, "DTSTART", *t.DueAt, loc)
+	w.Prop("TRANSP", "TRANSPARENT")
+	w.End("VEVENT")
+}
+
+// writeTodo writes a task as a VTODO.
+func writeTodo(w *ical.Writer, t *repository.LabeledTask, loc *time.Location) {
+	w.Begin("VTODO")
+	writeTaskProps(w, t)
+	if t.DueAt != nil {
+		writeDue(w, "DUE", *t.DueAt, loc)
+	}
+	w.Prop("STATUS", todoStatus(t.Status))
+	if t.Status == statusDone {
+		// Tasks don't record when they were finished; their last change is
+		// the closest there is.
+		w.Time("COMPLETED", t.UpdatedAt)
+		w.Prop("PERCENT-COMPLETE", "100")
+	}
+	w.End("VTODO")
+}
+
+// writeTaskProps writes what VEVENTs and VTODOs have in common. Tasks don't
+// recur, so there is never an RRULE: each task is a single item that an app
+// refreshing the feed updates in place by its UID and SEQUENCE.
+func writeTaskProps(w *ical.Writer, t *repository.LabeledTask) {
+	w.Text("UID", t.ID)
+	w.Time("DTSTAMP", t.UpdatedAt)
+	w.Time("CREATED", t.CreatedAt)
+	w.Time("LAST-MODIFIED", t.UpdatedAt)
+	w.Prop("SEQUENCE", fmt.Sprint(t.Version))
+	w.Text("SUMMARY", t.Name)
+	w.Text("DESCRIPTION", t.Description)
+	w.TextList("CATEGORIES", t.Labels)
+}
+
+// writeDue writes a due time in UTC, which every calendar app shows in the
+// viewer's own time zone. A task due exactly at midnight in loc is taken to be
+// due that whole day and goes out as a date instead.
+func writeDue(w *ical.Writer, name string, due time.Time, loc *time.Location) {
+	local := due.In(loc)
+	if local.Equal(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)) {
+		w.Date(name, local)
+		return
+	}
+	w.Time(name, due)
+}
+
+// todoStatus maps a task status onto the VTODO statuses. Statuses of our own
+// that iCalendar has no word for go out as NEEDS-ACTION.
+func todoStatus(status string) string {
+	switch status {
+	case statusDone:
+		return "COMPLETED"
+	case "IN_PROGRESS":
+		return "IN-PROCESS"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
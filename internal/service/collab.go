@@ -0,0 +1,453 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	PresenceViewing = "viewing"
+	PresenceEditing = "editing"
+)
+
+const (
+	collabBuffer    = 32
+	collabMaxTopics = 50
+	presenceTTL     = 45 * time.Second
+	presenceRefresh = 20 * time.Second
+	presenceRetry   = 5 * time.Second
+)
+
+// presenceNotice is what servers tell each other about presence. Kind is
+// "presence", "leave" or "typing"; entries are per connection, so a user with
+// two tabs open counts once per tab until both go.
+type presenceNotice struct {
+	Kind     string `json:"kind"`
+	Topic    string `json:"topic"`
+	ConnID   string `json:"conn_id"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	State    string `json:"state,omitempty"`
+}
+
+type presenceEntry struct {
+	userID   string
+	username string
+	state    string
+	expires  time.Time
+}
+
+// CollabService runs the collaboration channel: clients subscribe to tasks
+// and projects, get their change events from the live stream, and see who
+// else is viewing or editing them. Presence and typing go out to every server
+// through the presence repository, and each server keeps the presence it
+// hears about in memory. Entries expire unless refreshed, so a server that
+// dies takes its clients' presence with it within a minute.
+type CollabService struct {
+	presenceRepo  *repository.PresenceRepository
+	taskRepo      *repository.TaskRepository
+	projectRepo   *repository.ProjectRepository
+	streamService *StreamService
+	timeout       time.Duration
+
+	mu       sync.Mutex
+	clients  map[*CollabClient]struct{}
+	presence map[string]map[string]presenceEntry
+}
+
+// CollabClient is one connection. The connection writes whatever arrives on
+// Send, and gives up when Done is closed, which happens when the client
+// can't keep up or disconnects.
+type CollabClient struct {
+	Send chan model.CollabMessage
+	Done chan struct{}
+
+	id       string
+	userID   string
+	username string
+	topics   map[string]string
+	stream   *StreamSubscription
+	once     sync.Once
+}
+
+func NewCollabService(presenceRepo *repository.PresenceRepository, taskRepo *repository.TaskRepository, projectRepo *repository.ProjectRepository, streamService *StreamService) *CollabService {
+	return &CollabService{
+		presenceRepo:  presenceRepo,
+		taskRepo:      taskRepo,
+		projectRepo:   projectRepo,
+		streamService: streamService,
+		timeout:       time.Duration(2) * time.Second,
+		clients:       map[*CollabClient]struct{}{},
+		presence:      map[string]map[string]presenceEntry{},
+	}
+}
+
+// Connect registers a client for the signed-in user in c.
+func (s *CollabService) Connect(c context.Context, userID, username string) *CollabClient {
+	cl := &CollabClient{
+		Send:     make(chan model.CollabMessage, collabBuffer),
+		Done:     make(chan struct{}),
+		id:       uuid.NewString(),
+		userID:   userID,
+		username: username,
+		topics:   map[string]string{},
+		stream:   s.streamService.Subscribe(c, userID),
+	}
+
+	s.mu.Lock()
+	s.clients[cl] = struct{}{}
+	s.mu.Unlock()
+
+	go s.forward(cl)
+	return cl
+}
+
+// Disconnect unregisters the client and takes it out of every topic's
+// presence.
+func (s *CollabService) Disconnect(c context.Context, cl *CollabClient) {
+	s.drop(cl)
+	s.streamService.Unsubscribe(cl.stream)
+
+	s.mu.Lock()
+	topics := make([]string, 0, len(cl.topics))
+	for topic := range cl.topics {
+		topics = append(topics, topic)
+	}
+	s.mu.Unlock()
+
+	for _, topic := range topics {
+		s.announce(c, presenceNotice{Kind: "leave", Topic: topic, ConnID: cl.id, UserID: cl.userID})
+	}
+}
+
+func (s *CollabService) drop(cl *CollabClient) {
+	cl.once.Do(func() {
+		close(cl.Done)
+
+		s.mu.Lock()
+		delete(s.clients, cl)
+		s.mu.Unlock()
+	})
+}
+
+// send queues msg for the client. A client whose buffer is full is dropped,
+// unless msg is only a typing indicator, which is not worth it.
+func (s *CollabService) send(cl *CollabClient, msg model.CollabMessage) {
+	select {
+	case cl.Send <- msg:
+	case <-cl.Done:
+	default:
+		if msg.Type == "typing" {
+			return
+		}
+		log.Printf("CollabService.send - Dropping connection %s that fell behind", cl.id)
+		s.drop(cl)
+	}
+}
+
+// Handle acts on a message from the client. c must carry the signed-in user.
+func (s *CollabService) Handle(c context.Context, cl *CollabClient, msg model.CollabMessage) error {
+	switch msg.Type {
+	case "subscribe":
+		return s.subscribe(c, cl, msg.Topic)
+	case "unsubscribe":
+		if !s.subscribed(cl, msg.Topic) {
+			return nil
+		}
+		s.mu.Lock()
+		delete(cl.topics, msg.Topic)
+		s.mu.Unlock()
+		s.announce(c, presenceNotice{Kind: "leave", Topic: msg.Topic, ConnID: cl.id, UserID: cl.userID})
+		return nil
+	case "presence":
+		if msg.State != PresenceViewing && msg.State != PresenceEditing {
+			return fmt.Errorf("%w: state must be viewing or editing", ErrInvalidRequest)
+		}
+		if !s.subscribed(cl, msg.Topic) {
+			return fmt.Errorf("%w: not subscribed to %s", ErrInvalidRequest, msg.Topic)
+		}
+		s.mu.Lock()
+		cl.topics[msg.Topic] = msg.State
+		s.mu.Unlock()
+		s.announce(c, s.presenceOf(cl, msg.Topic, msg.State))
+		return nil
+	case "typing":
+		if !s.subscribed(cl, msg.Topic) {
+			return fmt.Errorf("%w: not subscribed to %s", ErrInvalidRequest, msg.Topic)
+		}
+		s.announce(c, presenceNotice{Kind: "typing", Topic: msg.Topic, ConnID: cl.id, UserID: cl.userID, Username: cl.username})
+		return nil
+	case "pong":
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown message type %q", ErrInvalidRequest, msg.Type)
+	}
+}
+
+func (s *CollabService) subscribe(c context.Context, cl *CollabClient, topic string) error {
+	if err := s.authorizeTopic(c, cl.userID, topic); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if _, ok := cl.topics[topic]; !ok && len(cl.topics) >= collabMaxTopics {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: at most %d subscriptions per connection", ErrInvalidRequest, collabMaxTopics)
+	}
+	cl.topics[topic] = PresenceViewing
+	users := s.usersOn(topic)
+	s.mu.Unlock()
+
+	s.send(cl, model.CollabMessage{Type: "subscribed", Topic: topic, Users: users})
+	s.announce(c, s.presenceOf(cl, topic, PresenceViewing))
+	return nil
+}
+
+// authorizeTopic checks the user can read the task or project a topic names.
+func (s *CollabService) authorizeTopic(c context.Context, userID, topic string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	kind, id, _ := strings.Cut(topic, ":")
+	switch kind {
+	case "task":
+		_, err := authorizeTask(c, s.taskRepo, userID, id, accessRead)
+		return err
+	case "project":
+		pid, err := uuid.Parse(id)
+		if err != nil {
+			return repository.ErrProjectNotFound
+		}
+		// Row-level security hides projects the user can't see.
+		_, err = s.projectRepo.GetProjectByIDUnscoped(c, pid)
+		return err
+	default:
+		return fmt.Errorf("%w: topic must be task:<id> or project:<id>", ErrInvalidRequest)
+	}
+}
+
+func (s *CollabService) subscribed(cl *CollabClient, topic string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := cl.topics[topic]
+	return ok
+}
+
+func (s *CollabService) presenceOf(cl *CollabClient, topic, state string) presenceNotice {
+	return presenceNotice{Kind: "presence", Topic: topic, ConnID: cl.id, UserID: cl.userID, Username: cl.username, State: state}
+}
+
+func (s *CollabService) announce(c context.Context, n presenceNotice) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return
+	}
+
+	c, cancel := context.WithTimeout(context.WithoutCancel(c), s.timeout)
+	defer cancel()
+
+	if err := s.presenceRepo.Announce(c, body); err != nil {
+		log.Printf("CollabService.announce - Database error: %v", err)
+	}
+}
+
+// forward passes the client's stream events on for the topics it is
+// subscribed to.
+func (s *CollabService) forward(cl *CollabClient) {
+	for {
+		select {
+		case <-cl.Done:
+			return
+		case <-cl.stream.Dropped:
+			s.drop(cl)
+			return
+		case e := <-cl.stream.C:
+			topics := []string{"task:" + e.TaskID}
+			var payload struct {
+				Task struct {
+					ProjectID *string `json:"project_id"`
+				} `json:"task"`
+			}
+			if json.Unmarshal(e.Data, &payload) == nil && payload.Task.ProjectID != nil {
+				topics = append(topics, "project:"+*payload.Task.ProjectID)
+			}
+
+			for _, topic := range topics {
+				if s.subscribed(cl, topic) {
+					s.send(cl, model.CollabMessage{Type: "event", Topic: topic, Event: e.Event, Data: e.Data})
+				}
+			}
+		}
+	}
+}
+
+// usersOn lists who is on a topic, each user once with the strongest state
+// any of their connections has. s.mu must be held.
+func (s *CollabService) usersOn(topic string) []model.PresenceUser {
+	byUser := map[string]model.PresenceUser{}
+	for _, e := range s.presence[topic] {
+		if u, ok := byUser[e.userID]; ok && u.State == PresenceEditing {
+			continue
+		}
+		byUser[e.userID] = model.PresenceUser{UserID: e.userID, Username: e.username, State: e.state}
+	}
+
+	users := make([]model.PresenceUser, 0, len(byUser))
+	for _, u := range byUser {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
+
+// delivery is a message bound for a client, collected under s.mu and sent
+// after it is released, since send may need the lock to drop the client.
+type delivery struct {
+	cl  *CollabClient
+	msg model.CollabMessage
+}
+
+// apply records a notice from any server and tells this server's clients on
+// the topic about it.
+func (s *CollabService) apply(n presenceNotice) {
+	s.mu.Lock()
+	var out []delivery
+
+	switch n.Kind {
+	case "typing":
+		for cl := range s.clients {
+			if _, ok := cl.topics[n.Topic]; ok && cl.id != n.ConnID {
+				out = append(out, delivery{cl, model.CollabMessage{Type: "typing", Topic: n.Topic, UserID: n.UserID, Username: n.Username}})
+			}
+		}
+	case "presence":
+		entries := s.presence[n.Topic]
+		if entries == nil {
+			entries = map[string]presenceEntry{}
+			s.presence[n.Topic] = entries
+		}
+		old, existed := entries[n.ConnID]
+		entries[n.ConnID] = presenceEntry{userID: n.UserID, username: n.Username, state: n.State, expires: time.Now().Add(presenceTTL)}
+		// Refreshes that change nothing aren't worth telling anyone about.
+		if !existed || old.state != n.State {
+			out = s.presenceFor(n.Topic)
+		}
+	case "leave":
+		if _, ok := s.presence[n.Topic][n.ConnID]; ok {
+			delete(s.presence[n.Topic], n.ConnID)
+			if len(s.presence[n.Topic]) == 0 {
+				delete(s.presence, n.Topic)
+			}
+			out = s.presenceFor(n.Topic)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, d := range out {
+		s.send(d.cl, d.msg)
+	}
+}
+
+// presenceFor builds the topic's presence message for each of this server's
+// clients on it. s.mu must be held.
+func (s *CollabService) presenceFor(topic string) []delivery {
+	users := s.usersOn(topic)
+
+	var out []delivery
+	for cl := range s.clients {
+		if _, ok := cl.topics[topic]; ok {
+			out = append(out, delivery{cl, model.CollabMessage{Type: "presence", Topic: topic, Users: users}})
+		}
+	}
+	return out
+}
+
+// Run listens for presence notices from every server until c is done, and
+// keeps this server's presence fresh while dropping what others stopped
+// refreshing.
+func (s *CollabService) Run(c context.Context) {
+	go s.refresh(c)
+
+	for {
+		err := s.presenceRepo.Listen(c, func(notice []byte) {
+			if notice == nil {
+				return
+			}
+			var n presenceNotice
+			if err := json.Unmarshal(notice, &n); err != nil {
+				log.Printf("CollabService.Run - Bad presence notice: %v", err)
+				return
+			}
+			s.apply(n)
+		})
+		if c.Err() != nil {
+			return
+		}
+		log.Printf("CollabService.Run - Lost the listening connection: %v", err)
+
+		select {
+		case <-c.Done():
+			return
+		case <-time.After(presenceRetry):
+		}
+	}
+}
+
+func (s *CollabService) refresh(c context.Context) {
+	ticker := time.NewTicker(presenceRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var notices []presenceNotice
+		var out []delivery
+
+		s.mu.Lock()
+		now := time.Now()
+		for topic, entries := range s.presence {
+			expired := false
+			for conn, e := range entries {
+				if now.After(e.expires) {
+					delete(entries, conn)
+					expired = true
+				}
+			}
+			if len(entries) == 0 {
+				delete(s.presence, topic)
+			}
+			if expired {
+				out = append(out, s.presenceFor(topic)...)
+			}
+		}
+		for cl := range s.clients {
+			for topic, state := range cl.topics {
+				notices = append(notices, s.presenceOf(cl, topic, state))
+			}
+			out = append(out, delivery{cl, model.CollabMessage{Type: "ping"}})
+		}
+		s.mu.Unlock()
+
+		for _, d := range out {
+			s.send(d.cl, d.msg)
+		}
+		for _, n := range notices {
+			s.announce(c, n)
+		}
+	}
+}
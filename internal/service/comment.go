@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const maxCommentLength = 10000
+
+func cleanComment(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > maxCommentLength {
+		return "", fmt.Errorf("%w: comments must be between 1 and %d characters", ErrInvalidRequest, maxCommentLength)
+	}
+	return body, nil
+}
+
+func (s *TaskService) GetComments(c context.Context, userID, taskID string) ([]repository.Comment, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessRead)
+	if err != nil {
+		log.Printf("TaskService.GetComments - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.taskRepo.GetComments(c, tid)
+}
+
+func (s *TaskService) AddComment(c context.Context, userID, taskID, body string) (*repository.Comment, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.AddComment - Starting attempt to comment on task: %s", taskID)
+
+	body, err := cleanComment(body)
+	if err != nil {
+		return nil, err
+	}
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("TaskService.AddComment - Access check failed: %v", err)
+		return nil, err
+	}
+
+	cm, err := s.taskRepo.CreateComment(c, tid, uuid.MustParse(userID), body)
+	if err != nil {
+		log.Printf("TaskService.AddComment - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TaskService.AddComment - Successfully added comment: %s", cm.ID)
+	return cm, nil
+}
+
+// UpdateComment edits one of the caller's own comments.
+func (s *TaskService) UpdateComment(c context.Context, userID, taskID, commentID, body string) (*repository.Comment, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.UpdateComment - Starting attempt to update comment: %s", commentID)
+
+	body, err := cleanComment(body)
+	if err != nil {
+		return nil, err
+	}
+
+	cid, err := uuid.Parse(commentID)
+	if err != nil {
+		return nil, repository.ErrCommentNotFound
+	}
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("TaskService.UpdateComment - Access check failed: %v", err)
+		return nil, err
+	}
+
+	cm, err := s.taskRepo.UpdateComment(c, cid, tid, uuid.MustParse(userID), body)
+	if err != nil {
+		log.Printf("TaskService.UpdateComment - Database error: %v", err)
+		return nil, err
+	}
+
+	return cm, nil
+}
+
+// DeleteComment removes one of the caller's own comments.
+func (s *TaskService) DeleteComment(c context.Context, userID, taskID, commentID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.DeleteComment - Starting attempt to delete comment: %s", commentID)
+
+	cid, err := uuid.Parse(commentID)
+	if err != nil {
+		return repository.ErrCommentNotFound
+	}
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("TaskService.DeleteComment - Access check failed: %v", err)
+		return err
+	}
+
+	if err := s.taskRepo.DeleteComment(c, cid, tid, uuid.MustParse(userID)); err != nil {
+		log.Printf("TaskService.DeleteComment - Database error: %v", err)
+		return err
+	}
+
+	log.Printf("TaskService.DeleteComment - Successfully deleted comment: %s", commentID)
+	return nil
+}
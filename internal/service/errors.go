@@ -0,0 +1,17 @@
+package service
+
+import "errors"
+
+var (
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrForbidden          = errors.New("you do not have access to this task")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrFileTooLarge       = errors.New("file exceeds the maximum upload size")
+	ErrWorkspaceForbidden = errors.New("your role in this workspace does not allow this")
+	ErrPasswordRequired   = errors.New("this link is password protected")
+	ErrLastOwner          = errors.New("a workspace must keep at least one owner")
+	ErrViewForbidden      = errors.New("only the owner of a view can change it")
+	ErrPreconditionFailed = errors.New("the resource does not match the given precondition")
+	ErrInvalidSyncToken   = errors.New("sync token is not valid")
+)
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	statusDone     = "DONE"
+	maxStoryPoints = 100
+)
+
+func validateEstimates(storyPoints *int, estimate, remaining *int64) error {
+	if storyPoints != nil && (*storyPoints < 0 || *storyPoints > maxStoryPoints) {
+		return fmt.Errorf("%w: story_points must be between 0 and %d", ErrInvalidRequest, maxStoryPoints)
+	}
+	if estimate != nil && *estimate < 0 {
+		return fmt.Errorf("%w: estimate_seconds cannot be negative", ErrInvalidRequest)
+	}
+	if remaining != nil && *remaining < 0 {
+		return fmt.Errorf("%w: remaining_seconds cannot be negative", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// checkParent makes sure userID may edit the parent task and, when
+// re-parenting an existing task, that the move keeps the task inside its
+// workspace and doesn't create a cycle. It returns the parent.
+func (s *TaskService) checkParent(c context.Context, taskID uuid.UUID, userID, parentID string) (*repository.Task, error) {
+	if _, err := uuid.Parse(parentID); err != nil {
+		return nil, fmt.Errorf("%w: parent_id must be a task id", ErrInvalidRequest)
+	}
+
+	pid, err := authorizeTask(c, s.taskRepo, userID, parentID, accessWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := s.taskRepo.GetTaskByID(c, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	if wid, ok := repository.WorkspaceFromContext(c); ok && parent.WorkspaceID != wid.String() {
+		return nil, fmt.Errorf("%w: parent task must be in the same workspace", ErrInvalidRequest)
+	}
+
+	if taskID == uuid.Nil {
+		return parent, nil
+	}
+
+	task, err := s.taskRepo.GetTaskByID(c, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if parent.WorkspaceID != task.WorkspaceID {
+		return nil, fmt.Errorf("%w: parent task must be in the same workspace", ErrInvalidRequest)
+	}
+
+	cycle, err := s.taskRepo.IsDescendant(c, taskID, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	if cycle {
+		return nil, fmt.Errorf("%w: a task cannot be nested under itself or its subtasks", ErrInvalidRequest)
+	}
+
+	return parent, nil
+}
+
+// patchParent checks a parent_id from a patch. Null or empty moves the task
+// back to the top level.
+func (s *TaskService) patchParent(c context.Context, userID string, taskID uuid.UUID, parentID *string) (*uuid.UUID, error) {
+	if parentID == nil || *parentID == "" {
+		return nil, nil
+	}
+
+	if _, err := s.checkParent(c, taskID, userID, *parentID); err != nil {
+		return nil, err
+	}
+
+	pid := uuid.MustParse(*parentID)
+	return &pid, nil
+}
+
+// GetEstimate returns the task's own estimate together with the totals rolled
+// up from its subtasks and the time actually tracked against them.
+func (s *TaskService) GetEstimate(c context.Context, userID, taskID string) (*model.ResponseTaskEstimate, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.GetEstimate - Starting attempt to fetch estimate for task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessRead)
+	if err != nil {
+		log.Printf("TaskService.GetEstimate - Task lookup failed: %v", err)
+		return nil, err
+	}
+
+	task, err := s.taskRepo.GetTaskByID(c, tid)
+	if err != nil {
+		log.Printf("TaskService.GetEstimate - Database error: %v", err)
+		return nil, err
+	}
+
+	rollup, err := s.taskRepo.GetRollup(c, tid)
+	if err != nil {
+		log.Printf("TaskService.GetEstimate - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TaskService.GetEstimate - Successfully fetched estimate for task: %s", taskID)
+
+	return &model.ResponseTaskEstimate{
+		TaskID:           task.ID,
+		StoryPoints:      task.StoryPoints,
+		EstimateSeconds:  task.EstimateSeconds,
+		RemainingSeconds: task.RemainingSeconds,
+		Rollup:           newEstimateTotals(*rollup),
+		VarianceSeconds:  rollup.ActualSeconds - rollup.EstimateSeconds,
+	}, nil
+}
+
+// GetEstimateSummary totals the user's estimates per status, plus overall
+// committed work versus work that is done.
+func (s *TaskService) GetEstimateSummary(c context.Context, userID string) (*model.ResponseEstimateSummary, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.GetEstimateSummary - Starting attempt to summarise estimates for user: %s", userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TaskService.GetEstimateSummary - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	estimates, err := s.taskRepo.GetEstimatesByStatus(c, uid)
+	if err != nil {
+		log.Printf("TaskService.GetEstimateSummary - Database error: %v", err)
+		return nil, err
+	}
+
+	summary := &model.ResponseEstimateSummary{ByStatus: []model.StatusEstimate{}}
+	for _, e := range estimates {
+		summary.ByStatus = append(summary.ByStatus, model.StatusEstimate{
+			Status:         e.Status,
+			EstimateTotals: newEstimateTotals(e.EstimateTotals),
+		})
+
+		addTotals(&summary.Committed, e.EstimateTotals)
+		if e.Status == statusDone {
+			addTotals(&summary.Done, e.EstimateTotals)
+		}
+	}
+
+	log.Printf("TaskService.GetEstimateSummary - Successfully summarised estimates for user: %s", userID)
+	return summary, nil
+}
+
+func newEstimateTotals(t repository.EstimateTotals) model.EstimateTotals {
+	return model.EstimateTotals{
+		Tasks:            t.Tasks,
+		StoryPoints:      t.StoryPoints,
+		EstimateSeconds:  t.EstimateSeconds,
+		RemainingSeconds: t.RemainingSeconds,
+		ActualSeconds:    t.ActualSeconds,
+	}
+}
+
+func addTotals(dst *model.EstimateTotals, src repository.EstimateTotals) {
+	dst.Tasks += src.Tasks
+	dst.StoryPoints += src.StoryPoints
+	dst.EstimateSeconds += src.EstimateSeconds
+	dst.RemainingSeconds += src.RemainingSeconds
+	dst.ActualSeconds += src.ActualSeconds
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/filter"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+// exportTimeout bounds how long an export may keep streaming.
+const exportTimeout = 5 * time.Minute
+
+// exportFormats are the formats tasks can be exported in. Markdown and HTML
+// are reports, grouped by status, where CSV and JSON hold the data as is.
+var exportFormats = map[string]struct {
+	contentType string
+	byStatus    bool
+	writer      func(w *bufio.Writer, now time.Time) exportWriter
+}{
+	"csv":  {"text/csv; charset=utf-8", false, newCSVExport},
+	"json": {"application/json; charset=utf-8", false, newJSONExport},
+	"md":   {"text/markdown; charset=utf-8", true, newMarkdownExport},
+	"html": {"text/html; charset=utf-8", true, newHTMLExport},
+}
+
+// exportWriter writes out the tasks of an export one by one, as they are
+// read, so that nothing but the current task is held in memory.
+type exportWriter interface {
+	task(t *repository.LabeledTask) error
+	end() error
+}
+
+// TaskExport is an export that has been checked and is ready to be written.
+type TaskExport struct {
+	ContentType string
+	Filename    string
+
+	s      *TaskService
+	format string
+	userID uuid.UUID
+	query  repository.TaskQuery
+}
+
+// Export checks an export of the tasks the caller can see that match req.
+// Nothing is read until it is written out, so that a bad request can still be
+// answered with an error.
+func (s *TaskService) Export(c context.Context, userID string, req model.RequestExport) (*TaskExport, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TaskService.Export - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	format, ok := exportFormats[req.Format]
+	if !ok {
+		return nil, fmt.Errorf("%w: format must be csv, json, md or html", ErrInvalidRequest)
+	}
+
+	q, err := parseTaskQuery(req.RequestListTasks)
+	if err != nil {
+		return nil, err
+	}
+	q.After, q.Limit = nil, 0
+	q.ByStatus = format.byStatus
+
+	if req.Query != "" {
+		node, err := filter.Parse(req.Query, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		q.Filter = node
+		if req.Archived == "" && filter.Uses(node, filter.FieldArchived) {
+			q.Archived = repository.IncludeArchived
+		}
+	}
+
+	return &TaskExport{
+		ContentType: format.contentType,
+		Filename:    fmt.Sprintf("tasks-%s.%s", time.Now().UTC().Format("2006-01-02"), req.Format),
+		s:           s,
+		format:      req.Format,
+		userID:      uid,
+		query:       q,
+	}, nil
+}
+
+// Write streams the export to w. Once it has started there is no taking it
+// back, so an error part way through leaves the output cut short.
+func (e *TaskExport) Write(c context.Context, w io.Writer) error {
+	c, cancel := context.WithTimeout(c, exportTimeout)
+	defer cancel()
+
+	log.Printf("TaskService.Export - Starting %s export for user: %s", e.format, e.userID)
+
+	bw := bufio.NewWriter(w)
+	out := exportFormats[e.format].writer(bw, time.Now().UTC())
+
+	n := 0
+	err := e.s.taskRepo.EachVisibleTask(c, e.userID, e.query, func(t *repository.LabeledTask) error {
+		n++
+		return out.task(t)
+	})
+	if err == nil {
+		err = out.end()
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		log.Printf("TaskService.Export - Export failed after %d tasks: %v", n, err)
+		return err
+	}
+
+	log.Printf("TaskService.Export - Exported %d tasks for user: %s", n, e.userID)
+	return nil
+}
+
+// csvExport writes a header line and one line per task. Its columns are the
+// field names a CSV import reads without a mapping.
+type csvExport struct {
+	w *csv.Writer
+}
+
+var csvExportHeader = []string{
+	"id", "name", "description", "status", "labels", "project_id", "parent_id", "story_points",
+	"estimate_seconds", "remaining_seconds", "due_at", "created_at", "updated_at", "archived_at",
+}
+
+func newCSVExport(w *bufio.Writer, now time.Time) exportWriter {
+	e := &csvExport{w: csv.NewWriter(w)}
+	_ = e.w.Write(csvExportHeader)
+	return e
+}
+
+func (e *csvExport) task(t *repository.LabeledTask) error {
+	return e.w.Write([]string{
+		t.ID,
+		csvCell(t.Name),
+		csvCell(t.Description),
+		csvCell(t.Status),
+		csvCell(strings.Join(t.Labels, ",")),
+		exportString(t.ProjectID),
+		exportString(t.ParentID),
+		exportInt(t.StoryPoints),
+		exportInt64(t.EstimateSeconds),
+		exportInt64(t.RemainingSeconds),
+		exportTime(t.DueAt),
+		t.CreatedAt.UTC().Format(time.RFC3339),
+		t.UpdatedAt.UTC().Format(time.RFC3339),
+		exportTime(t.ArchivedAt),
+	})
+}
+
+// csvCell keeps spreadsheets from running text typed by users as a formula,
+// by starting any cell that would be read as one with a quote.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (e *csvExport) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExport writes a JSON array, one task per line.
+type jsonExport struct {
+	w *bufio.Writer
+	n int
+}
+
+type exportTask struct {
+	*repository.Task
+	Labels []string `json:"labels"`
+}
+
+func newJSONExport(w *bufio.Writer, now time.Time) exportWriter {
+	w.WriteString("[")
+	return &jsonExport{w: w}
+}
+
+func (e *jsonExport) task(t *repository.LabeledTask) error {
+	b, err := json.Marshal(exportTask{Task: &t.Task, Labels: t.Labels})
+	if err != nil {
+		return err
+	}
+
+	if e.n > 0 {
+		e.w.WriteString(",")
+	}
+	e.n++
+	e.w.WriteString("\n")
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonExport) end() error {
+	_, err := e.w.WriteString("\n]\n")
+	return err
+}
+
+// markdownExport writes a checklist with a section per status.
+type markdownExport struct {
+	w      *bufio.Writer
+	status *string
+}
+
+func newMarkdownExport(w *bufio.Writer, now time.Time) exportWriter {
+	fmt.Fprintf(w, "# Tasks\n\nExported %s.\n", now.Format("2006-01-02 15:04 UTC"))
+	return &markdownExport{w: w}
+}
+
+func (e *markdownExport) task(t *repository.LabeledTask) error {
+	if e.status == nil || *e.status != t.Status {
+		e.status = &t.Status
+		fmt.Fprintf(e.w, "\n## %s\n\n", markdownEscape(statusTitle(t.Status)))
+	}
+
+	check := " "
+	if t.Status == statusDone {
+		check = "x"
+	}
+
+	line := "- [" + check + "] " + markdownEscape(t.Name)
+	var details []string
+	if t.DueAt != nil {
+		details = append(details, "due "+exportDue(*t.DueAt))
+	}
+	for _, label := range t.Labels {
+		details = append(details, "`"+strings.ReplaceAll(label, "`", "'")+"`")
+	}
+	if len(details) > 0 {
+		line += " — " + strings.Join(details, " ")
+	}
+
+	_, err := e.w.WriteString(line + "\n")
+	return err
+}
+
+func (e *markdownExport) end() error {
+	if e.status == nil {
+		_, err := e.w.WriteString("\nNo tasks.\n")
+		return err
+	}
+	return nil
+}
+
+// htmlExport writes a standalone page, with a table per status, that prints
+// cleanly for anyone after a PDF.
+type htmlExport struct {
+	w      *bufio.Writer
+	status *string
+	n      int
+}
+
+const htmlExportHead = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Tasks</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2328; }
+h2 { margin-top: 2rem; border-bottom: 1px solid #d0d7de; padding-bottom: .25rem; }
+table { width: 100%; border-collapse: collapse; }
+th, td { text-align: left; vertical-align: top; padding: .4rem .5rem; border-bottom: 1px solid #eaeef2; }
+th { font-size: .8rem; text-transform: uppercase; color: #656d76; }
+.done { color: #656d76; text-decoration: line-through; }
+.description { white-space: pre-wrap; font-size: .85rem; color: #656d76; }
+.label { display: inline-block; margin: 0 .25rem .25rem 0; padding: 0 .4rem; border-radius: 1rem; background: #eaeef2; font-size: .8rem; }
+.meta { color: #656d76; }
+@media print { body { margin: 0; } section { break-inside: avoid-page; } }
+</style>
+</head>
+<body>
+<h1>Tasks</h1>
+`
+
+func newHTMLExport(w *bufio.Writer, now time.Time) exportWriter {
+	w.WriteString(htmlExportHead)
+	fmt.Fprintf(w, "<p class=\"meta\">Exported %s.</p>\n", now.Format("2006-01-02 15:04 UTC"))
+	return &htmlExport{w: w}
+}
+
+func (e *htmlExport) task(t *repository.LabeledTask) error {
+	if e.status == nil || *e.status != t.Status {
+		if e.status != nil {
+			e.w.WriteString("</tbody>\n</table>\n</section>\n")
+		}
+		e.status = &t.Status
+		fmt.Fprintf(e.w, "<section>\n<h2>%s</h2>\n<table>\n<thead><tr><th>Task</th><th>Labels</th><th>Due</th><th>Updated</th></tr></thead>\n<tbody>\n",
+			html.EscapeString(statusTitle(t.Status)))
+	}
+	e.n++
+
+	class := ""
+	if t.Status == statusDone {
+		class = ` class="done"`
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<tr><td><div%s>%s</div>", class, html.EscapeString(t.Name))
+	if t.Description != "" {
+		fmt.Fprintf(&b, `<div class="description">%s</div>`, html.EscapeString(t.Description))
+	}
+	b.WriteString("</td><td>")
+	for _, label := range t.Labels {
+		fmt.Fprintf(&b, `<span class="label">%s</span>`, html.EscapeString(label))
+	}
+	b.WriteString("</td><td>")
+	if t.DueAt != nil {
+		b.WriteString(exportDue(*t.DueAt))
+	}
+	fmt.Fprintf(&b, "</td><td>%s</td></tr>\n", t.UpdatedAt.UTC().Format("2006-01-02"))
+
+	_, err := e.w.WriteString(b.String())
+	return err
+}
+
+func (e *htmlExport) end() error {
+	if e.status != nil {
+		e.w.WriteString("</tbody>\n</table>\n</section>\n")
+	}
+
+	tasks := "tasks"
+	if e.n == 1 {
+		tasks = "task"
+	}
+	_, err := fmt.Fprintf(e.w, "<p class=\"meta\">%d %s.</p>\n</body>\n</html>\n", e.n, tasks)
+	return err
+}
+
+// statusTitle turns a status into a heading: IN_PROGRESS becomes
+// "In progress".
+func statusTitle(status string) string {
+	title := strings.ToLower(strings.ReplaceAll(status, "_", " "))
+	if title == "" {
+		return "No status"
+	}
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "\r", "", "\n", " ",
+)
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// exportDue formats a due time for a report, leaving the time out when it is
+// midnight UTC, as it is for tasks due on a day rather than at a time.
+func exportDue(t time.Time) string {
+	t = t.UTC()
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04 UTC")
+}
+
+func exportString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func exportInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+func exportInt64(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatInt(*n, 10)
+}
+
+func exportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+func TestCSVExportEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{`=HYPERLINK("https://example.com","x")`, `'=HYPERLINK("https://example.com","x")`},
+		{"+1 for this", "'+1 for this"},
+		{"-2 days", "'-2 days"},
+		{"@everyone", "'@everyone"},
+		{"\tindented", "'\tindented"},
+		{"\rreturn", "'\rreturn"},
+		{"write report", "write report"},
+		{"a=b", "a=b"},
+		{"", ""},
+	}
+
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	out := newCSVExport(bw, time.Now())
+	for _, tt := range tests {
+		err := out.task(&repository.LabeledTask{
+			Task:   repository.Task{ID: "1", Name: tt.name, Description: tt.name, Status: tt.name},
+			Labels: []string{tt.name},
+		})
+		if err != nil {
+			t.Fatalf("write task: %v", err)
+		}
+	}
+	if err := out.end(); err != nil {
+		t.Fatalf("end export: %v", err)
+	}
+	if err := bw.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read back export: %v", err)
+	}
+	if len(rows) != len(tests)+1 {
+		t.Fatalf("export has %d rows, want %d", len(rows), len(tests)+1)
+	}
+
+	// name, description, status and labels are all typed by users.
+	for i, tt := range tests {
+		for _, col := range []int{1, 2, 3, 4} {
+			if got := rows[i+1][col]; got != tt.want {
+				t.Errorf("%s of %q exported as %q, want %q", csvExportHeader[col], tt.name, got, tt.want)
+			}
+		}
+	}
+}
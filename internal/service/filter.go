@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/filter"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+// FilterTasks returns one page of the tasks the caller can see that match
+// query, written in the filter language, and the cursor of the next page.
+// A query that asks about archived tasks sees them unless req says otherwise.
+func (s *TaskService) FilterTasks(c context.Context, userID, query string, req model.RequestListTasks) ([]repository.Task, string, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.FilterTasks - Starting attempt to filter tasks for user: %s", userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TaskService.FilterTasks - UUID parsing error: %v", err)
+		return nil, "", err
+	}
+
+	node, err := filter.Parse(query, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+
+	q, err := parseTaskQuery(req)
+	if err != nil {
+		return nil, "", err
+	}
+	q.Filter = node
+	if req.Archived == "" && filter.Uses(node, filter.FieldArchived) {
+		q.Archived = repository.IncludeArchived
+	}
+
+	t, err := s.taskRepo.GetVisibleTasks(c, uid, q)
+	if err != nil {
+		log.Printf("TaskService.FilterTasks - Database error: %v", err)
+		return nil, "", err
+	}
+
+	t, next := nextCursor(t, q)
+	log.Printf("TaskService.FilterTasks - Successfully filtered tasks for user: %s", userID)
+	return t, next, nil
+}
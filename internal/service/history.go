@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+func (s *TaskService) GetHistory(c context.Context, userID, taskID string) ([]repository.TaskEvent, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.GetHistory - Starting attempt to fetch history for task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessRead)
+	if err != nil {
+		log.Printf("TaskService.GetHistory - Access check failed: %v", err)
+		return nil, err
+	}
+
+	events, err := s.taskRepo.GetHistory(c, tid)
+	if err != nil {
+		log.Printf("TaskService.GetHistory - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TaskService.GetHistory - Successfully fetched history for task: %s", taskID)
+	return events, nil
+}
+
+// Revert rolls the task's content back to how it stood right after the
+// given history event.
+func (s *TaskService) Revert(c context.Context, userID, taskID string, req model.RequestRevertTask) (*model.ResponseCreateTask, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.Revert - Starting attempt to revert task %s to event %d", taskID, req.EventID)
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("TaskService.Revert - Access check failed: %v", err)
+		return nil, err
+	}
+
+	var task *repository.Task
+	err = s.outboxService.InTx(c, func(c context.Context) (err error) {
+		// The status before the revert goes out with task.status_changed.
+		before, err := s.taskRepo.GetTaskByID(c, tid)
+		if err != nil {
+			return err
+		}
+
+		if task, err = s.taskRepo.RevertTask(c, tid, req.EventID); err != nil {
+			return err
+		}
+
+		if err := s.outboxService.RecordTask(c, EventTaskUpdated, task, nil); err != nil {
+			return err
+		}
+		if before.Status != task.Status {
+			return s.outboxService.RecordTask(c, EventTaskStatusChanged, task, map[string]any{"previous_status": before.Status})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("TaskService.Revert - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TaskService.Revert - Successfully reverted task: %s", taskID)
+	return newTaskResponse(task), nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const defaultIdempotencyTTL = 24 * time.Hour
+
+var (
+	ErrIdempotencyMismatch = errors.New("this Idempotency-Key was already used for a different request")
+	ErrIdempotencyInFlight = errors.New("a request with this Idempotency-Key is still being processed")
+)
+
+// IdempotencyService remembers responses to requests sent with an
+// Idempotency-Key, so that a retried request gets the first response back
+// instead of being carried out twice.
+type IdempotencyService struct {
+	idempotencyRepo *repository.IdempotencyRepository
+	ttl             time.Duration
+	timeout         time.Duration
+}
+
+func NewIdempotencyService(idempotencyRepo *repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		timeout:         time.Duration(2) * time.Second,
+	}
+}
+
+// Reserve claims key for the request with the given fingerprint. It returns
+// nil when the request should go ahead, or the stored response to replay.
+func (s *IdempotencyService) Reserve(c context.Context, scope, key, fingerprint string) (*repository.IdempotencyRecord, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	rec, err := s.idempotencyRepo.Reserve(c, scope, key, fingerprint, s.ttl)
+	if err != nil {
+		log.Printf("IdempotencyService.Reserve - Database error: %v", err)
+		return nil, err
+	}
+
+	switch {
+	case rec == nil:
+		return nil, nil
+	case rec.Fingerprint != fingerprint:
+		return nil, ErrIdempotencyMismatch
+	case rec.Status == nil:
+		return nil, ErrIdempotencyInFlight
+	default:
+		log.Printf("IdempotencyService.Reserve - Replaying response for key: %s", key)
+		return rec, nil
+	}
+}
+
+func (s *IdempotencyService) Complete(c context.Context, scope, key string, status int, header http.Header, body []byte) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.idempotencyRepo.Complete(c, scope, key, status, header, body)
+}
+
+func (s *IdempotencyService) Release(c context.Context, scope, key string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.idempotencyRepo.Release(c, scope, key)
+}
+
+// RunPurger drops expired keys every interval until c is done.
+func (s *IdempotencyService) RunPurger(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		dc, cancel := context.WithTimeout(c, s.timeout)
+		if _, err := s.idempotencyRepo.Purge(dc, time.Now().Add(-s.ttl)); err != nil {
+			log.Printf("IdempotencyService.RunPurger - Database error: %v", err)
+		}
+		cancel()
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
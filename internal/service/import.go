@@ -0,0 +1,540 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	// MaxImportBytes caps the size of a file to import.
+	MaxImportBytes = 20 << 20
+
+	// maxImportRows caps the tasks one file may hold.
+	maxImportRows = 50000
+
+	// Imports of more than maxInlineImportRows tasks are left to a background
+	// job instead of being run within the request.
+	maxInlineImportRows = 500
+
+	importPreviewRows = 20
+
+	importJobLease       = 15 * time.Minute
+	importJobTimeout     = 10 * time.Minute
+	importJobAttempts    = 3
+	importJobRetention   = 7 * 24 * time.Hour
+	importProgressStride = 1000
+)
+
+const (
+	importPreview  = "preview"
+	importImported = "imported"
+	importQueued   = "queued"
+	importRejected = "rejected"
+)
+
+// importTimeLayouts are the due date formats an import understands, besides
+// RFC 3339. Dates without a time are due at midnight.
+var importTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"Jan 2 2006",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+}
+
+// ImportService creates tasks in bulk from files exported by other tools.
+// Every row is validated before anything is written, and the tasks are
+// inserted in a single transaction, so an import either lands whole or not
+// at all. Imported tasks don't raise task.created events one by one.
+type ImportService struct {
+	importRepo    *repository.ImportRepository
+	taskRepo      *repository.TaskRepository
+	workspaceRepo *repository.WorkspaceRepository
+	taskService   *TaskService
+	timeout       time.Duration
+}
+
+func NewImportService(importRepo *repository.ImportRepository, taskRepo *repository.TaskRepository, workspaceRepo *repository.WorkspaceRepository, taskService *TaskService) *ImportService {
+	return &ImportService{
+		importRepo:    importRepo,
+		taskRepo:      taskRepo,
+		workspaceRepo: workspaceRepo,
+		taskService:   taskService,
+		timeout:       time.Duration(2) * time.Second,
+	}
+}
+
+// Import reads the file and validates every row. A dry run stops there and
+// previews the result. Otherwise the tasks are created in the workspace from
+// the route, or the user's personal workspace: right away for small files,
+// by a background job for large ones. Rows that fail validation reject the
+// whole import unless SkipInvalid is set, in which case they are left out.
+func (s *ImportService) Import(c context.Context, userID string, req model.RequestImport, file io.Reader) (*model.ResponseImport, error) {
+	log.Printf("ImportService.Import - Starting %s import for user: %s", req.Format, userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("ImportService.Import - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	read, ok := importReaders[req.Format]
+	if !ok {
+		return nil, fmt.Errorf("%w: format must be csv, json, todoist or trello", ErrInvalidRequest)
+	}
+
+	var mapping model.ImportMapping
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &mapping); err != nil {
+			return nil, fmt.Errorf("%w: mapping is not valid JSON", ErrInvalidRequest)
+		}
+	}
+
+	loc, err := loadLocation(mapping.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	records, ignored, err := read(file, &mapping)
+	if err != nil {
+		log.Printf("ImportService.Import - Failed to read the file: %v", err)
+		return nil, err
+	}
+	if len(records) > maxImportRows {
+		return nil, fmt.Errorf("%w: at most %d tasks can be imported at once", ErrInvalidRequest, maxImportRows)
+	}
+
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, err := s.workspace(c, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, rowErrors := s.validate(c, wid, records, &mapping, loc)
+	res := &model.ResponseImport{
+		Total:   len(records),
+		Valid:   len(tasks),
+		Ignored: ignored,
+		Errors:  rowErrors,
+	}
+
+	switch {
+	case req.DryRun:
+		res.Status = importPreview
+		res.Preview = tasks[:min(len(tasks), importPreviewRows)]
+		return res, nil
+	case len(rowErrors) > 0 && !req.SkipInvalid:
+		res.Status = importRejected
+		return res, nil
+	case len(tasks) > maxInlineImportRows:
+		payload, err := json.Marshal(tasks)
+		if err != nil {
+			return nil, err
+		}
+
+		job, err := s.importRepo.CreateJob(c, &repository.ImportJob{
+			UserID:      userID,
+			WorkspaceID: wid.String(),
+			Format:      req.Format,
+			Total:       len(tasks),
+		}, payload)
+		if err != nil {
+			log.Printf("ImportService.Import - Database error: %v", err)
+			return nil, err
+		}
+
+		log.Printf("ImportService.Import - Queued job %s to import %d tasks", job.ID, len(tasks))
+		res.Status = importQueued
+		res.JobID = job.ID
+		return res, nil
+	}
+
+	if err := s.insert(c, uid, wid, tasks, nil); err != nil {
+		log.Printf("ImportService.Import - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ImportService.Import - Imported %d tasks for user: %s", len(tasks), userID)
+	res.Status = importImported
+	res.Imported = len(tasks)
+	return res, nil
+}
+
+func (s *ImportService) GetJob(c context.Context, userID, jobID string) (*repository.ImportJob, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	jid, err := uuid.Parse(jobID)
+	if err != nil {
+		return nil, repository.ErrImportJobNotFound
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, repository.ErrImportJobNotFound
+	}
+
+	return s.importRepo.GetJob(c, jid, uid)
+}
+
+// workspace picks the workspace tasks are imported into and makes sure the
+// user may create tasks there.
+func (s *ImportService) workspace(c context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	wid, ok := repository.WorkspaceFromContext(c)
+	if !ok {
+		var err error
+		if wid, err = s.workspaceRepo.GetPersonalWorkspaceID(c, userID); err != nil {
+			log.Printf("ImportService.workspace - Database error: %v", err)
+			return uuid.Nil, err
+		}
+	}
+
+	role, err := s.workspaceRepo.GetRole(c, wid, userID)
+	if err != nil {
+		log.Printf("ImportService.workspace - Database error: %v", err)
+		return uuid.Nil, err
+	}
+	if roleAccess(role) < accessWrite {
+		return uuid.Nil, ErrWorkspaceForbidden
+	}
+
+	return wid, nil
+}
+
+// validate turns records into tasks, reporting what is wrong with the rows
+// that can't be. A subtask whose parent is invalid is invalid too.
+func (s *ImportService) validate(c context.Context, workspaceID uuid.UUID, records []importRecord, m *model.ImportMapping, loc *time.Location) ([]model.ImportTask, []model.ImportRowError) {
+	tasks := make([]model.ImportTask, 0, len(records))
+	rowErrors := []model.ImportRowError{}
+	valid := make(map[int]bool, len(records))
+	projects := map[string]error{}
+
+	separator := m.LabelSeparator
+	if separator == "" {
+		separator = ","
+	}
+
+	for _, rec := range records {
+		errs := rec.errors
+		fail := func(field, format string, args ...any) {
+			errs = append(errs, model.ImportRowError{Row: rec.row, Field: field, Error: fmt.Sprintf(format, args...)})
+		}
+
+		t := model.ImportTask{
+			Row:         rec.row,
+			Name:        strings.TrimSpace(rec.fields["name"]),
+			Description: rec.fields["description"],
+			Status:      importStatus(rec.fields["status"], m.Statuses),
+		}
+		if t.Name == "" {
+			fail("name", "name is required")
+		}
+
+		if rec.parentRow != 0 {
+			parent := rec.parentRow
+			t.ParentRow = &parent
+			if !valid[parent] {
+				fail("", "its parent task on row %d is invalid", parent)
+			}
+		}
+
+		if v := strings.TrimSpace(rec.fields["due_at"]); v != "" {
+			due, err := parseImportTime(v, rec.tz, loc)
+			if err != nil {
+				fail("due_at", "%q is not a date we can read", v)
+			} else {
+				t.DueAt = &due
+			}
+		}
+
+		if v := strings.TrimSpace(rec.fields["project_id"]); v != "" {
+			err, seen := projects[v]
+			if !seen {
+				err = s.taskService.checkProject(c, workspaceID, v)
+				projects[v] = err
+			}
+			if err != nil {
+				fail("project_id", "%v", err)
+			} else {
+				t.ProjectID = &v
+			}
+		}
+
+		if v := strings.TrimSpace(rec.fields["story_points"]); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				fail("story_points", "%q is not a whole number", v)
+			} else {
+				t.StoryPoints = &n
+			}
+		}
+
+		if v := strings.TrimSpace(rec.fields["estimate_seconds"]); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				fail("estimate_seconds", "%q is not a whole number", v)
+			} else {
+				t.EstimateSeconds = &n
+			}
+		}
+
+		if err := validateEstimates(t.StoryPoints, t.EstimateSeconds, nil); err != nil {
+			fail("", "%v", err)
+		}
+
+		labels := rec.labels
+		if v := rec.fields["labels"]; strings.TrimSpace(v) != "" {
+			labels = append(labels, strings.Split(v, separator)...)
+		}
+		labels = dropBlank(labels)
+		if len(labels) > 0 {
+			cleaned, err := cleanLabels(labels)
+			if err != nil {
+				fail("labels", "%v", err)
+			}
+			t.Labels = cleaned
+		}
+
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+
+		valid[rec.row] = true
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rowErrors
+}
+
+// insert creates the tasks and their labels in one transaction, reporting
+// how many tasks are in after every stride of them.
+func (s *ImportService) insert(c context.Context, userID, workspaceID uuid.UUID, tasks []model.ImportTask, progress func(done int)) error {
+	ids := make(map[int]string, len(tasks))
+	rows := make([]repository.Task, len(tasks))
+	var labelTasks, labels []string
+
+	for i, t := range tasks {
+		id := uuid.NewString()
+		ids[t.Row] = id
+
+		var parentID *string
+		if t.ParentRow != nil {
+			parent := ids[*t.ParentRow]
+			parentID = &parent
+		}
+
+		rows[i] = repository.Task{
+			ID:               id,
+			Name:             t.Name,
+			Description:      t.Description,
+			Status:           t.Status,
+			UserID:           userID.String(),
+			WorkspaceID:      workspaceID.String(),
+			ProjectID:        t.ProjectID,
+			ParentID:         parentID,
+			StoryPoints:      t.StoryPoints,
+			EstimateSeconds:  t.EstimateSeconds,
+			RemainingSeconds: t.EstimateSeconds,
+			DueAt:            t.DueAt,
+		}
+
+		for _, label := range t.Labels {
+			labelTasks = append(labelTasks, id)
+			labels = append(labels, label)
+		}
+	}
+
+	return s.taskRepo.InTx(c, func(c context.Context) error {
+		for start := 0; start < len(rows); start += importProgressStride {
+			end := min(start+importProgressStride, len(rows))
+			if err := s.taskRepo.CreateTasks(c, rows[start:end]); err != nil {
+				return err
+			}
+			if progress != nil {
+				progress(end)
+			}
+		}
+
+		if len(labels) > 0 {
+			return s.taskRepo.AddTaskLabels(c, labelTasks, labels)
+		}
+		return nil
+	})
+}
+
+// RunImporter works through queued imports one at a time until c is done.
+func (s *ImportService) RunImporter(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for s.runNext(c) {
+		}
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and runs the next queued import, reporting whether there
+// was one.
+func (s *ImportService) runNext(c context.Context) bool {
+	system := repository.WithSystem(c)
+
+	cc, cancel := context.WithTimeout(system, s.timeout)
+	job, err := s.importRepo.ClaimJob(cc, importJobLease, importJobAttempts)
+	cancel()
+	if err != nil {
+		log.Printf("ImportService.runNext - Database error: %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	log.Printf("ImportService.runNext - Starting job %s with %d tasks", job.ID, job.Total)
+
+	jid := uuid.MustParse(job.ID)
+	uid := uuid.MustParse(job.UserID)
+	wid := uuid.MustParse(job.WorkspaceID)
+
+	err = s.runJob(c, job, uid, wid, func(done int) {
+		pc, cancel := context.WithTimeout(system, s.timeout)
+		defer cancel()
+		if err := s.importRepo.SetProgress(pc, jid, done); err != nil {
+			log.Printf("ImportService.runNext - Failed to record progress of job %s: %v", job.ID, err)
+		}
+	})
+
+	var errMsg *string
+	if err != nil {
+		log.Printf("ImportService.runNext - Job %s failed: %v", job.ID, err)
+		msg := err.Error()
+		errMsg = &msg
+	} else {
+		log.Printf("ImportService.runNext - Job %s succeeded", job.ID)
+	}
+
+	fc, cancel := context.WithTimeout(system, s.timeout)
+	defer cancel()
+	if err := s.importRepo.FinishJob(fc, jid, errMsg); err != nil {
+		log.Printf("ImportService.runNext - Failed to finish job %s: %v", job.ID, err)
+	}
+	return true
+}
+
+// runJob inserts a job's tasks as the user who queued it, who must still be
+// allowed to create tasks in the workspace.
+func (s *ImportService) runJob(c context.Context, job *repository.PendingImport, userID, workspaceID uuid.UUID, progress func(done int)) error {
+	var tasks []model.ImportTask
+	if err := json.Unmarshal(job.Tasks, &tasks); err != nil {
+		return fmt.Errorf("read queued tasks: %w", err)
+	}
+
+	c, cancel := context.WithTimeout(repository.WithUser(c, userID), importJobTimeout)
+	defer cancel()
+
+	role, err := s.workspaceRepo.GetRole(c, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if roleAccess(role) < accessWrite {
+		return ErrWorkspaceForbidden
+	}
+
+	return s.insert(c, userID, workspaceID, tasks, progress)
+}
+
+// RunPurger deletes finished import jobs once they are a week old.
+func (s *ImportService) RunPurger(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pc, cancel := context.WithTimeout(repository.WithSystem(c), s.timeout)
+		n, err := s.importRepo.PurgeJobs(pc, time.Now().Add(-importJobRetention))
+		cancel()
+		if err != nil {
+			log.Printf("ImportService.RunPurger - Database error: %v", err)
+		} else if n > 0 {
+			log.Printf("ImportService.RunPurger - Purged %d import jobs", n)
+		}
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// importStatus maps a status from a file onto a task status, through the
+// mapping's statuses first. Anything else is upper-cased with spaces and
+// dashes turned into underscores; no status at all is TO_DO.
+func importStatus(status string, statuses map[string]string) string {
+	status = strings.TrimSpace(status)
+	if mapped, ok := statuses[status]; ok {
+		return mapped
+	}
+	for from, to := range statuses {
+		if strings.EqualFold(from, status) {
+			return to
+		}
+	}
+
+	if status == "" {
+		return "TO_DO"
+	}
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToUpper(status))
+}
+
+// parseImportTime reads a due date. Times without a zone are read in tz when
+// the row names one, else in loc.
+func parseImportTime(value, tz string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+
+	var err error
+	for _, layout := range importTimeLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func dropBlank(values []string) []string {
+	kept := values[:0:0]
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/0xrishabk/tasktracker/internal/model"
+)
+
+// The task fields an import can fill in, as named in an ImportMapping.
+var importFields = []string{
+	"name", "description", "status", "due_at", "labels", "project_id", "story_points", "estimate_seconds",
+}
+
+// importRecord is one task as read from a file, before validation. Fields
+// holds the raw values by task field; labels that come as a list rather than
+// a string to split are in labels. parentRow is the row of the task it is a
+// subtask of, and tz the zone its due date is in when the file says so.
+type importRecord struct {
+	row       int
+	parentRow int
+	fields    map[string]string
+	labels    []string
+	tz        string
+	errors    []model.ImportRowError
+}
+
+// importReader reads the records out of a file. ignored counts entries that
+// aren't tasks and are left out on purpose.
+type importReader func(r io.Reader, m *model.ImportMapping) (records []importRecord, ignored int, err error)
+
+var importReaders = map[string]importReader{
+	"csv":     readCSVImport,
+	"json":    readJSONImport,
+	"todoist": readTodoistImport,
+	"trello":  readTrelloImport,
+}
+
+// importColumns resolves the mapping against the columns or keys a file has,
+// returning the column each mapped field is read from.
+func importColumns(columns []string, mapping map[string]string) (map[string]string, error) {
+	for field := range mapping {
+		if !isImportField(field) {
+			return nil, fmt.Errorf("%w: mapping names unknown field %q", ErrInvalidRequest, field)
+		}
+	}
+
+	resolved := make(map[string]string, len(importFields))
+	for _, field := range importFields {
+		if column, ok := mapping[field]; ok {
+			if columns != nil && !containsString(columns, column) {
+				return nil, fmt.Errorf("%w: column %q mapped to %s is not in the file", ErrInvalidRequest, column, field)
+			}
+			resolved[field] = column
+			continue
+		}
+
+		for _, column := range columns {
+			if strings.EqualFold(strings.TrimSpace(column), field) {
+				resolved[field] = column
+				break
+			}
+		}
+		if columns == nil {
+			resolved[field] = field
+		}
+	}
+
+	return resolved, nil
+}
+
+func isImportField(field string) bool {
+	return containsString(importFields, field)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// readCSV reads a CSV file, handing its header line to header and each
+// non-blank record after it to fn by column name, along with the line the
+// record starts on.
+func readCSV(r io.Reader, header func(columns []string) error, fn func(line int, record map[string]string) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	columns, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: the file is empty", ErrInvalidRequest)
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	columns[0] = strings.TrimPrefix(columns[0], "\ufeff")
+	if err := header(columns); err != nil {
+		return err
+	}
+
+	for {
+		values, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+
+		blank := true
+		record := make(map[string]string, len(columns))
+		for i, v := range values {
+			if i < len(columns) {
+				record[columns[i]] = v
+			}
+			blank = blank && strings.TrimSpace(v) == ""
+		}
+		if blank {
+			continue
+		}
+
+		line, _ := cr.FieldPos(0)
+		if err := fn(line, record); err != nil {
+			return err
+		}
+	}
+}
+
+// readCSVImport reads a generic CSV file, one task per line.
+func readCSVImport(r io.Reader, m *model.ImportMapping) ([]importRecord, int, error) {
+	var records []importRecord
+	var columns map[string]string
+	err := readCSV(r, func(header []string) (err error) {
+		columns, err = importColumns(header, m.Fields)
+		return err
+	}, func(line int, record map[string]string) error {
+		fields := make(map[string]string, len(columns))
+		for field, column := range columns {
+			fields[field] = record[column]
+		}
+		records = append(records, importRecord{row: line, fields: fields})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, 0, nil
+}
+
+// readJSONImport reads a JSON array of objects, one task each. Labels may be
+// an array of strings as well as a string to split.
+func readJSONImport(r io.Reader, m *model.ImportMapping) ([]importRecord, int, error) {
+	columns, err := importColumns(nil, m.Fields)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var items []map[string]any
+	if err := dec.Decode(&items); err != nil {
+		return nil, 0, fmt.Errorf("%w: the file must hold a JSON array of objects", ErrInvalidRequest)
+	}
+
+	records := make([]importRecord, 0, len(items))
+	for i, item := range items {
+		rec := importRecord{row: i + 1, fields: map[string]string{}}
+
+		for field, key := range columns {
+			value, ok := item[key]
+			if !ok || value == nil {
+				continue
+			}
+
+			switch v := value.(type) {
+			case string:
+				rec.fields[field] = v
+			case json.Number:
+				rec.fields[field] = v.String()
+			case bool:
+				rec.fields[field] = strconv.FormatBool(v)
+			case []any:
+				if field != "labels" {
+					rec.errors = append(rec.errors, model.ImportRowError{Row: rec.row, Field: field, Error: "must not be a list"})
+					continue
+				}
+				for _, label := range v {
+					s, ok := label.(string)
+					if !ok {
+						rec.errors = append(rec.errors, model.ImportRowError{Row: rec.row, Field: field, Error: "must be a list of strings"})
+						break
+					}
+					rec.labels = append(rec.labels, s)
+				}
+			default:
+				rec.errors = append(rec.errors, model.ImportRowError{Row: rec.row, Field: field, Error: "must be a string or a number"})
+			}
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, 0, nil
+}
+
+// todoistLabel matches the @labels Todoist keeps inline in a task's content.
+var todoistLabel = regexp.MustCompile(`(^|\s)@([^\s@]+)`)
+
+// readTodoistImport reads Todoist's CSV template export. Sections and notes
+// are left out, INDENT makes subtasks, inline @labels become labels and
+// durations become estimates. Priorities have nowhere to go and are dropped.
+func readTodoistImport(r io.Reader, m *model.ImportMapping) ([]importRecord, int, error) {
+	var records []importRecord
+	ignored := 0
+
+	// parents[i] is the row of the last task seen at indent i+1.
+	var parents []int
+	err := readCSV(r, func(header []string) error {
+		if !containsString(header, "TYPE") || !containsString(header, "CONTENT") {
+			return fmt.Errorf("%w: the file is not a Todoist CSV export", ErrInvalidRequest)
+		}
+		return nil
+	}, func(line int, record map[string]string) error {
+		if !strings.EqualFold(record["TYPE"], "task") {
+			ignored++
+			return nil
+		}
+
+		content := record["CONTENT"]
+		var labels []string
+		for _, match := range todoistLabel.FindAllStringSubmatch(content, -1) {
+			labels = append(labels, match[2])
+		}
+		content = strings.Join(strings.Fields(todoistLabel.ReplaceAllString(content, "$1")), " ")
+
+		rec := importRecord{
+			row: line,
+			fields: map[string]string{
+				"name":        content,
+				"description": record["DESCRIPTION"],
+				"due_at":      record["DATE"],
+			},
+			labels: labels,
+			tz:     record["TIMEZONE"],
+		}
+
+		indent, err := strconv.Atoi(record["INDENT"])
+		if err != nil || indent < 1 {
+			indent = 1
+		}
+		if indent > len(parents)+1 {
+			indent = len(parents) + 1
+		}
+		if indent > 1 {
+			rec.parentRow = parents[indent-2]
+		}
+		parents = append(parents[:indent-1], line)
+
+		if d := record["DURATION"]; d != "" {
+			n, err := strconv.ParseInt(d, 10, 64)
+			unit := int64(60)
+			if strings.EqualFold(record["DURATION_UNIT"], "day") {
+				unit = 24 * 60 * 60
+			}
+			if err != nil {
+				rec.errors = append(rec.errors, model.ImportRowError{Row: line, Field: "estimate_seconds", Error: fmt.Sprintf("duration %q is not a number", d)})
+			} else {
+				rec.fields["estimate_seconds"] = strconv.FormatInt(n*unit, 10)
+			}
+		}
+
+		records = append(records, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, ignored, nil
+}
+
+type trelloBoard struct {
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID          string  `json:"id"`
+		Name        string  `json:"name"`
+		Desc        string  `json:"desc"`
+		IDList      string  `json:"idList"`
+		Due         *string `json:"due"`
+		DueComplete bool    `json:"dueComplete"`
+		Closed      bool    `json:"closed"`
+		Labels      []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Checklists []struct {
+		IDCard     string `json:"idCard"`
+		CheckItems []struct {
+			Name  string  `json:"name"`
+			State string  `json:"state"`
+			Due   *string `json:"due"`
+		} `json:"checkItems"`
+	} `json:"checklists"`
+}
+
+// readTrelloImport reads a Trello board's JSON export. Each open card becomes
+// a task whose status is the list it is in, or DONE once its due date is
+// marked complete, and the items of its checklists become its subtasks.
+// Archived cards and cards on archived lists are left out. Rows number the
+// tasks in the order they are read.
+func readTrelloImport(r io.Reader, m *model.ImportMapping) ([]importRecord, int, error) {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil || board.Cards == nil {
+		return nil, 0, fmt.Errorf("%w: the file is not a Trello board export", ErrInvalidRequest)
+	}
+
+	lists := make(map[string]string, len(board.Lists))
+	closedLists := map[string]bool{}
+	for _, l := range board.Lists {
+		lists[l.ID] = l.Name
+		closedLists[l.ID] = l.Closed
+	}
+
+	checklists := map[string][]int{}
+	for i, cl := range board.Checklists {
+		checklists[cl.IDCard] = append(checklists[cl.IDCard], i)
+	}
+
+	var records []importRecord
+	ignored := 0
+	for _, card := range board.Cards {
+		if card.Closed || closedLists[card.IDList] {
+			ignored++
+			continue
+		}
+
+		status := lists[card.IDList]
+		if card.DueComplete {
+			status = statusDone
+		}
+
+		rec := importRecord{
+			row: len(records) + 1,
+			fields: map[string]string{
+				"name":        card.Name,
+				"description": card.Desc,
+				"status":      status,
+			},
+		}
+		if card.Due != nil {
+			rec.fields["due_at"] = *card.Due
+		}
+		for _, l := range card.Labels {
+			if l.Name != "" {
+				rec.labels = append(rec.labels, l.Name)
+			} else if l.Color != "" {
+				rec.labels = append(rec.labels, l.Color)
+			}
+		}
+		records = append(records, rec)
+
+		for _, i := range checklists[card.ID] {
+			for _, item := range board.Checklists[i].CheckItems {
+				sub := importRecord{
+					row:       len(records) + 1,
+					parentRow: rec.row,
+					fields:    map[string]string{"name": item.Name},
+				}
+				if item.State == "complete" {
+					sub.fields["status"] = statusDone
+				}
+				if item.Due != nil {
+					sub.fields["due_at"] = *item.Due
+				}
+				records = append(records, sub)
+			}
+		}
+	}
+
+	return records, ignored, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const maxLabelLength = 64
+
+// cleanLabels trims the labels and drops duplicates, rejecting empty or
+// overly long ones.
+func cleanLabels(labels []string) ([]string, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("%w: labels are required", ErrInvalidRequest)
+	}
+
+	seen := make(map[string]bool, len(labels))
+	cleaned := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || len(label) > maxLabelLength {
+			return nil, fmt.Errorf("%w: labels must be between 1 and %d characters", ErrInvalidRequest, maxLabelLength)
+		}
+		if !seen[label] {
+			seen[label] = true
+			cleaned = append(cleaned, label)
+		}
+	}
+
+	return cleaned, nil
+}
+
+func (s *TaskService) GetLabels(c context.Context, userID, taskID string) ([]string, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessRead)
+	if err != nil {
+		log.Printf("TaskService.GetLabels - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.taskRepo.GetLabels(c, tid)
+}
+
+func (s *TaskService) AddLabels(c context.Context, userID, taskID string, labels []string) ([]string, error) {
+	return s.changeLabels(c, userID, taskID, labels, s.taskRepo.AddLabels)
+}
+
+func (s *TaskService) RemoveLabels(c context.Context, userID, taskID string, labels []string) ([]string, error) {
+	return s.changeLabels(c, userID, taskID, labels, s.taskRepo.RemoveLabels)
+}
+
+// changeLabels applies change to the task's labels and returns the labels it
+// ends up with.
+func (s *TaskService) changeLabels(c context.Context, userID, taskID string, labels []string, change func(context.Context, uuid.UUID, []string) error) ([]string, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.changeLabels - Starting attempt to change labels on task: %s", taskID)
+
+	labels, err := cleanLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("TaskService.changeLabels - Access check failed: %v", err)
+		return nil, err
+	}
+
+	if err := change(c, tid, labels); err != nil {
+		log.Printf("TaskService.changeLabels - Database error: %v", err)
+		return nil, err
+	}
+
+	return s.taskRepo.GetLabels(c, tid)
+}
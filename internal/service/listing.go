@@ -0,0 +1,120 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	defaultTaskPageSize = 100
+	maxTaskPageSize     = 500
+)
+
+// parseTaskQuery turns listing query parameters into a repository query. It
+// asks for one task more than the page holds so nextCursor can tell whether
+// another page follows.
+func parseTaskQuery(req model.RequestListTasks) (repository.TaskQuery, error) {
+	var (
+		q   repository.TaskQuery
+		err error
+	)
+
+	if q.Archived, err = parseArchiveFilter(req.Archived); err != nil {
+		return q, err
+	}
+
+	for _, status := range strings.Split(req.Status, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			q.Statuses = append(q.Statuses, status)
+		}
+	}
+	q.NameContains = strings.TrimSpace(req.Name)
+
+	for _, p := range []struct {
+		name  string
+		value string
+		dst   **time.Time
+	}{
+		{"created_after", req.CreatedAfter, &q.CreatedAfter},
+		{"created_before", req.CreatedBefore, &q.CreatedBefore},
+		{"updated_after", req.UpdatedAfter, &q.UpdatedAfter},
+		{"updated_before", req.UpdatedBefore, &q.UpdatedBefore},
+	} {
+		if p.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, p.value)
+		if err != nil {
+			return q, fmt.Errorf("%w: %s must be an RFC 3339 time", ErrInvalidRequest, p.name)
+		}
+		*p.dst = &t
+	}
+
+	if req.Cursor != "" {
+		if q.After, err = decodeCursor(req.Cursor); err != nil {
+			return q, err
+		}
+	}
+
+	switch {
+	case req.Limit < 0:
+		return q, fmt.Errorf("%w: limit must be positive", ErrInvalidRequest)
+	case req.Limit == 0:
+		q.Limit = defaultTaskPageSize
+	case req.Limit > maxTaskPageSize:
+		q.Limit = maxTaskPageSize
+	default:
+		q.Limit = req.Limit
+	}
+	q.Limit++
+
+	return q, nil
+}
+
+// nextCursor trims the extra task parseTaskQuery asked for and returns the
+// cursor of the following page, or "" on the last page.
+func nextCursor(tasks []repository.Task, q repository.TaskQuery) ([]repository.Task, string) {
+	size := q.Limit - 1
+	if len(tasks) <= size {
+		return tasks, ""
+	}
+
+	tasks = tasks[:size]
+	last := tasks[size-1]
+	return tasks, encodeCursor(repository.TaskCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+}
+
+func encodeCursor(cur repository.TaskCursor) string {
+	raw := cur.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + cur.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*repository.TaskCursor, error) {
+	invalid := fmt.Errorf("%w: cursor is not valid", ErrInvalidRequest)
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+
+	at, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, invalid
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, invalid
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, invalid
+	}
+
+	return &repository.TaskCursor{CreatedAt: createdAt, ID: id}, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/events"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	EventTaskCreated       = "task.created"
+	EventTaskUpdated       = "task.updated"
+	EventTaskStatusChanged = "task.status_changed"
+	EventTaskDeleted       = "task.deleted"
+	EventUserCreated       = "user.created"
+	EventUserUpdated       = "user.updated"
+	EventUserDeleted       = "user.deleted"
+)
+
+const (
+	outboxBatchSize       = 100
+	outboxDispatchTimeout = 30 * time.Second
+	outboxRetention       = 7 * 24 * time.Hour
+)
+
+// OutboxService records domain events in the same transaction as the change
+// they describe, and publishes them to its sinks from a dispatcher. Since an
+// event is only marked published once every sink took it, sinks see each
+// event at least once; see events.Event for the ordering guarantees.
+type OutboxService struct {
+	outboxRepo *repository.OutboxRepository
+	sinks      []events.Sink
+	timeout    time.Duration
+}
+
+func NewOutboxService(outboxRepo *repository.OutboxRepository, sinks ...events.Sink) *OutboxService {
+	s := &OutboxService{
+		outboxRepo: outboxRepo,
+		timeout:    time.Duration(2) * time.Second,
+	}
+	for _, sink := range sinks {
+		if sink != nil {
+			s.sinks = append(s.sinks, sink)
+		}
+	}
+	return s
+}
+
+// InTx runs fn in one transaction; events recorded with its context commit
+// or roll back together with everything else fn does.
+func (s *OutboxService) InTx(c context.Context, fn func(c context.Context) error) error {
+	return s.outboxRepo.InTx(c, fn)
+}
+
+// Record writes an event to the outbox. Call it inside InTx, next to the
+// change it describes.
+func (s *OutboxService) Record(c context.Context, eventType, aggregateID string, workspaceIDs []string, userID string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+
+	return s.outboxRepo.Append(c, events.Event{
+		ID:           uuid.NewString(),
+		Type:         eventType,
+		AggregateID:  aggregateID,
+		WorkspaceIDs: workspaceIDs,
+		UserID:       userID,
+		Data:         body,
+	})
+}
+
+// RecordTask records a task event about the task as it now is. data is
+// merged into the event next to the task.
+func (s *OutboxService) RecordTask(c context.Context, eventType string, task *repository.Task, data map[string]any) error {
+	if data == nil {
+		data = map[string]any{}
+	}
+	data["task"] = newTaskResponse(task)
+
+	return s.Record(c, eventType, task.ID, []string{task.WorkspaceID}, task.UserID, data)
+}
+
+// RecordUser records a user event concerning the given workspaces.
+func (s *OutboxService) RecordUser(c context.Context, eventType string, user *repository.User, workspaceIDs []string) error {
+	id := user.ID.String()
+	return s.Record(c, eventType, id, workspaceIDs, id, map[string]any{
+		"user": map[string]string{"id": id, "username": user.Username},
+	})
+}
+
+// RunDispatcher publishes due events every interval until c is done.
+func (s *OutboxService) RunDispatcher(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back.
+		for c.Err() == nil {
+			n, err := s.dispatch(repository.WithSystem(c))
+			if err != nil {
+				log.Printf("OutboxService.RunDispatcher - Database error: %v", err)
+			}
+			if err != nil || n < outboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch publishes one batch of due events in a single transaction, which
+// also holds the lock that keeps other instances from dispatching at the same
+// time. Each event is published inside a savepoint, so a sink that writes to
+// the database commits exactly when the event is marked published. It returns
+// how many events were due.
+func (s *OutboxService) dispatch(c context.Context) (int, error) {
+	c, cancel := context.WithTimeout(c, outboxDispatchTimeout)
+	defer cancel()
+
+	var n int
+	err := s.outboxRepo.InTx(c, func(c context.Context) error {
+		locked, err := s.outboxRepo.LockDispatch(c)
+		if err != nil || !locked {
+			return err
+		}
+
+		due, err := s.outboxRepo.GetDue(c, outboxBatchSize)
+		if err != nil {
+			return err
+		}
+		n = len(due)
+
+		// Once an event fails, the rest of its aggregate's events wait for it.
+		blocked := map[string]bool{}
+		published := make([]int64, 0, len(due))
+
+		for _, ev := range due {
+			if blocked[ev.AggregateID] {
+				continue
+			}
+
+			err := repository.Savepoint(c, func(c context.Context) error {
+				return s.publish(c, ev)
+			})
+			if err != nil {
+				log.Printf("OutboxService.dispatch - Failed to publish %s %s: %v", ev.Type, ev.ID, err)
+				blocked[ev.AggregateID] = true
+				if err := s.outboxRepo.MarkFailed(c, ev.Seq, err); err != nil {
+					return err
+				}
+				continue
+			}
+
+			published = append(published, ev.Seq)
+		}
+
+		return s.outboxRepo.MarkPublished(c, published)
+	})
+
+	return n, err
+}
+
+func (s *OutboxService) publish(c context.Context, ev events.Event) error {
+	for _, sink := range s.sinks {
+		if err := sink.Publish(c, ev); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// RunPurger deletes published events once they are a week old.
+func (s *OutboxService) RunPurger(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		dc, cancel := context.WithTimeout(repository.WithSystem(c), s.timeout)
+		if _, err := s.outboxRepo.Purge(dc, time.Now().Add(-outboxRetention)); err != nil {
+			log.Printf("OutboxService.RunPurger - Database error: %v", err)
+		}
+		cancel()
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
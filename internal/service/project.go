@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+type ProjectService struct {
+	projectRepo      *repository.ProjectRepository
+	workspaceService *WorkspaceService
+	timeout          time.Duration
+}
+
+func NewProjectService(projectRepo *repository.ProjectRepository, workspaceService *WorkspaceService) *ProjectService {
+	return &ProjectService{
+		projectRepo:      projectRepo,
+		workspaceService: workspaceService,
+		timeout:          time.Duration(2) * time.Second,
+	}
+}
+
+func (s *ProjectService) CreateProject(c context.Context, userID, workspaceID string, req model.RequestCreateProject) (*repository.Project, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ProjectService.CreateProject - Starting project creation for: %s", req.Name)
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+
+	wid, _, err := s.workspaceService.authorize(c, userID, workspaceID, roleMember)
+	if err != nil {
+		log.Printf("ProjectService.CreateProject - Access check failed: %v", err)
+		return nil, err
+	}
+
+	p, err := s.projectRepo.CreateProject(c, &repository.Project{
+		WorkspaceID: wid.String(),
+		Name:        name,
+		Description: req.Description,
+		CreatedBy:   &userID,
+	})
+	if err != nil {
+		log.Printf("ProjectService.CreateProject - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ProjectService.CreateProject - Project creation was successful: %s", p.ID)
+	return p, nil
+}
+
+func (s *ProjectService) GetProjects(c context.Context, userID, workspaceID string) ([]repository.Project, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, _, err := s.workspaceService.authorize(c, userID, workspaceID, roleGuest)
+	if err != nil {
+		log.Printf("ProjectService.GetProjects - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.projectRepo.GetProjects(c, wid)
+}
+
+func (s *ProjectService) GetProject(c context.Context, userID, workspaceID, projectID string) (*repository.Project, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, _, err := s.workspaceService.authorize(c, userID, workspaceID, roleGuest)
+	if err != nil {
+		log.Printf("ProjectService.GetProject - Access check failed: %v", err)
+		return nil, err
+	}
+
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		return nil, repository.ErrProjectNotFound
+	}
+
+	return s.projectRepo.GetProjectByID(c, wid, pid)
+}
+
+func (s *ProjectService) UpdateProject(c context.Context, userID, workspaceID, projectID string, req model.RequestUpdateProject) (*repository.Project, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if req.Name == nil && req.Description == nil {
+		return nil, fmt.Errorf("%w: nothing to update", ErrInvalidRequest)
+	}
+
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidRequest)
+	}
+
+	wid, _, err := s.workspaceService.authorize(c, userID, workspaceID, roleMember)
+	if err != nil {
+		log.Printf("ProjectService.UpdateProject - Access check failed: %v", err)
+		return nil, err
+	}
+
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		return nil, repository.ErrProjectNotFound
+	}
+
+	p, err := s.projectRepo.UpdateProject(c, wid, pid, req.Name, req.Description)
+	if err != nil {
+		log.Printf("ProjectService.UpdateProject - Database error: %v", err)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// DeleteProject removes the project. Its tasks stay in the workspace,
+// detached from any project.
+func (s *ProjectService) DeleteProject(c context.Context, userID, workspaceID, projectID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, _, err := s.workspaceService.authorize(c, userID, workspaceID, roleAdmin)
+	if err != nil {
+		log.Printf("ProjectService.DeleteProject - Access check failed: %v", err)
+		return err
+	}
+
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		return repository.ErrProjectNotFound
+	}
+
+	if err := s.projectRepo.DeleteProject(c, wid, pid); err != nil {
+		log.Printf("ProjectService.DeleteProject - Database error: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var searchWord = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Search finds the caller's tasks matching query, best matches first.
+// Archived tasks are searched too unless archived says otherwise.
+func (s *TaskService) Search(c context.Context, userID, query, archived string, limit int) ([]model.ResponseTaskSearchHit, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.Search - Starting search for user: %s", userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TaskService.Search - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	tsquery, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	af := repository.IncludeArchived
+	if archived != "" && archived != "include" {
+		if af, err = parseArchiveFilter(archived); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case limit < 0:
+		return nil, fmt.Errorf("%w: limit must be positive", ErrInvalidRequest)
+	case limit == 0:
+		limit = defaultSearchLimit
+	case limit > maxSearchLimit:
+		limit = maxSearchLimit
+	}
+
+	hits, err := s.taskRepo.SearchTasks(c, uid, tsquery, af, limit)
+	if err != nil {
+		log.Printf("TaskService.Search - Database error: %v", err)
+		return nil, err
+	}
+
+	res := make([]model.ResponseTaskSearchHit, 0, len(hits))
+	for i := range hits {
+		res = append(res, model.ResponseTaskSearchHit{
+			Task: *newTaskResponse(&hits[i].Task),
+			Rank: hits[i].Rank,
+			Highlights: model.TaskHighlights{
+				Name:        markHighlights(hits[i].NameHighlight),
+				Description: markHighlights(hits[i].Snippet),
+			},
+		})
+	}
+
+	log.Printf("TaskService.Search - Found %d tasks for user: %s", len(res), userID)
+	return res, nil
+}
+
+// parseSearchQuery turns a search box query into a to_tsquery expression.
+// Words must all match; "quoted words" match as a phrase, a trailing * makes
+// a prefix match and a leading - excludes tasks that match.
+func parseSearchQuery(query string) (string, error) {
+	var terms []string
+	positive := false
+
+	for rest := strings.TrimSpace(query); rest != ""; rest = strings.TrimSpace(rest) {
+		negate := strings.HasPrefix(rest, "-")
+		if negate {
+			rest = rest[1:]
+		}
+
+		var (
+			text   string
+			prefix bool
+		)
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				text, rest = rest[1:], ""
+			} else {
+				text, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+			prefix = strings.HasSuffix(text, "*")
+		}
+
+		words := searchWord.FindAllString(text, -1)
+		if len(words) == 0 {
+			continue
+		}
+		if prefix {
+			words[len(words)-1] += ":*"
+		}
+
+		term := strings.Join(words, " <-> ")
+		if negate {
+			term = "!(" + term + ")"
+		} else {
+			positive = true
+		}
+		terms = append(terms, term)
+	}
+
+	if !positive {
+		return "", fmt.Errorf("%w: q must contain at least one word to search for", ErrInvalidRequest)
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+// markHighlights escapes a headline for HTML and turns its match markers
+// into <mark> tags.
+func markHighlights(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, repository.HighlightStart, "<mark>")
+	return strings.ReplaceAll(s, repository.HighlightStop, "</mark>")
+}
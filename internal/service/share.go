@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+	"github.com/0xrishabk/tasktracker/internal/util"
+)
+
+const (
+	permissionViewer = "viewer"
+	permissionEditor = "editor"
+
+	shareTask    = "task"
+	shareProject = "project"
+)
+
+type ShareService struct {
+	shareRepo        *repository.ShareRepository
+	taskRepo         *repository.TaskRepository
+	projectRepo      *repository.ProjectRepository
+	userRepo         *repository.UserRepository
+	workspaceService *WorkspaceService
+	appURL           string
+	timeout          time.Duration
+}
+
+func NewShareService(shareRepo *repository.ShareRepository, taskRepo *repository.TaskRepository, projectRepo *repository.ProjectRepository, userRepo *repository.UserRepository, workspaceService *WorkspaceService, appURL string) *ShareService {
+	return &ShareService{
+		shareRepo:        shareRepo,
+		taskRepo:         taskRepo,
+		projectRepo:      projectRepo,
+		userRepo:         userRepo,
+		workspaceService: workspaceService,
+		appURL:           strings.TrimRight(appURL, "/"),
+		timeout:          time.Duration(2) * time.Second,
+	}
+}
+
+// ShareTask gives a user viewer or editor permission on a single task. Only
+// the task owner can share it.
+func (s *ShareService) ShareTask(c context.Context, actorID, taskID string, req model.RequestShare) ([]repository.Share, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ShareService.ShareTask - Starting attempt to share task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessOwner)
+	if err != nil {
+		log.Printf("ShareService.ShareTask - Access check failed: %v", err)
+		return nil, err
+	}
+
+	if err := s.share(c, shareTask, tid, actorID, req); err != nil {
+		log.Printf("ShareService.ShareTask - Share failed: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ShareService.ShareTask - Successfully shared task: %s", taskID)
+	return s.shareRepo.GetShares(c, shareTask, tid)
+}
+
+func (s *ShareService) UnshareTask(c context.Context, actorID, taskID, userID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Anyone can drop a task that was shared with them.
+	need := accessOwner
+	if actorID == userID {
+		need = accessRead
+	}
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, need)
+	if err != nil {
+		log.Printf("ShareService.UnshareTask - Access check failed: %v", err)
+		return err
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return repository.ErrShareNotFound
+	}
+
+	return s.shareRepo.RemoveShare(c, shareTask, tid, uid)
+}
+
+func (s *ShareService) GetTaskShares(c context.Context, actorID, taskID string) ([]repository.Share, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessOwner)
+	if err != nil {
+		log.Printf("ShareService.GetTaskShares - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.shareRepo.GetShares(c, shareTask, tid)
+}
+
+func (s *ShareService) ShareProject(c context.Context, actorID, workspaceID, projectID string, req model.RequestShare) ([]repository.Share, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ShareService.ShareProject - Starting attempt to share project: %s", projectID)
+
+	pid, err := s.authorizeProject(c, actorID, workspaceID, projectID)
+	if err != nil {
+		log.Printf("ShareService.ShareProject - Access check failed: %v", err)
+		return nil, err
+	}
+
+	if err := s.share(c, shareProject, pid, actorID, req); err != nil {
+		log.Printf("ShareService.ShareProject - Share failed: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ShareService.ShareProject - Successfully shared project: %s", projectID)
+	return s.shareRepo.GetShares(c, shareProject, pid)
+}
+
+func (s *ShareService) UnshareProject(c context.Context, actorID, workspaceID, projectID, userID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	pid, err := s.authorizeProject(c, actorID, workspaceID, projectID)
+	if err != nil {
+		log.Printf("ShareService.UnshareProject - Access check failed: %v", err)
+		return err
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return repository.ErrShareNotFound
+	}
+
+	return s.shareRepo.RemoveShare(c, shareProject, pid, uid)
+}
+
+func (s *ShareService) GetProjectShares(c context.Context, actorID, workspaceID, projectID string) ([]repository.Share, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	pid, err := s.authorizeProject(c, actorID, workspaceID, projectID)
+	if err != nil {
+		log.Printf("ShareService.GetProjectShares - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.shareRepo.GetShares(c, shareProject, pid)
+}
+
+func (s *ShareService) share(c context.Context, kind string, id uuid.UUID, actorID string, req model.RequestShare) error {
+	if req.Permission == "" {
+		req.Permission = permissionViewer
+	}
+	if req.Permission != permissionViewer && req.Permission != permissionEditor {
+		return fmt.Errorf("%w: permission must be viewer or editor", ErrInvalidRequest)
+	}
+
+	user, err := lookupUser(c, s.userRepo, req.UserID, req.Email)
+	if err != nil {
+		return err
+	}
+
+	if user.ID.String() == actorID {
+		return fmt.Errorf("%w: you cannot share with yourself", ErrInvalidRequest)
+	}
+
+	return s.shareRepo.UpsertShare(c, kind, id, user.ID, req.Permission, uuid.MustParse(actorID))
+}
+
+// authorizeProject lets workspace admins, and whoever created the project,
+// manage how it is shared.
+func (s *ShareService) authorizeProject(c context.Context, actorID, workspaceID, projectID string) (uuid.UUID, error) {
+	wid, role, err := s.workspaceService.authorize(c, actorID, workspaceID, roleMember)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	pid, err := uuid.Parse(projectID)
+	if err != nil {
+		return uuid.Nil, repository.ErrProjectNotFound
+	}
+
+	p, err := s.projectRepo.GetProjectByID(c, wid, pid)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if roleRank[role] < roleRank[roleAdmin] && (p.CreatedBy == nil || *p.CreatedBy != actorID) {
+		return uuid.Nil, ErrWorkspaceForbidden
+	}
+
+	return pid, nil
+}
+
+func (s *ShareService) CreateTaskLink(c context.Context, actorID, taskID string, req model.RequestCreateShareLink) (*model.ResponseShareLink, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ShareService.CreateTaskLink - Starting attempt to create link for task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessOwner)
+	if err != nil {
+		log.Printf("ShareService.CreateTaskLink - Access check failed: %v", err)
+		return nil, err
+	}
+
+	id := tid.String()
+	return s.createLink(c, &repository.ShareLink{TaskID: &id, CreatedBy: &actorID}, req)
+}
+
+func (s *ShareService) CreateProjectLink(c context.Context, actorID, workspaceID, projectID string, req model.RequestCreateShareLink) (*model.ResponseShareLink, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ShareService.CreateProjectLink - Starting attempt to create link for project: %s", projectID)
+
+	pid, err := s.authorizeProject(c, actorID, workspaceID, projectID)
+	if err != nil {
+		log.Printf("ShareService.CreateProjectLink - Access check failed: %v", err)
+		return nil, err
+	}
+
+	id := pid.String()
+	return s.createLink(c, &repository.ShareLink{ProjectID: &id, CreatedBy: &actorID}, req)
+}
+
+func (s *ShareService) createLink(c context.Context, l *repository.ShareLink, req model.RequestCreateShareLink) (*model.ResponseShareLink, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidRequest)
+	}
+	l.ExpiresAt = req.ExpiresAt
+
+	if req.Password != "" {
+		hash, err := util.HashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		l.PasswordHash = &hash
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.shareRepo.CreateLink(c, l, tokenHash)
+	if err != nil {
+		log.Printf("ShareService.createLink - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ShareService.createLink - Share link creation was successful: %s", link.ID)
+
+	return &model.ResponseShareLink{
+		ID:          link.ID,
+		Token:       token,
+		URL:         fmt.Sprintf("%s/share/%s", s.appURL, token),
+		HasPassword: link.HasPassword,
+		ExpiresAt:   link.ExpiresAt,
+		CreatedAt:   link.CreatedAt,
+	}, nil
+}
+
+func (s *ShareService) GetTaskLinks(c context.Context, actorID, taskID string) ([]repository.ShareLink, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessOwner)
+	if err != nil {
+		log.Printf("ShareService.GetTaskLinks - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.shareRepo.GetLinks(c, shareTask, tid)
+}
+
+func (s *ShareService) GetProjectLinks(c context.Context, actorID, workspaceID, projectID string) ([]repository.ShareLink, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	pid, err := s.authorizeProject(c, actorID, workspaceID, projectID)
+	if err != nil {
+		log.Printf("ShareService.GetProjectLinks - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.shareRepo.GetLinks(c, shareProject, pid)
+}
+
+func (s *ShareService) RevokeTaskLink(c context.Context, actorID, taskID, linkID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	tid, err := authorizeTask(c, s.taskRepo, actorID, taskID, accessOwner)
+	if err != nil {
+		log.Printf("ShareService.RevokeTaskLink - Access check failed: %v", err)
+		return err
+	}
+
+	lid, err := uuid.Parse(linkID)
+	if err != nil {
+		return repository.ErrShareLinkNotFound
+	}
+
+	return s.shareRepo.RevokeLink(c, shareTask, tid, lid)
+}
+
+func (s *ShareService) RevokeProjectLink(c context.Context, actorID, workspaceID, projectID, linkID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	pid, err := s.authorizeProject(c, actorID, workspaceID, projectID)
+	if err != nil {
+		log.Printf("ShareService.RevokeProjectLink - Access check failed: %v", err)
+		return err
+	}
+
+	lid, err := uuid.Parse(linkID)
+	if err != nil {
+		return repository.ErrShareLinkNotFound
+	}
+
+	return s.shareRepo.RevokeLink(c, shareProject, pid, lid)
+}
+
+// OpenLink resolves a public share link into a read-only view of what it
+// points at. Nobody is signed in, so the lookup runs as the system and the
+// link itself is the only authorization.
+func (s *ShareService) OpenLink(c context.Context, token, password string) (*model.ResponsePublicShare, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	c = repository.WithSystem(c)
+
+	link, err := s.shareRepo.GetActiveLink(c, hashToken(token))
+	if err != nil {
+		log.Printf("ShareService.OpenLink - Link lookup failed: %v", err)
+		return nil, err
+	}
+
+	if link.PasswordHash != nil {
+		if password == "" || util.CheckPassword(password, *link.PasswordHash) != nil {
+			return nil, ErrPasswordRequired
+		}
+	}
+
+	if link.TaskID != nil {
+		task, err := s.taskRepo.GetTaskByID(c, uuid.MustParse(*link.TaskID))
+		if err != nil {
+			log.Printf("ShareService.OpenLink - Database error: %v", err)
+			return nil, err
+		}
+		return &model.ResponsePublicShare{Task: newPublicTask(task)}, nil
+	}
+
+	pid := uuid.MustParse(*link.ProjectID)
+
+	project, err := s.projectRepo.GetProjectByIDUnscoped(c, pid)
+	if err != nil {
+		log.Printf("ShareService.OpenLink - Database error: %v", err)
+		return nil, err
+	}
+
+	tasks, err := s.taskRepo.GetTasks(c, &pid, repository.TaskQuery{})
+	if err != nil {
+		log.Printf("ShareService.OpenLink - Database error: %v", err)
+		return nil, err
+	}
+
+	view := &model.ResponsePublicProject{
+		Name:        project.Name,
+		Description: project.Description,
+		Tasks:       []model.ResponsePublicTask{},
+	}
+	for i := range tasks {
+		view.Tasks = append(view.Tasks, *newPublicTask(&tasks[i]))
+	}
+
+	return &model.ResponsePublicShare{Project: view}, nil
+}
+
+func newPublicTask(task *repository.Task) *model.ResponsePublicTask {
+	return &model.ResponsePublicTask{
+		ID:               task.ID,
+		Name:             task.Name,
+		Description:      task.Description,
+		Status:           task.Status,
+		StoryPoints:      task.StoryPoints,
+		EstimateSeconds:  task.EstimateSeconds,
+		RemainingSeconds: task.RemainingSeconds,
+		CreatedAt:        task.CreatedAt,
+		UpdatedAt:        task.UpdatedAt,
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/events"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	streamBuffer    = 64
+	streamBatchSize = 500
+	streamRetention = 24 * time.Hour
+	streamRetry     = 5 * time.Second
+)
+
+// streamEvents are the outbox events the live stream carries.
+var streamEvents = map[string]bool{
+	EventTaskCreated: true,
+	EventTaskUpdated: true,
+	EventTaskDeleted: true,
+}
+
+// StreamService feeds the live task stream. As an outbox sink it logs task
+// events to a short-lived table and announces them with NOTIFY; every server
+// instance listens for those announcements, reads the new events once and
+// hands each to the subscribers who may see it.
+type StreamService struct {
+	streamRepo *repository.StreamRepository
+	timeout    time.Duration
+
+	mu   sync.Mutex
+	subs map[*StreamSubscription]struct{}
+	last int64
+}
+
+// StreamSubscription receives live events for one client. Dropped is closed
+// if the client falls too far behind to keep up; it should reconnect and
+// resume from the last event it got.
+type StreamSubscription struct {
+	C       chan repository.TaskStreamEvent
+	Dropped chan struct{}
+
+	userID      string
+	workspaceID *string
+}
+
+func NewStreamService(streamRepo *repository.StreamRepository) *StreamService {
+	return &StreamService{
+		streamRepo: streamRepo,
+		timeout:    time.Duration(2) * time.Second,
+		subs:       map[*StreamSubscription]struct{}{},
+	}
+}
+
+func (s *StreamService) Name() string {
+	return "stream"
+}
+
+func (s *StreamService) Publish(c context.Context, ev events.Event) error {
+	if !streamEvents[ev.Type] {
+		return nil
+	}
+
+	tid, err := uuid.Parse(ev.AggregateID)
+	if err != nil {
+		return err
+	}
+
+	var wid, owner *uuid.UUID
+	if len(ev.WorkspaceIDs) > 0 {
+		if id, err := uuid.Parse(ev.WorkspaceIDs[0]); err == nil {
+			wid = &id
+		}
+	}
+	if id, err := uuid.Parse(ev.UserID); err == nil {
+		owner = &id
+	}
+
+	return s.streamRepo.Record(c, ev.Type, tid, wid, owner, ev.Data)
+}
+
+// Subscribe starts collecting live events for the user, narrowed to a
+// workspace when the request is scoped to one.
+func (s *StreamService) Subscribe(c context.Context, userID string) *StreamSubscription {
+	sub := &StreamSubscription{
+		C:       make(chan repository.TaskStreamEvent, streamBuffer),
+		Dropped: make(chan struct{}),
+		userID:  userID,
+	}
+	if wid, ok := repository.WorkspaceFromContext(c); ok {
+		id := wid.String()
+		sub.workspaceID = &id
+	}
+
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub
+}
+
+func (s *StreamService) Unsubscribe(sub *StreamSubscription) {
+	s.mu.Lock()
+	delete(s.subs, sub)
+	s.mu.Unlock()
+}
+
+// Replay lists the events a subscriber missed after lastID. complete is
+// false when some of them are no longer kept, in which case the client
+// should reload instead of relying on the stream.
+func (s *StreamService) Replay(c context.Context, sub *StreamSubscription, lastID int64) (list []repository.TaskStreamEvent, complete bool, err error) {
+	c, cancel := context.WithTimeout(repository.WithSystem(c), s.timeout)
+	defer cancel()
+
+	oldest, err := s.streamRepo.OldestID(c)
+	if err != nil {
+		log.Printf("StreamService.Replay - Database error: %v", err)
+		return nil, false, err
+	}
+
+	uid, err := uuid.Parse(sub.userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var wid *uuid.UUID
+	if sub.workspaceID != nil {
+		id := uuid.MustParse(*sub.workspaceID)
+		wid = &id
+	}
+
+	list, err = s.streamRepo.GetSince(c, lastID, &uid, wid, streamBatchSize)
+	if err != nil {
+		log.Printf("StreamService.Replay - Database error: %v", err)
+		return nil, false, err
+	}
+
+	// More than a batch behind counts as a gap too.
+	complete = (oldest == 0 || lastID+1 >= oldest) && len(list) < streamBatchSize
+	return list, complete, nil
+}
+
+// Run listens for new stream events and fans them out until c is done,
+// reconnecting whenever the listening connection fails.
+func (s *StreamService) Run(c context.Context) {
+	c = repository.WithSystem(c)
+
+	dc, cancel := context.WithTimeout(c, s.timeout)
+	last, err := s.streamRepo.LatestID(dc)
+	cancel()
+	if err != nil {
+		log.Printf("StreamService.Run - Database error: %v", err)
+	}
+	s.last = last
+
+	for {
+		err := s.streamRepo.Listen(c, func() { s.fanOut(c) })
+		if c.Err() != nil {
+			return
+		}
+		log.Printf("StreamService.Run - Lost the listening connection: %v", err)
+
+		select {
+		case <-c.Done():
+			return
+		case <-time.After(streamRetry):
+		}
+	}
+}
+
+// fanOut reads every event logged since the last one seen and hands each to
+// the subscribers that may see it.
+func (s *StreamService) fanOut(c context.Context) {
+	for {
+		dc, cancel := context.WithTimeout(c, s.timeout)
+		list, err := s.streamRepo.GetSince(dc, s.last, nil, nil, streamBatchSize)
+		cancel()
+		if err != nil {
+			log.Printf("StreamService.fanOut - Database error: %v", err)
+			return
+		}
+
+		s.mu.Lock()
+		for _, e := range list {
+			for sub := range s.subs {
+				if !slices.Contains(e.Audience, sub.userID) {
+					continue
+				}
+				if sub.workspaceID != nil && (e.WorkspaceID == nil || *e.WorkspaceID != *sub.workspaceID) {
+					continue
+				}
+
+				select {
+				case sub.C <- e:
+				default:
+					// Let a client that can't keep up go rather than hold
+					// everyone else up; it resumes from the log.
+					close(sub.Dropped)
+					delete(s.subs, sub)
+				}
+			}
+			s.last = e.ID
+		}
+		s.mu.Unlock()
+
+		if len(list) < streamBatchSize {
+			return
+		}
+	}
+}
+
+// RunPurger trims the stream log to the last day every interval until c is
+// done.
+func (s *StreamService) RunPurger(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		dc, cancel := context.WithTimeout(repository.WithSystem(c), s.timeout)
+		if _, err := s.streamRepo.Purge(dc, time.Now().Add(-streamRetention)); err != nil {
+			log.Printf("StreamService.RunPurger - Database error: %v", err)
+		}
+		cancel()
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	syncPageSize     = 500
+	maxSyncMutations = 100
+)
+
+const (
+	syncReport = "report"
+	syncLWW    = "lww"
+)
+
+const (
+	syncApplied  = "applied"
+	syncConflict = "conflict"
+	syncFailed   = "failed"
+)
+
+// syncFields are the task fields a client can change through a sync, named as
+// they are in task history.
+var syncFields = []string{
+	"name", "description", "status", "project_id", "parent_id", "story_points",
+	"estimate_seconds", "remaining_seconds", "due_at", "labels",
+}
+
+// syncToken is what an opaque sync token holds. Since is where the changes
+// start (empty for a full sync); while paging, Next is the token the sync
+// will end on and After the last task id handed out.
+type syncToken struct {
+	Since string
+	Next  string
+	After string
+}
+
+func encodeSyncToken(t syncToken) string {
+	raw := t.Since + "," + t.Next + "," + t.After
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSyncToken(s string) (syncToken, error) {
+	invalid := fmt.Errorf("%w: sync token is not valid", ErrInvalidRequest)
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return syncToken{}, invalid
+	}
+
+	parts := strings.Split(string(raw), ",")
+	if len(parts) != 3 {
+		return syncToken{}, invalid
+	}
+	t := syncToken{Since: parts[0], Next: parts[1], After: parts[2]}
+
+	for _, xid := range []string{t.Since, t.Next} {
+		if _, err := strconv.ParseUint(xid, 10, 64); xid != "" && err != nil {
+			return syncToken{}, invalid
+		}
+	}
+	if t.After != "" {
+		if _, err := uuid.Parse(t.After); err != nil || t.Next == "" {
+			return syncToken{}, invalid
+		}
+	}
+
+	return t, nil
+}
+
+// Sync returns the tasks changed since the given token, or every task when
+// there is none, a page at a time.
+func (s *TaskService) Sync(c context.Context, userID, token string) (*model.ResponseSync, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.Sync - Starting sync for user: %s", userID)
+
+	var tok syncToken
+	if token != "" {
+		var err error
+		if tok, err = decodeSyncToken(token); err != nil {
+			return nil, err
+		}
+	}
+
+	var since *string
+	if tok.Since != "" {
+		since = &tok.Since
+	}
+	var after *uuid.UUID
+	if tok.After != "" {
+		id := uuid.MustParse(tok.After)
+		after = &id
+	}
+
+	set, err := s.taskRepo.GetChangesSince(c, since, after, syncPageSize+1)
+	if err != nil {
+		log.Printf("TaskService.Sync - Database error: %v", err)
+		return nil, err
+	}
+
+	// Later pages take fresh snapshots, but the sync ends on the token of
+	// its first page so nothing committed in between is missed.
+	next := set.Token
+	if tok.Next != "" {
+		next = tok.Next
+	}
+
+	res := &model.ResponseSync{
+		Tasks:   []model.SyncTask{},
+		Deleted: set.Deleted,
+		Token:   encodeSyncToken(syncToken{Since: next}),
+	}
+
+	tasks := set.Tasks
+	if len(tasks) > syncPageSize {
+		tasks = tasks[:syncPageSize]
+		res.HasMore = true
+		res.Token = encodeSyncToken(syncToken{Since: tok.Since, Next: next, After: tasks[len(tasks)-1].ID})
+	}
+
+	for _, t := range tasks {
+		if t.DeletedAt != nil {
+			res.Deleted = append(res.Deleted, t.ID)
+			continue
+		}
+		res.Tasks = append(res.Tasks, model.SyncTask{ResponseCreateTask: *newTaskResponse(&t.Task), Labels: t.Labels})
+	}
+
+	log.Printf("TaskService.Sync - Successfully synced %d tasks and %d deletions for user: %s", len(res.Tasks), len(res.Deleted), userID)
+	return res, nil
+}
+
+// PushChanges applies a batch of offline changes. Each mutation commits on its
+// own, so a failed or conflicting one doesn't hold back the rest. A client
+// queues its mutations against the same base token, so changes made by earlier
+// mutations of the batch don't count as conflicts.
+func (s *TaskService) PushChanges(c context.Context, userID string, req model.RequestSyncPush) (*model.ResponseSyncPush, error) {
+	log.Printf("TaskService.PushChanges - Starting %d mutations for user: %s", len(req.Mutations), userID)
+
+	if len(req.Mutations) == 0 || len(req.Mutations) > maxSyncMutations {
+		return nil, fmt.Errorf("%w: between 1 and %d mutations are allowed", ErrInvalidRequest, maxSyncMutations)
+	}
+
+	res := &model.ResponseSyncPush{Results: make([]model.SyncMutationResult, len(req.Mutations))}
+	var applied []string
+	for i, m := range req.Mutations {
+		r := &res.Results[i]
+		r.ID = m.ID
+
+		var xid string
+		r.Err = s.taskRepo.InTx(c, func(c context.Context) (err error) {
+			if err := s.applyMutation(c, userID, m, applied, r); err != nil {
+				return err
+			}
+			xid, err = s.taskRepo.CurrentTxID(c)
+			return err
+		})
+		if r.Err == nil {
+			applied = append(applied, xid)
+		} else {
+			log.Printf("TaskService.PushChanges - Mutation %q (%s) failed: %v", m.ID, m.Op, r.Err)
+			r.Status, r.Task, r.Conflicts = syncFailed, nil, nil
+		}
+	}
+
+	log.Printf("TaskService.PushChanges - Finished mutations for user: %s", userID)
+	return res, nil
+}
+
+// syncPatch is a mutation's fields split into the labels, which are set as a
+// whole, and everything else.
+type syncPatch struct {
+	fields    map[string]json.RawMessage
+	labels    []string
+	hasLabels bool
+}
+
+func (s *TaskService) applyMutation(c context.Context, userID string, m model.SyncMutation, ignore []string, r *model.SyncMutationResult) error {
+	if m.OnConflict == "" {
+		m.OnConflict = syncReport
+	}
+	if m.OnConflict != syncReport && m.OnConflict != syncLWW {
+		return fmt.Errorf("%w: on_conflict must be report or lww", ErrInvalidRequest)
+	}
+
+	tid, err := uuid.Parse(m.TaskID)
+	if err != nil {
+		return fmt.Errorf("%w: task_id must be a valid id", ErrInvalidRequest)
+	}
+
+	p := syncPatch{fields: map[string]json.RawMessage{}}
+	if len(m.Fields) > 0 {
+		if err := json.Unmarshal(m.Fields, &p.fields); err != nil || p.fields == nil {
+			return fmt.Errorf("%w: fields must be an object", ErrInvalidRequest)
+		}
+	}
+	if raw, ok := p.fields["labels"]; ok {
+		if err := json.Unmarshal(raw, &p.labels); err != nil {
+			return fmt.Errorf("%w: labels must be a list", ErrInvalidRequest)
+		}
+		if len(p.labels) > 0 {
+			if p.labels, err = cleanLabels(p.labels); err != nil {
+				return err
+			}
+		}
+		p.hasLabels = true
+		delete(p.fields, "labels")
+	}
+
+	switch m.Op {
+	case "create":
+		return s.pushCreate(c, userID, tid, m, p, r)
+	case "update":
+		return s.pushUpdate(c, userID, tid, m, p, ignore, r)
+	case "delete":
+		return s.pushDelete(c, userID, tid, m, ignore, r)
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidRequest, m.Op)
+	}
+}
+
+// pushCreate creates the task under the id the client gave it. Creating a
+// task that already exists counts as applied, so a client can safely resend
+// a batch whose response it never got.
+func (s *TaskService) pushCreate(c context.Context, userID string, taskID uuid.UUID, m model.SyncMutation, p syncPatch, r *model.SyncMutationResult) error {
+	if _, err := s.GetTaskByID(c, userID, m.TaskID); !errors.Is(err, repository.ErrTaskNotFound) {
+		if err != nil {
+			return err
+		}
+		r.Status = syncApplied
+		r.Task, err = s.syncTask(c, userID, m.TaskID)
+		return err
+	}
+
+	var req model.RequestCreateTask
+	if err := json.Unmarshal(m.Fields, &req); err != nil {
+		return fmt.Errorf("%w: fields are not a valid task", ErrInvalidRequest)
+	}
+	id := taskID.String()
+	req.ID, req.UserID = &id, userID
+
+	if _, err := s.CreateTask(c, req); err != nil {
+		return err
+	}
+	if len(p.labels) > 0 {
+		if _, err := s.AddLabels(c, userID, id, p.labels); err != nil {
+			return err
+		}
+	}
+
+	r.Status = syncApplied
+	var err error
+	r.Task, err = s.syncTask(c, userID, id)
+	return err
+}
+
+// pushUpdate applies a merge patch, minus whichever fields lost a conflict.
+func (s *TaskService) pushUpdate(c context.Context, userID string, taskID uuid.UUID, m model.SyncMutation, p syncPatch, ignore []string, r *model.SyncMutationResult) error {
+	names := make([]string, 0, len(p.fields)+1)
+	for name := range p.fields {
+		if !isSyncField(name) {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidRequest, name)
+		}
+		names = append(names, name)
+	}
+	if p.hasLabels {
+		names = append(names, "labels")
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%w: nothing to update", ErrInvalidRequest)
+	}
+
+	current, err := s.syncTask(c, userID, m.TaskID)
+	if err != nil {
+		return err
+	}
+
+	conflicts, err := s.syncConflicts(c, taskID, m, names, ignore, current)
+	if err != nil {
+		return err
+	}
+
+	r.Status = syncApplied
+	for _, conflict := range conflicts {
+		if conflict.Winner == "server" {
+			r.Status = syncConflict
+			delete(p.fields, conflict.Field)
+			if conflict.Field == "labels" {
+				p.hasLabels = false
+			}
+		}
+	}
+	r.Conflicts = conflicts
+
+	if len(p.fields) > 0 {
+		raw, err := json.Marshal(p.fields)
+		if err != nil {
+			return err
+		}
+		var patch model.RequestUpdateTask
+		if err := json.Unmarshal(raw, &patch); err != nil {
+			return fmt.Errorf("%w: fields are not a valid patch", ErrInvalidRequest)
+		}
+		if _, err := s.UpdateTaskDetails(c, userID, m.TaskID, &patch, nil); err != nil {
+			return err
+		}
+	}
+
+	if p.hasLabels {
+		add, remove := diffLabels(current.Labels, p.labels)
+		if len(add) > 0 {
+			if _, err := s.AddLabels(c, userID, m.TaskID, add); err != nil {
+				return err
+			}
+		}
+		if len(remove) > 0 {
+			if _, err := s.RemoveLabels(c, userID, m.TaskID, remove); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.Task, err = s.syncTask(c, userID, m.TaskID)
+	return err
+}
+
+// pushDelete deletes the task unless it changed after the client's base token
+// and the server's change wins. A task that is already gone counts as deleted.
+func (s *TaskService) pushDelete(c context.Context, userID string, taskID uuid.UUID, m model.SyncMutation, ignore []string, r *model.SyncMutationResult) error {
+	current, err := s.syncTask(c, userID, m.TaskID)
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		r.Status = syncApplied
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	conflicts, err := s.syncConflicts(c, taskID, m, syncFields, ignore, current)
+	if err != nil {
+		return err
+	}
+
+	for _, conflict := range conflicts {
+		if conflict.Winner == "server" {
+			r.Status, r.Task, r.Conflicts = syncConflict, current, conflicts
+			return nil
+		}
+	}
+
+	if err := s.DeleteTask(c, userID, m.TaskID); err != nil {
+		return err
+	}
+
+	r.Status, r.Conflicts = syncApplied, conflicts
+	return nil
+}
+
+// syncConflicts lists the given fields that the server changed after the
+// mutation's base token, other than in the ignored transactions. Under
+// last-writer-wins the client takes a field if it made its change later than
+// the server did.
+func (s *TaskService) syncConflicts(c context.Context, taskID uuid.UUID, m model.SyncMutation, fields, ignore []string, current *model.SyncTask) ([]model.SyncConflict, error) {
+	if m.BaseToken == "" {
+		return nil, fmt.Errorf("%w: base_token is required", ErrInvalidRequest)
+	}
+	base, err := decodeSyncToken(m.BaseToken)
+	if err != nil {
+		return nil, err
+	}
+	if base.Since == "" {
+		return nil, fmt.Errorf("%w: base_token must come from a finished sync", ErrInvalidRequest)
+	}
+
+	changed, err := s.taskRepo.ChangedFieldsSince(c, taskID, base.Since, fields, ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	changedAt := time.Now()
+	if m.ChangedAt != nil {
+		changedAt = *m.ChangedAt
+	}
+
+	values, err := syncValues(current)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]model.SyncConflict, 0, len(changed))
+	for field, at := range changed {
+		winner := "server"
+		if m.OnConflict == syncLWW && changedAt.After(at) {
+			winner = "client"
+		}
+		conflicts = append(conflicts, model.SyncConflict{Field: field, ServerValue: values[field], Winner: winner})
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Field < conflicts[j].Field })
+
+	return conflicts, nil
+}
+
+// syncTask loads a task the way a sync hands it out.
+func (s *TaskService) syncTask(c context.Context, userID, taskID string) (*model.SyncTask, error) {
+	task, err := s.GetTaskByID(c, userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := s.GetLabels(c, userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SyncTask{ResponseCreateTask: *newTaskResponse(task), Labels: labels}, nil
+}
+
+// syncValues maps each sync field to the task's current value as JSON.
+func syncValues(task *model.SyncTask) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func isSyncField(name string) bool {
+	for _, field := range syncFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// diffLabels works out which labels to add and remove to get from current to
+// wanted.
+func diffLabels(current, wanted []string) (add, remove []string) {
+	have := make(map[string]bool, len(current))
+	for _, label := range current {
+		have[label] = true
+	}
+	want := make(map[string]bool, len(wanted))
+	for _, label := range wanted {
+		want[label] = true
+		if !have[label] {
+			add = append(add, label)
+		}
+	}
+	for _, label := range current {
+		if !want[label] {
+			remove = append(remove, label)
+		}
+	}
+	return add, remove
+}
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -13,16 +12,22 @@ import (
 )
 
 type TaskService struct {
-	taskRepo *repository.TaskRepository
-	userRepo *repository.UserRepository
-	timeout  time.Duration
+	taskRepo      *repository.TaskRepository
+	userRepo      *repository.UserRepository
+	workspaceRepo *repository.WorkspaceRepository
+	projectRepo   *repository.ProjectRepository
+	outboxService *OutboxService
+	timeout       time.Duration
 }
 
-func NewTaskService(taskRepo *repository.TaskRepository, userRepo *repository.UserRepository) *TaskService {
+func NewTaskService(taskRepo *repository.TaskRepository, userRepo *repository.UserRepository, workspaceRepo *repository.WorkspaceRepository, projectRepo *repository.ProjectRepository, outboxService *OutboxService) *TaskService {
 	return &TaskService{
-		taskRepo: taskRepo,
-		userRepo: userRepo,
-		timeout:  time.Duration(2) * time.Second,
+		taskRepo:      taskRepo,
+		userRepo:      userRepo,
+		workspaceRepo: workspaceRepo,
+		projectRepo:   projectRepo,
+		outboxService: outboxService,
+		timeout:       time.Duration(2) * time.Second,
 	}
 }
 
@@ -41,14 +46,102 @@ func (s *TaskService) CreateTask(c context.Context, req model.RequestCreateTask)
 		req.Status = "TO_DO"
 	}
 
+	if err := validateEstimates(req.StoryPoints, req.EstimateSeconds, req.RemainingSeconds); err != nil {
+		log.Printf("TaskService.CreateTask - Validation failed: %v", err)
+		return nil, err
+	}
+
+	// A fresh estimate starts out entirely remaining.
+	if req.RemainingSeconds == nil {
+		req.RemainingSeconds = req.EstimateSeconds
+	}
+
+	uid, err := uuid.Parse(req.UserID)
+	if err != nil {
+		log.Printf("TaskService.CreateTask - UUID parsing error: %v", err)
+		return nil, fmt.Errorf("%w: user_id must be a valid id", ErrInvalidRequest)
+	}
+
+	var taskID string
+	if req.ID != nil {
+		id, err := uuid.Parse(*req.ID)
+		if err != nil {
+			log.Printf("TaskService.CreateTask - UUID parsing error: %v", err)
+			return nil, fmt.Errorf("%w: id must be a valid id", ErrInvalidRequest)
+		}
+		taskID = id.String()
+	}
+
+	// The creator is always the signed-in caller; the body can't name anyone
+	// else.
+	if caller, ok := repository.UserFromContext(c); !ok || caller != uid {
+		log.Printf("TaskService.CreateTask - Creator %s is not the signed-in user", req.UserID)
+		return nil, ErrForbidden
+	}
+
+	// The task lands in the workspace from the route, else its parent's
+	// workspace, else the creator's personal workspace.
+	wid, scoped := repository.WorkspaceFromContext(c)
+
+	if req.ParentID != nil {
+		parent, err := s.checkParent(c, uuid.Nil, req.UserID, *req.ParentID)
+		if err != nil {
+			log.Printf("TaskService.CreateTask - Invalid parent task: %v", err)
+			return nil, err
+		}
+		if !scoped {
+			wid, scoped = uuid.MustParse(parent.WorkspaceID), true
+		}
+	}
+
+	if !scoped {
+		wid, err = s.workspaceRepo.GetPersonalWorkspaceID(c, uid)
+		if err != nil {
+			log.Printf("TaskService.CreateTask - Database error: %v", err)
+			return nil, err
+		}
+	}
+
+	role, err := s.workspaceRepo.GetRole(c, wid, uid)
+	if err != nil {
+		log.Printf("TaskService.CreateTask - Database error: %v", err)
+		return nil, err
+	}
+
+	if roleAccess(role) < accessWrite {
+		log.Printf("TaskService.CreateTask - User %s cannot create tasks in workspace: %s", req.UserID, wid.String())
+		return nil, ErrWorkspaceForbidden
+	}
+
+	if req.ProjectID != nil {
+		if err := s.checkProject(c, wid, *req.ProjectID); err != nil {
+			log.Printf("TaskService.CreateTask - Invalid project: %v", err)
+			return nil, err
+		}
+	}
+
 	t := &repository.Task{
-		Name:        req.Name,
-		Description: req.Description,
-		Status:      req.Status,
-		UserID:      req.UserID,
+		ID:               taskID,
+		Name:             req.Name,
+		Description:      req.Description,
+		Status:           req.Status,
+		UserID:           req.UserID,
+		WorkspaceID:      wid.String(),
+		ProjectID:        req.ProjectID,
+		ParentID:         req.ParentID,
+		StoryPoints:      req.StoryPoints,
+		EstimateSeconds:  req.EstimateSeconds,
+		RemainingSeconds: req.RemainingSeconds,
+		DueAt:            req.DueAt,
 	}
 
-	task, err := s.taskRepo.CreateTask(c, t)
+	var task *repository.Task
+	err = s.outboxService.InTx(c, func(c context.Context) (err error) {
+		if task, err = s.taskRepo.CreateTask(c, t); err != nil {
+			return err
+		}
+		return s.outboxService.RecordTask(c, EventTaskCreated, task, nil)
+	})
 	if err != nil {
 		log.Printf("TaskService.CreateTask - Database error: %v", err)
 		return nil, fmt.Errorf("failed to create task: %v", err)
@@ -56,25 +149,18 @@ func (s *TaskService) CreateTask(c context.Context, req model.RequestCreateTask)
 
 	log.Printf("TaskService.CreateTask - Task creation was successful: %s", task.ID)
 
-	return &model.ResponseCreateTask{
-		ID:          task.ID,
-		Name:        task.Name,
-		Description: task.Description,
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-	}, nil
+	return newTaskResponse(task), nil
 }
 
-func (s *TaskService) GetTaskByID(c context.Context, taskID string) (*repository.Task, error) {
+func (s *TaskService) GetTaskByID(c context.Context, userID, taskID string) (*repository.Task, error) {
 	c, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
 	log.Printf("TaskService.GetTaskByID - Starting attempt to fetch task by ID: %s", taskID)
 
-	tid, err := uuid.Parse(taskID)
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessRead)
 	if err != nil {
-		log.Printf("TaskService.GetTaskByID - UUID parsing error: %v", err)
+		log.Printf("TaskService.GetTaskByID - Access check failed: %v", err)
 		return nil, err
 	}
 
@@ -88,7 +174,9 @@ func (s *TaskService) GetTaskByID(c context.Context, taskID string) (*repository
 	return t, nil
 }
 
-func (s *TaskService) GetTasksByUserID(c context.Context, userID string) ([]repository.Task, error) {
+// GetTasksByUserID returns one page of the user's tasks and the cursor of the
+// next page, which is empty on the last one.
+func (s *TaskService) GetTasksByUserID(c context.Context, userID string, req model.RequestListTasks) ([]repository.Task, string, error) {
 	c, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
@@ -97,36 +185,57 @@ func (s *TaskService) GetTasksByUserID(c context.Context, userID string) ([]repo
 	uid, err := uuid.Parse(userID)
 	if err != nil {
 		log.Printf("TaskService.GetTasksByUserID - UUID parsing error: %v", err)
-		return nil, err
+		return nil, "", err
+	}
+
+	q, err := parseTaskQuery(req)
+	if err != nil {
+		return nil, "", err
 	}
 
-	t, err := s.taskRepo.GetTasksByUserID(c, uid)
+	t, err := s.taskRepo.GetTasksByUserID(c, uid, q)
 	if err != nil {
 		log.Printf("TaskService.GetTasksByUserID - Database error: %v", err)
-		return nil, err
+		return nil, "", err
 	}
 
+	t, next := nextCursor(t, q)
 	log.Printf("TaskService.GetTasksByUserID - Successfully fetched tasks by UserID: %s", userID)
-	return t, nil
+	return t, next, nil
 }
 
-func (s *TaskService) GetTasks(c context.Context) ([]repository.Task, error) {
+func (s *TaskService) GetTasks(c context.Context, projectID string, req model.RequestListTasks) ([]repository.Task, string, error) {
 	c, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
 	log.Printf("TaskService.GetTasks - Starting attempt to fetch tasks.")
 
-	t, err := s.taskRepo.GetTasks(c)
+	var pid *uuid.UUID
+	if projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: project_id must be a valid id", ErrInvalidRequest)
+		}
+		pid = &id
+	}
+
+	q, err := parseTaskQuery(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	t, err := s.taskRepo.GetTasks(c, pid, q)
 	if err != nil {
 		log.Printf("TaskService.GetTasks - Database error: %v", err)
-		return nil, err
+		return nil, "", err
 	}
 
+	t, next := nextCursor(t, q)
 	log.Printf("TaskService.GetTasks - Successfully fetched tasks.")
-	return t, nil
+	return t, next, nil
 }
 
-func (s *TaskService) GetTasksByEmail(c context.Context, email string) ([]repository.Task, error) {
+func (s *TaskService) GetTasksByEmail(c context.Context, email string, req model.RequestListTasks) ([]repository.Task, string, error) {
 	c, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
@@ -135,170 +244,260 @@ func (s *TaskService) GetTasksByEmail(c context.Context, email string) ([]reposi
 	userID, err := s.userRepo.GetUserIDByEmail(c, email)
 	if err != nil {
 		log.Printf("TaskService.GetTasksByEmail - Database error: %v", err)
-		return nil, err
+		return nil, "", err
 	}
 
 	uid, err := uuid.Parse(userID)
 	if err != nil {
 		log.Printf("TaskService.GetTasksByEmail - UUID parsing error (user_id): %v", err)
-		return nil, err
+		return nil, "", err
 	}
 
-	t, err := s.taskRepo.GetTasksByUserID(c, uid)
+	q, err := parseTaskQuery(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	t, err := s.taskRepo.GetTasksByUserID(c, uid, q)
 	if err != nil {
 		log.Printf("TaskService.GetTasksByEmail - Database error: %v", err)
-		return nil, err
+		return nil, "", err
 	}
 
+	t, next := nextCursor(t, q)
 	log.Printf("TaskService.GetTasksByEmail - Successfully fetched tasks by email: %s", email)
-	return t, nil
+	return t, next, nil
 }
 
-func (s *TaskService) UpdateTaskDetails(c context.Context, taskID string, req *model.RequestUpdateTask) (*model.ResponseCreateTask, error) {
-	if req == nil {
-		return nil, errors.New("nothing to update")
+// GetMyTasks lists the caller's tasks: the ones they own (the default), the
+// ones assigned to them, the ones they watch, or the ones shared with them.
+// Archived tasks are left out unless req asks for them.
+func (s *TaskService) GetMyTasks(c context.Context, userID, filter string, req model.RequestListTasks) ([]repository.Task, string, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TaskService.GetMyTasks - Starting attempt to fetch %q tasks for user: %s", filter, userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TaskService.GetMyTasks - UUID parsing error: %v", err)
+		return nil, "", err
 	}
 
-	if req.Name == nil && req.Description == nil && req.Status == nil {
-		return nil, errors.New("nothing to update")
+	q, err := parseTaskQuery(req)
+	if err != nil {
+		return nil, "", err
 	}
 
+	var t []repository.Task
+	switch filter {
+	case "", "owned":
+		t, err = s.taskRepo.GetTasksByUserID(c, uid, q)
+	case "assigned":
+		t, err = s.taskRepo.GetTasksByAssignee(c, uid, q)
+	case "watching":
+		t, err = s.taskRepo.GetTasksByWatcher(c, uid, q)
+	case "shared":
+		t, err = s.taskRepo.GetTasksBySharedUser(c, uid, q)
+	default:
+		return nil, "", fmt.Errorf("%w: filter must be one of owned, assigned, watching, shared", ErrInvalidRequest)
+	}
+	if err != nil {
+		log.Printf("TaskService.GetMyTasks - Database error: %v", err)
+		return nil, "", err
+	}
+
+	t, next := nextCursor(t, q)
+	log.Printf("TaskService.GetMyTasks - Successfully fetched %q tasks for user: %s", filter, userID)
+	return t, next, nil
+}
+
+// UpdateTaskDetails applies a JSON Merge Patch to the task in one statement.
+// When version is set the update only succeeds if the task hasn't changed
+// since the caller read that version.
+func (s *TaskService) UpdateTaskDetails(c context.Context, userID, taskID string, req *model.RequestUpdateTask, version *int64) (*model.ResponseCreateTask, error) {
 	c, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
-	log.Printf("TaskService.UpdateTaskDetails - Starting attempt to update task records.")
+	log.Printf("TaskService.UpdateTaskDetails - Starting attempt to update task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("TaskService.UpdateTaskDetails - Access check failed: %v", err)
+		return nil, err
+	}
 
-	var err error
-	tid, err := uuid.Parse(taskID)
+	changes, err := s.taskChanges(c, userID, tid, req)
 	if err != nil {
-		log.Printf("TaskService.UpdateTaskDetails - UUID parsing error: %v", err)
+		log.Printf("TaskService.UpdateTaskDetails - Validation failed: %v", err)
 		return nil, err
 	}
 
 	var task *repository.Task
+	err = s.outboxService.InTx(c, func(c context.Context) (err error) {
+		// The status before the update goes out with task.status_changed.
+		var before *repository.Task
+		if _, ok := changes["status"]; ok {
+			if before, err = s.taskRepo.GetTaskByID(c, tid); err != nil {
+				return err
+			}
+		}
 
-	if req.Name != nil {
-		log.Printf("\tTaskService.UpdateTaskDetails - Updating name.")
-		task, err = s.taskRepo.UpdateName(c, tid, *req.Name)
-		if err != nil {
-			log.Printf("> \tTaskService.UpdateTaskDetails - Database error: %v", err)
-			return nil, err
+		if task, err = s.taskRepo.UpdateTask(c, tid, changes, version); err != nil {
+			return err
+		}
+
+		if err := s.outboxService.RecordTask(c, EventTaskUpdated, task, nil); err != nil {
+			return err
 		}
+		if before != nil && before.Status != task.Status {
+			return s.outboxService.RecordTask(c, EventTaskStatusChanged, task, map[string]any{"previous_status": before.Status})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("TaskService.UpdateTaskDetails - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TaskService.UpdateTaskDetails - Successfully updated task: %s", taskID)
+
+	return newTaskResponse(task), nil
+}
+
+// taskChanges validates a merge patch and turns it into the column changes
+// for the repository.
+func (s *TaskService) taskChanges(c context.Context, userID string, taskID uuid.UUID, req *model.RequestUpdateTask) (repository.TaskUpdate, error) {
+	changes := repository.TaskUpdate{}
+
+	if req.Name.Set {
+		if req.Name.Value == nil || *req.Name.Value == "" {
+			return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidRequest)
+		}
+		changes["name"] = *req.Name.Value
+	}
+	if req.Description.Set {
+		changes["description"] = ""
+		if req.Description.Value != nil {
+			changes["description"] = *req.Description.Value
+		}
+	}
+	if req.Status.Set {
+		if req.Status.Value == nil || *req.Status.Value == "" {
+			return nil, fmt.Errorf("%w: status cannot be empty", ErrInvalidRequest)
+		}
+		changes["status"] = *req.Status.Value
+	}
+
+	if err := validateEstimates(req.StoryPoints.Value, req.EstimateSeconds.Value, req.RemainingSeconds.Value); err != nil {
+		return nil, err
+	}
+	if req.StoryPoints.Set {
+		changes["story_points"] = req.StoryPoints.Value
+	}
+	if req.EstimateSeconds.Set {
+		changes["estimate_seconds"] = req.EstimateSeconds.Value
+	}
+	if req.RemainingSeconds.Set {
+		changes["remaining_seconds"] = req.RemainingSeconds.Value
+	}
+	if req.DueAt.Set {
+		changes["due_at"] = req.DueAt.Value
 	}
-	if req.Description != nil {
-		log.Printf("\tTaskService.UpdateTaskDetails - Updating description.")
-		task, err = s.taskRepo.UpdateDescription(c, tid, *req.Description)
+
+	if req.ProjectID.Set {
+		pid, err := s.patchProject(c, taskID, req.ProjectID.Value)
 		if err != nil {
-			log.Printf("> \tTaskService.UpdateTaskDetails - Database error: %v", err)
 			return nil, err
 		}
+		changes["project_id"] = pid
 	}
-	if req.Status != nil {
-		log.Printf("\tTaskService.UpdateTaskDetails - Updating status.")
-		task, err = s.taskRepo.UpdateStatus(c, tid, *req.Status)
+	if req.ParentID.Set {
+		pid, err := s.patchParent(c, userID, taskID, req.ParentID.Value)
 		if err != nil {
-			log.Printf("> \tTaskService.UpdateTaskDetails - Database error: %v", err)
 			return nil, err
 		}
+		changes["parent_id"] = pid
 	}
 
-	log.Printf("TaskService.UpdateTaskDetails - Succesffuly updated task records.")
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: nothing to update", ErrInvalidRequest)
+	}
 
-	return &model.ResponseCreateTask{
-		ID:          task.ID,
-		Name:        task.Name,
-		Status:      task.Status,
-		Description: task.Description,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-	}, nil
+	return changes, nil
 }
 
-func (s *TaskService) DeleteTask(c context.Context, taskID string) error {
+func (s *TaskService) DeleteTask(c context.Context, userID, taskID string) error {
 	c, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
-	tid, err := uuid.Parse(taskID)
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessOwner)
 	if err != nil {
 		return err
 	}
 
-	if err := s.taskRepo.DeleteTask(c, tid); err != nil {
-		return err
-	}
-	return nil
-}
+	return s.outboxService.InTx(c, func(c context.Context) error {
+		task, err := s.taskRepo.GetTaskByID(c, tid)
+		if err != nil {
+			return err
+		}
 
-/*
-func (s *TaskService) UpdateName(c context.Context, taskID string, name string) (*model.ResponseCreateTask, error) {
-	c, cancel := context.WithTimeout(c, s.timeout)
-	defer cancel()
+		// Deleting only moves the task to the trash; see TrashService.
+		if err := s.taskRepo.TrashTask(c, tid, uuid.MustParse(userID)); err != nil {
+			return err
+		}
 
-	tid, err := uuid.Parse(taskID)
-	if err != nil {
-		return nil, err
-	}
+		return s.outboxService.RecordTask(c, EventTaskDeleted, task, nil)
+	})
+}
 
-	task, err := s.taskRepo.UpdateName(c, tid, name)
+// checkProject makes sure projectID names a project in the given workspace.
+func (s *TaskService) checkProject(c context.Context, workspaceID uuid.UUID, projectID string) error {
+	pid, err := uuid.Parse(projectID)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("%w: project_id must be a project id", ErrInvalidRequest)
 	}
 
-	return &model.ResponseCreateTask{
-		ID:          task.ID,
-		Name:        task.Name,
-		Description: task.Description,
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-	}, nil
+	_, err = s.projectRepo.GetProjectByID(c, workspaceID, pid)
+	return err
 }
 
-func (s *TaskService) UpdateDescription(c context.Context, taskID string, desc string) (*model.ResponseCreateTask, error) {
-	c, cancel := context.WithTimeout(c, s.timeout)
-	defer cancel()
-
-	tid, err := uuid.Parse(taskID)
-	if err != nil {
-		return nil, err
+// patchProject checks a project_id from a patch. Null or empty moves the task
+// out of any project; otherwise the project must be in the task's workspace.
+func (s *TaskService) patchProject(c context.Context, taskID uuid.UUID, projectID *string) (*uuid.UUID, error) {
+	if projectID == nil || *projectID == "" {
+		return nil, nil
 	}
 
-	task, err := s.taskRepo.UpdateDescription(c, tid, desc)
+	task, err := s.taskRepo.GetTaskByID(c, taskID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &model.ResponseCreateTask{
-		ID:          task.ID,
-		Name:        task.Name,
-		Description: task.Description,
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-	}, nil
-}
-
-func (s *TaskService) UpdateStatus(c context.Context, taskID string, desc string) (*model.ResponseCreateTask, error) {
-	c, cancel := context.WithTimeout(c, s.timeout)
-	defer cancel()
-
-	tid, err := uuid.Parse(taskID)
-	if err != nil {
+	if err := s.checkProject(c, uuid.MustParse(task.WorkspaceID), *projectID); err != nil {
 		return nil, err
 	}
 
-	task, err := s.taskRepo.UpdateStatus(c, tid, desc)
-	if err != nil {
-		return nil, err
-	}
+	pid := uuid.MustParse(*projectID)
+	return &pid, nil
+}
 
+func newTaskResponse(task *repository.Task) *model.ResponseCreateTask {
 	return &model.ResponseCreateTask{
-		ID:          task.ID,
-		Name:        task.Name,
-		Description: task.Description,
-		Status:      task.Status,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-	}, nil
+		ID:               task.ID,
+		Name:             task.Name,
+		Description:      task.Description,
+		Status:           task.Status,
+		WorkspaceID:      task.WorkspaceID,
+		ProjectID:        task.ProjectID,
+		ParentID:         task.ParentID,
+		StoryPoints:      task.StoryPoints,
+		EstimateSeconds:  task.EstimateSeconds,
+		RemainingSeconds: task.RemainingSeconds,
+		DueAt:            task.DueAt,
+		Version:          task.Version,
+		CreatedAt:        task.CreatedAt,
+		UpdatedAt:        task.UpdatedAt,
+		ArchivedAt:       task.ArchivedAt,
+	}
 }
-*/
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const maxTimeEntryDuration = 24 * time.Hour
+
+type TimeEntryService struct {
+	timeEntryRepo *repository.TimeEntryRepository
+	taskRepo      *repository.TaskRepository
+	timeout       time.Duration
+}
+
+func NewTimeEntryService(timeEntryRepo *repository.TimeEntryRepository, taskRepo *repository.TaskRepository) *TimeEntryService {
+	return &TimeEntryService{
+		timeEntryRepo: timeEntryRepo,
+		taskRepo:      taskRepo,
+		timeout:       time.Duration(2) * time.Second,
+	}
+}
+
+func (s *TimeEntryService) StartTimer(c context.Context, userID, taskID string, req model.RequestStartTimer) (*repository.TimeEntry, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TimeEntryService.StartTimer - Starting timer on task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("TimeEntryService.StartTimer - Task lookup failed: %v", err)
+		return nil, err
+	}
+
+	e, err := s.timeEntryRepo.StartTimer(c, tid, uuid.MustParse(userID), req.Note)
+	if err != nil {
+		log.Printf("TimeEntryService.StartTimer - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TimeEntryService.StartTimer - Timer started: %s", e.ID)
+	return e, nil
+}
+
+func (s *TimeEntryService) StopTimer(c context.Context, userID string) (*repository.TimeEntry, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TimeEntryService.StopTimer - Stopping running timer for user: %s", userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TimeEntryService.StopTimer - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	e, err := s.timeEntryRepo.StopTimer(c, uid)
+	if err != nil {
+		log.Printf("TimeEntryService.StopTimer - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TimeEntryService.StopTimer - Timer stopped: %s", e.ID)
+	return e, nil
+}
+
+func (s *TimeEntryService) GetRunningTimer(c context.Context, userID string) (*repository.TimeEntry, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TimeEntryService.GetRunningTimer - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	e, err := s.timeEntryRepo.GetRunningTimer(c, uid)
+	if err != nil {
+		log.Printf("TimeEntryService.GetRunningTimer - Database error: %v", err)
+		return nil, err
+	}
+
+	if e == nil {
+		return nil, repository.ErrNoRunningTimer
+	}
+
+	return e, nil
+}
+
+func (s *TimeEntryService) CreateEntry(c context.Context, userID, taskID string, req model.RequestCreateTimeEntry) (*repository.TimeEntry, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TimeEntryService.CreateEntry - Starting manual time entry on task: %s", taskID)
+
+	if req.StartedAt.IsZero() {
+		return nil, fmt.Errorf("%w: started_at is required", ErrInvalidRequest)
+	}
+
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	if err := validateDuration(duration); err != nil {
+		log.Printf("TimeEntryService.CreateEntry - Validation failed: %v", err)
+		return nil, err
+	}
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessWrite)
+	if err != nil {
+		log.Printf("TimeEntryService.CreateEntry - Task lookup failed: %v", err)
+		return nil, err
+	}
+
+	endedAt := req.StartedAt.Add(duration)
+	e, err := s.timeEntryRepo.CreateEntry(c, &repository.TimeEntry{
+		TaskID:    tid.String(),
+		UserID:    userID,
+		StartedAt: req.StartedAt,
+		EndedAt:   &endedAt,
+		Note:      req.Note,
+	})
+	if err != nil {
+		log.Printf("TimeEntryService.CreateEntry - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TimeEntryService.CreateEntry - Time entry created: %s", e.ID)
+	return e, nil
+}
+
+// GetTaskTime returns every entry logged against the task together with the
+// total tracked seconds, running timers included.
+func (s *TimeEntryService) GetTaskTime(c context.Context, userID, taskID string) ([]repository.TimeEntry, int64, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TimeEntryService.GetTaskTime - Starting attempt to fetch time entries for task: %s", taskID)
+
+	tid, err := authorizeTask(c, s.taskRepo, userID, taskID, accessRead)
+	if err != nil {
+		log.Printf("TimeEntryService.GetTaskTime - Task lookup failed: %v", err)
+		return nil, 0, err
+	}
+
+	entries, err := s.timeEntryRepo.GetEntriesByTaskID(c, tid)
+	if err != nil {
+		log.Printf("TimeEntryService.GetTaskTime - Database error: %v", err)
+		return nil, 0, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.DurationSeconds
+	}
+
+	log.Printf("TimeEntryService.GetTaskTime - Successfully fetched time entries for task: %s", taskID)
+	return entries, total, nil
+}
+
+func (s *TimeEntryService) UpdateEntry(c context.Context, userID, entryID string, req model.RequestUpdateTimeEntry) (*repository.TimeEntry, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TimeEntryService.UpdateEntry - Starting attempt to update time entry: %s", entryID)
+
+	e, err := s.ownedEntry(c, userID, entryID)
+	if err != nil {
+		log.Printf("TimeEntryService.UpdateEntry - Entry lookup failed: %v", err)
+		return nil, err
+	}
+
+	startedAt := e.StartedAt
+	if req.StartedAt != nil {
+		startedAt = *req.StartedAt
+	}
+
+	endedAt := e.EndedAt
+	switch {
+	case req.EndedAt != nil:
+		endedAt = req.EndedAt
+	case req.DurationSeconds != nil:
+		end := startedAt.Add(time.Duration(*req.DurationSeconds) * time.Second)
+		endedAt = &end
+	}
+
+	if endedAt != nil {
+		if err := validateDuration(endedAt.Sub(startedAt)); err != nil {
+			log.Printf("TimeEntryService.UpdateEntry - Validation failed: %v", err)
+			return nil, err
+		}
+	}
+
+	note := e.Note
+	if req.Note != nil {
+		note = *req.Note
+	}
+
+	updated, err := s.timeEntryRepo.UpdateEntry(c, uuid.MustParse(e.ID), startedAt, endedAt, note)
+	if err != nil {
+		log.Printf("TimeEntryService.UpdateEntry - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TimeEntryService.UpdateEntry - Successfully updated time entry: %s", entryID)
+	return updated, nil
+}
+
+func (s *TimeEntryService) DeleteEntry(c context.Context, userID, entryID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TimeEntryService.DeleteEntry - Starting attempt to delete time entry: %s", entryID)
+
+	e, err := s.ownedEntry(c, userID, entryID)
+	if err != nil {
+		log.Printf("TimeEntryService.DeleteEntry - Entry lookup failed: %v", err)
+		return err
+	}
+
+	if err := s.timeEntryRepo.DeleteEntry(c, uuid.MustParse(e.ID)); err != nil {
+		log.Printf("TimeEntryService.DeleteEntry - Database error: %v", err)
+		return err
+	}
+
+	log.Printf("TimeEntryService.DeleteEntry - Successfully deleted time entry: %s", entryID)
+	return nil
+}
+
+// GetTotals aggregates the user's tracked time between the from and to dates
+// (inclusive, YYYY-MM-DD in tz), grouped by "task", "day" or "project".
+func (s *TimeEntryService) GetTotals(c context.Context, userID, groupBy, from, to, tz string) ([]repository.TimeTotal, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TimeEntryService.GetTotals - Starting attempt to fetch totals by %s for user: %s", groupBy, userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TimeEntryService.GetTotals - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := dateRange(from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var totals []repository.TimeTotal
+	switch groupBy {
+	case "", "task":
+		totals, err = s.timeEntryRepo.GetTotalsByTask(c, uid, start, end)
+	case "day":
+		totals, err = s.timeEntryRepo.GetTotalsByDay(c, uid, start, end, loc.String())
+	case "project":
+		totals, err = s.timeEntryRepo.GetTotalsByProject(c, uid, start, end)
+	default:
+		return nil, fmt.Errorf("%w: group_by must be one of task, day, project", ErrInvalidRequest)
+	}
+	if err != nil {
+		log.Printf("TimeEntryService.GetTotals - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TimeEntryService.GetTotals - Successfully fetched totals for user: %s", userID)
+	return totals, nil
+}
+
+// GetTimesheet builds a Monday-to-Sunday timesheet for the week containing
+// the given date (YYYY-MM-DD, defaults to today).
+func (s *TimeEntryService) GetTimesheet(c context.Context, userID, week, tz string) (*model.ResponseTimesheet, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TimeEntryService.GetTimesheet - Starting attempt to build timesheet for user: %s", userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TimeEntryService.GetTimesheet - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+
+	day := time.Now().In(loc)
+	if week != "" {
+		day, err = time.ParseInLocation(time.DateOnly, week, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: week must be a YYYY-MM-DD date", ErrInvalidRequest)
+		}
+	}
+
+	start := weekStart(day)
+	end := start.AddDate(0, 0, 7)
+
+	cells, err := s.timeEntryRepo.GetTimesheet(c, uid, start, end, loc.String())
+	if err != nil {
+		log.Printf("TimeEntryService.GetTimesheet - Database error: %v", err)
+		return nil, err
+	}
+
+	sheet := &model.ResponseTimesheet{
+		WeekStart: start.Format(time.DateOnly),
+		TimeZone:  loc.String(),
+		Days:      make([]string, 7),
+		Rows:      []model.TimesheetRow{},
+		DayTotals: make([]int64, 7),
+	}
+	for i := range sheet.Days {
+		sheet.Days[i] = start.AddDate(0, 0, i).Format(time.DateOnly)
+	}
+
+	rows := map[string]int{}
+	for _, cell := range cells {
+		i, ok := rows[cell.TaskID]
+		if !ok {
+			i = len(sheet.Rows)
+			rows[cell.TaskID] = i
+			sheet.Rows = append(sheet.Rows, model.TimesheetRow{
+				TaskID:   cell.TaskID,
+				TaskName: cell.TaskName,
+				Seconds:  make([]int64, 7),
+			})
+		}
+
+		d := daysBetween(start, cell.Day)
+		if d < 0 || d > 6 {
+			continue
+		}
+
+		sheet.Rows[i].Seconds[d] += cell.Seconds
+		sheet.Rows[i].TotalSeconds += cell.Seconds
+		sheet.DayTotals[d] += cell.Seconds
+		sheet.TotalSeconds += cell.Seconds
+	}
+
+	log.Printf("TimeEntryService.GetTimesheet - Successfully built timesheet for user: %s", userID)
+	return sheet, nil
+}
+
+func (s *TimeEntryService) ownedEntry(c context.Context, userID, entryID string) (*repository.TimeEntry, error) {
+	eid, err := uuid.Parse(entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := s.timeEntryRepo.GetEntryByID(c, eid)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	return e, nil
+}
+
+func validateDuration(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("%w: duration must be positive", ErrInvalidRequest)
+	}
+	if d > maxTimeEntryDuration {
+		return fmt.Errorf("%w: a single time entry cannot exceed %s", ErrInvalidRequest, maxTimeEntryDuration)
+	}
+	return nil
+}
+
+func loadLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidRequest, tz)
+	}
+	return loc, nil
+}
+
+// dateRange turns inclusive YYYY-MM-DD bounds into a half-open time range,
+// defaulting to the current week.
+func dateRange(from, to string, loc *time.Location) (time.Time, time.Time, error) {
+	start := weekStart(time.Now().In(loc))
+	end := start.AddDate(0, 0, 7)
+
+	if from != "" {
+		t, err := time.ParseInLocation(time.DateOnly, from, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be a YYYY-MM-DD date", ErrInvalidRequest)
+		}
+		start = t
+	}
+
+	if to != "" {
+		t, err := time.ParseInLocation(time.DateOnly, to, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be a YYYY-MM-DD date", ErrInvalidRequest)
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", ErrInvalidRequest)
+	}
+
+	return start, end, nil
+}
+
+// daysBetween counts calendar days from a to b, ignoring DST-shortened days.
+func daysBetween(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+	"github.com/0xrishabk/tasktracker/internal/storage"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+type TrashService struct {
+	taskRepo  *repository.TaskRepository
+	store     storage.BlobStore
+	retention time.Duration
+	timeout   time.Duration
+}
+
+func NewTrashService(taskRepo *repository.TaskRepository, store storage.BlobStore, retention time.Duration) *TrashService {
+	if retention <= 0 {
+		retention = defaultTrashRetention
+	}
+
+	return &TrashService{
+		taskRepo:  taskRepo,
+		store:     store,
+		retention: retention,
+		timeout:   time.Duration(2) * time.Second,
+	}
+}
+
+func (s *TrashService) GetTrash(c context.Context, userID string) ([]repository.Task, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TrashService.GetTrash - Starting attempt to fetch trash for user: %s", userID)
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("TrashService.GetTrash - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	t, err := s.taskRepo.GetTrash(c, uid)
+	if err != nil {
+		log.Printf("TrashService.GetTrash - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TrashService.GetTrash - Successfully fetched trash for user: %s", userID)
+	return t, nil
+}
+
+func (s *TrashService) Restore(c context.Context, userID, taskID string) (*repository.Task, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TrashService.Restore - Starting attempt to restore task: %s", taskID)
+
+	tid, err := authorizeTrashedTask(c, s.taskRepo, userID, taskID, accessOwner)
+	if err != nil {
+		log.Printf("TrashService.Restore - Access check failed: %v", err)
+		return nil, err
+	}
+
+	t, err := s.taskRepo.RestoreTask(c, tid)
+	if err != nil {
+		log.Printf("TrashService.Restore - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("TrashService.Restore - Successfully restored task: %s", taskID)
+	return t, nil
+}
+
+// DeleteForever permanently deletes a task that is already in the trash.
+func (s *TrashService) DeleteForever(c context.Context, userID, taskID string) error {
+	dc, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("TrashService.DeleteForever - Starting attempt to permanently delete task: %s", taskID)
+
+	tid, err := authorizeTrashedTask(dc, s.taskRepo, userID, taskID, accessOwner)
+	if err != nil {
+		log.Printf("TrashService.DeleteForever - Access check failed: %v", err)
+		return err
+	}
+
+	keys, err := s.taskRepo.DeleteTrashedTask(dc, tid)
+	if err != nil {
+		log.Printf("TrashService.DeleteForever - Database error: %v", err)
+		return err
+	}
+
+	s.deleteBlobs(c, keys)
+
+	log.Printf("TrashService.DeleteForever - Successfully deleted task: %s", taskID)
+	return nil
+}
+
+// Purge permanently deletes everything that has been in the trash for longer
+// than the retention period, whoever it belongs to.
+func (s *TrashService) Purge(c context.Context) error {
+	cutoff := time.Now().Add(-s.retention)
+
+	dc, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	purged, keys, err := s.taskRepo.PurgeTrash(repository.WithSystem(dc), cutoff)
+	if err != nil {
+		log.Printf("TrashService.Purge - Database error: %v", err)
+		return err
+	}
+
+	s.deleteBlobs(c, keys)
+
+	if purged > 0 {
+		log.Printf("TrashService.Purge - Purged %d tasks trashed before %s", purged, cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// RunPurger calls Purge every interval until c is done.
+func (s *TrashService) RunPurger(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = s.Purge(c)
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *TrashService) deleteBlobs(c context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(c, key); err != nil {
+			log.Printf("TrashService.deleteBlobs - Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
@@ -16,8 +16,10 @@ import (
 )
 
 type UserService struct {
-	userRepo *repository.UserRepository
-	timeout  time.Duration
+	userRepo      *repository.UserRepository
+	workspaceRepo *repository.WorkspaceRepository
+	outboxService *OutboxService
+	timeout       time.Duration
 }
 
 type JWTClaims struct {
@@ -26,10 +28,12 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
+func NewUserService(userRepo *repository.UserRepository, workspaceRepo *repository.WorkspaceRepository, outboxService *OutboxService) *UserService {
 	return &UserService{
-		userRepo: userRepo,
-		timeout:  time.Duration(2) * time.Second,
+		userRepo:      userRepo,
+		workspaceRepo: workspaceRepo,
+		outboxService: outboxService,
+		timeout:       time.Duration(2) * time.Second,
 	}
 }
 
@@ -61,7 +65,13 @@ func (s *UserService) CreateUser(c context.Context, req model.RequestCreateUser)
 		PasswordHash: &hashedPassword,
 	}
 
-	user, err := s.userRepo.CreateUser(c, u)
+	var user *repository.User
+	err = s.outboxService.InTx(c, func(c context.Context) (err error) {
+		if user, err = s.userRepo.CreateUser(c, u); err != nil {
+			return err
+		}
+		return s.outboxService.RecordUser(c, EventUserCreated, user, nil)
+	})
 	if err != nil {
 		log.Printf("UserService.CreateUser - Database Error: %v", err)
 		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
@@ -81,7 +91,7 @@ func (s *UserService) CreateUser(c context.Context, req model.RequestCreateUser)
 		},
 	})
 
-	secretKey := os.Getenv("SECRET_JWT")
+	secretKey := os.Getenv("JWT_SECRET")
 	ss, err := token.SignedString([]byte(secretKey))
 	if err != nil {
 		return nil, err
@@ -100,30 +110,11 @@ func (s *UserService) Login(c context.Context, req model.RequestLoginUser) (*mod
 
 	log.Printf("UserService.Login - Starting login attempt for email: %s", req.Email)
 
-	user, err := s.userRepo.GetUserByEmail(c, req.Email)
+	user, err := s.Authenticate(c, req.Email, req.Password)
 	if err != nil {
-		log.Printf("UserService.Login - Database error: %v", err)
-		return nil, fmt.Errorf("failed to authenticate user")
-	}
-
-	if user == nil {
-		log.Printf("UserService.Login - User not found for email: %s", req.Email)
-		return nil, fmt.Errorf("invalid email or password")
-	}
-
-	if user.PasswordHash == nil {
-		log.Printf("UserService.Login - User has no password hash: %s", req.Email)
-		return nil, fmt.Errorf("invalid user account")
-	}
-
-	err = util.CheckPassword(req.Password, *user.PasswordHash)
-	if err != nil {
-		log.Printf("UserService.Login - Password check failed for the user: %s", user.ID.String())
-		return nil, fmt.Errorf("invalid email or password")
+		return nil, err
 	}
 
-	log.Printf("UserService.Login - Password verification successful for the user: %s", user.ID.String())
-
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
 		ID:       user.ID.String(),
 		Username: user.Username,
@@ -133,7 +124,7 @@ func (s *UserService) Login(c context.Context, req model.RequestLoginUser) (*mod
 		},
 	})
 
-	secret := os.Getenv("SECRET_JWT")
+	secret := os.Getenv("JWT_SECRET")
 	ss, err := token.SignedString([]byte(secret))
 	if err != nil {
 		return nil, err
@@ -142,6 +133,34 @@ func (s *UserService) Login(c context.Context, req model.RequestLoginUser) (*mod
 	return &model.ResponseLoginUser{AccessToken: ss, Username: user.Username, ID: user.ID.String()}, nil
 }
 
+// Authenticate checks a user's email and password.
+func (s *UserService) Authenticate(c context.Context, email, password string) (*repository.User, error) {
+	user, err := s.userRepo.GetUserByEmail(c, email)
+	if err != nil {
+		log.Printf("UserService.Authenticate - Database error: %v", err)
+		return nil, fmt.Errorf("failed to authenticate user")
+	}
+
+	if user == nil {
+		log.Printf("UserService.Authenticate - User not found for email: %s", email)
+		return nil, fmt.Errorf("invalid email or password")
+	}
+
+	if user.PasswordHash == nil {
+		log.Printf("UserService.Authenticate - User has no password hash: %s", email)
+		return nil, fmt.Errorf("invalid user account")
+	}
+
+	err = util.CheckPassword(password, *user.PasswordHash)
+	if err != nil {
+		log.Printf("UserService.Authenticate - Password check failed for the user: %s", user.ID.String())
+		return nil, fmt.Errorf("invalid email or password")
+	}
+
+	log.Printf("UserService.Authenticate - Password verification successful for the user: %s", user.ID.String())
+	return user, nil
+}
+
 func (s *UserService) GetUserByID(c context.Context, userID string) (*repository.User, error) {
 	c, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
@@ -177,7 +196,29 @@ func (s *UserService) DeleteUser(c context.Context, userID string) error {
 		return err
 	}
 
-	err = s.userRepo.DeleteUser(c, uid)
+	err = s.outboxService.InTx(c, func(c context.Context) error {
+		user, err := s.userRepo.GetUserByID(c, uid)
+		if err != nil {
+			return err
+		}
+
+		// The event names the user's workspaces while the memberships that
+		// say which they are still exist.
+		workspaces, err := s.workspaceRepo.GetWorkspacesByUserID(c, uid)
+		if err != nil {
+			return err
+		}
+		workspaceIDs := make([]string, 0, len(workspaces))
+		for _, w := range workspaces {
+			workspaceIDs = append(workspaceIDs, w.ID)
+		}
+
+		if err := s.userRepo.DeleteUser(c, uid); err != nil {
+			return err
+		}
+
+		return s.outboxService.RecordUser(c, EventUserDeleted, user, workspaceIDs)
+	})
 	if err != nil {
 		log.Printf("UserService.DeleteUser - Database Error: %v", err)
 		return err
@@ -199,7 +240,13 @@ func (s *UserService) UpdateUsername(c context.Context, userID string, newUserna
 		return nil, err
 	}
 
-	user, err := s.userRepo.UpdateUsername(c, uid, newUsername)
+	var user *repository.User
+	err = s.outboxService.InTx(c, func(c context.Context) (err error) {
+		if user, err = s.userRepo.UpdateUsername(c, uid, newUsername); err != nil {
+			return err
+		}
+		return s.outboxService.RecordUser(c, EventUserUpdated, user, nil)
+	})
 	if err != nil {
 		log.Printf("UserService.UpdateUsername - Database error: %v", err)
 		return nil, err
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/filter"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const maxViewName = 100
+
+type ViewService struct {
+	viewRepo         *repository.ViewRepository
+	workspaceService *WorkspaceService
+	taskService      *TaskService
+	timeout          time.Duration
+}
+
+func NewViewService(viewRepo *repository.ViewRepository, workspaceService *WorkspaceService, taskService *TaskService) *ViewService {
+	return &ViewService{
+		viewRepo:         viewRepo,
+		workspaceService: workspaceService,
+		taskService:      taskService,
+		timeout:          time.Duration(2) * time.Second,
+	}
+}
+
+// newView checks a view request and turns it into a view owned by userID.
+// Saving to a workspace needs membership of it.
+func (s *ViewService) newView(c context.Context, userID string, req model.RequestSavedView) (*repository.SavedView, error) {
+	v := &repository.SavedView{
+		UserID: userID,
+		Name:   strings.TrimSpace(req.Name),
+		Query:  strings.TrimSpace(req.Query),
+		Shared: req.Shared,
+	}
+
+	if v.Name == "" || len(v.Name) > maxViewName {
+		return nil, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidRequest, maxViewName)
+	}
+	if _, err := filter.Parse(v.Query, time.Now()); err != nil {
+		return nil, err
+	}
+	if v.Shared && req.WorkspaceID == nil {
+		return nil, fmt.Errorf("%w: a shared view needs a workspace_id", ErrInvalidRequest)
+	}
+
+	if req.WorkspaceID != nil {
+		wid, _, err := s.workspaceService.authorize(c, userID, *req.WorkspaceID, roleGuest)
+		if err != nil {
+			return nil, err
+		}
+		id := wid.String()
+		v.WorkspaceID = &id
+	}
+
+	return v, nil
+}
+
+func (s *ViewService) CreateView(c context.Context, userID string, req model.RequestSavedView) (*repository.SavedView, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ViewService.CreateView - Starting attempt to create view %q for user: %s", req.Name, userID)
+
+	v, err := s.newView(c, userID, req)
+	if err != nil {
+		log.Printf("ViewService.CreateView - Validation failed: %v", err)
+		return nil, err
+	}
+
+	created, err := s.viewRepo.CreateView(c, v)
+	if err != nil {
+		log.Printf("ViewService.CreateView - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ViewService.CreateView - Successfully created view: %s", created.ID)
+	return created, nil
+}
+
+func (s *ViewService) GetViews(c context.Context, userID string) ([]repository.SavedView, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("ViewService.GetViews - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	views, err := s.viewRepo.GetViews(c, uid)
+	if err != nil {
+		log.Printf("ViewService.GetViews - Database error: %v", err)
+		return nil, err
+	}
+
+	return views, nil
+}
+
+// GetView returns a view the caller owns or that is shared with them.
+func (s *ViewService) GetView(c context.Context, userID, viewID string) (*repository.SavedView, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.getView(c, viewID)
+}
+
+func (s *ViewService) getView(c context.Context, viewID string) (*repository.SavedView, error) {
+	vid, err := uuid.Parse(viewID)
+	if err != nil {
+		return nil, repository.ErrViewNotFound
+	}
+
+	v, err := s.viewRepo.GetViewByID(c, vid)
+	if err != nil {
+		log.Printf("ViewService.getView - Database error: %v", err)
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (s *ViewService) UpdateView(c context.Context, userID, viewID string, req model.RequestSavedView) (*repository.SavedView, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("ViewService.UpdateView - Starting attempt to update view: %s", viewID)
+
+	existing, err := s.getView(c, viewID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.UserID != userID {
+		return nil, ErrViewForbidden
+	}
+
+	v, err := s.newView(c, userID, req)
+	if err != nil {
+		log.Printf("ViewService.UpdateView - Validation failed: %v", err)
+		return nil, err
+	}
+	v.ID = existing.ID
+
+	updated, err := s.viewRepo.UpdateView(c, v)
+	if err != nil {
+		log.Printf("ViewService.UpdateView - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("ViewService.UpdateView - Successfully updated view: %s", viewID)
+	return updated, nil
+}
+
+func (s *ViewService) DeleteView(c context.Context, userID, viewID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	existing, err := s.getView(c, viewID)
+	if err != nil {
+		return err
+	}
+	if existing.UserID != userID {
+		return ErrViewForbidden
+	}
+
+	if err := s.viewRepo.DeleteView(c, uuid.MustParse(existing.ID), uuid.MustParse(userID)); err != nil {
+		log.Printf("ViewService.DeleteView - Database error: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetViewTasks runs a view's query for the caller. A view saved to a
+// workspace only looks inside that workspace.
+func (s *ViewService) GetViewTasks(c context.Context, userID, viewID string, req model.RequestListTasks) ([]repository.Task, string, error) {
+	v, err := s.GetView(c, userID, viewID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if v.WorkspaceID != nil {
+		c = repository.WithWorkspace(c, uuid.MustParse(*v.WorkspaceID))
+	}
+
+	return s.taskService.FilterTasks(c, userID, v.Query, req)
+}
@@ -0,0 +1,539 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/events"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const eventPing = "ping"
+
+// webhookEvents are the events webhooks can subscribe to.
+var webhookEvents = map[string]bool{
+	EventTaskCreated:       true,
+	EventTaskUpdated:       true,
+	EventTaskStatusChanged: true,
+	EventTaskDeleted:       true,
+	EventUserDeleted:       true,
+}
+
+const (
+	webhookBatchSize      = 20
+	webhookLease          = 2 * time.Minute
+	webhookRequestTimeout = 10 * time.Second
+	webhookMaxAttempts    = 10
+	webhookRetryBase      = 30 * time.Second
+	webhookRetryMax       = 6 * time.Hour
+	webhookDisableAfter   = 20
+	webhookResponseLimit  = 1024
+	webhookDeliveryLimit  = 50
+)
+
+// WebhookService manages webhook endpoints and delivers events to them.
+//
+// Each delivery is a POST of the event as JSON with these headers:
+//
+//	X-Webhook-Event      the event type, such as task.created
+//	X-Webhook-Delivery   the delivery ID; retries of a delivery reuse it
+//	X-Webhook-Signature  t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+//
+// keyed with the webhook's secret. Any 2xx response counts as delivered;
+// anything else is retried with exponential backoff, and a webhook that keeps
+// failing is switched off.
+//
+// Events reach the service through the outbox, as one of its sinks.
+//
+// Receivers are never reached on loopback, link-local, private or unspecified
+// addresses, whatever their host name resolves to, unless the address is in
+// one of the allowed networks, and redirects are not followed.
+type WebhookService struct {
+	webhookRepo      *repository.WebhookRepository
+	workspaceService *WorkspaceService
+	allowed          []*net.IPNet
+	client           *http.Client
+	timeout          time.Duration
+}
+
+func NewWebhookService(webhookRepo *repository.WebhookRepository, workspaceService *WorkspaceService, allowed []*net.IPNet) *WebhookService {
+	s := &WebhookService{
+		webhookRepo:      webhookRepo,
+		workspaceService: workspaceService,
+		allowed:          allowed,
+		timeout:          time.Duration(2) * time.Second,
+	}
+
+	// The check is made on the address actually dialled, so a host name that
+	// resolves to an internal address, or is rebound to one, is caught too.
+	dialer := &net.Dialer{
+		Timeout: webhookRequestTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !s.addressAllowed(ip) {
+				return fmt.Errorf("webhook address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	s.client = &http.Client{
+		Timeout:   webhookRequestTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return s
+}
+
+// ParseWebhookNetworks reads a comma-separated list of CIDR blocks, such as
+// "127.0.0.0/8,10.1.0.0/16", that webhooks may be delivered to even though
+// they are internal, for receivers run locally in development.
+func ParseWebhookNetworks(list string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, cidr := range strings.Split(list, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook network %q: %w", cidr, err)
+		}
+		networks = append(networks, n)
+	}
+	return networks, nil
+}
+
+// addressAllowed reports whether webhooks may be sent to ip.
+func (s *WebhookService) addressAllowed(ip net.IP) bool {
+	for _, n := range s.allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// checkURL vets a receiver URL when it is registered. Host names are only
+// resolved when a delivery is sent, but internal addresses given outright are
+// turned away here already.
+func (s *WebhookService) checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an http or https URL", ErrInvalidRequest)
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil && !s.addressAllowed(ip) {
+		return fmt.Errorf("%w: url must not point at an internal address", ErrInvalidRequest)
+	}
+	if strings.EqualFold(host, "localhost") && !s.addressAllowed(net.IPv4(127, 0, 0, 1)) {
+		return fmt.Errorf("%w: url must not point at an internal address", ErrInvalidRequest)
+	}
+	return nil
+}
+
+func checkWebhookEvents(events []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, e := range events {
+		if !webhookEvents[e] {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidRequest, e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: subscribe to at least one event", ErrInvalidRequest)
+	}
+	return out, nil
+}
+
+// CreateWebhook registers an endpoint. The returned webhook carries its
+// signing secret, which is not shown again. Webhooks for a whole workspace
+// need an admin of it.
+func (s *WebhookService) CreateWebhook(c context.Context, userID string, req model.RequestCreateWebhook) (*repository.Webhook, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("WebhookService.CreateWebhook - Starting attempt to create webhook for user: %s", userID)
+
+	if err := s.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := checkWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	w := &repository.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: "whsec_" + hex.EncodeToString(secret),
+		Events: events,
+	}
+
+	if req.WorkspaceID != nil {
+		wid, _, err := s.workspaceService.authorize(c, userID, *req.WorkspaceID, roleAdmin)
+		if err != nil {
+			log.Printf("WebhookService.CreateWebhook - Access check failed: %v", err)
+			return nil, err
+		}
+		id := wid.String()
+		w.WorkspaceID = &id
+	}
+
+	created, err := s.webhookRepo.CreateWebhook(c, w)
+	if err != nil {
+		log.Printf("WebhookService.CreateWebhook - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("WebhookService.CreateWebhook - Successfully created webhook: %s", created.ID)
+	return created, nil
+}
+
+func (s *WebhookService) GetWebhooks(c context.Context, userID string) ([]repository.Webhook, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.webhookRepo.GetWebhooks(c, uid)
+	if err != nil {
+		log.Printf("WebhookService.GetWebhooks - Database error: %v", err)
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (s *WebhookService) GetWebhook(c context.Context, userID, webhookID string) (*repository.Webhook, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	w, err := s.getWebhook(c, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Secret = ""
+	return w, nil
+}
+
+func (s *WebhookService) getWebhook(c context.Context, userID, webhookID string) (*repository.Webhook, error) {
+	wid, err := uuid.Parse(webhookID)
+	if err != nil {
+		return nil, repository.ErrWebhookNotFound
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, repository.ErrWebhookNotFound
+	}
+
+	w, err := s.webhookRepo.GetWebhookByID(c, wid, uid)
+	if err != nil {
+		log.Printf("WebhookService.getWebhook - Database error: %v", err)
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// UpdateWebhook changes the URL, events or active flag of a webhook.
+// Reactivating a webhook that was switched off resets its failure count.
+func (s *WebhookService) UpdateWebhook(c context.Context, userID, webhookID string, req model.RequestUpdateWebhook) (*repository.Webhook, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("WebhookService.UpdateWebhook - Starting attempt to update webhook: %s", webhookID)
+
+	w, err := s.getWebhook(c, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := s.checkURL(*req.URL); err != nil {
+			return nil, err
+		}
+		w.URL = *req.URL
+	}
+	if req.Events != nil {
+		if w.Events, err = checkWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+
+	updated, err := s.webhookRepo.UpdateWebhook(c, w)
+	if err != nil {
+		log.Printf("WebhookService.UpdateWebhook - Database error: %v", err)
+		return nil, err
+	}
+
+	updated.Secret = ""
+	return updated, nil
+}
+
+func (s *WebhookService) DeleteWebhook(c context.Context, userID, webhookID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	w, err := s.getWebhook(c, userID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.DeleteWebhook(c, uuid.MustParse(w.ID), uuid.MustParse(userID)); err != nil {
+		log.Printf("WebhookService.DeleteWebhook - Database error: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDeliveries is the webhook's delivery log, newest first.
+func (s *WebhookService) GetDeliveries(c context.Context, userID, webhookID string) ([]repository.WebhookDelivery, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	w, err := s.getWebhook(c, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhookRepo.GetDeliveries(c, uuid.MustParse(w.ID), webhookDeliveryLimit)
+	if err != nil {
+		log.Printf("WebhookService.GetDeliveries - Database error: %v", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver queues a past delivery to be sent again.
+func (s *WebhookService) Redeliver(c context.Context, userID, webhookID, deliveryID string) (*repository.WebhookDelivery, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	w, err := s.getWebhook(c, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	did, err := uuid.Parse(deliveryID)
+	if err != nil {
+		return nil, repository.ErrDeliveryNotFound
+	}
+
+	d, err := s.webhookRepo.Redeliver(c, uuid.MustParse(w.ID), did)
+	if err != nil {
+		log.Printf("WebhookService.Redeliver - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("WebhookService.Redeliver - Queued delivery %s again as %s", deliveryID, d.ID)
+	return d, nil
+}
+
+// Ping queues a ping event to the webhook, which is handy for checking that
+// a receiver is reachable and verifies signatures.
+func (s *WebhookService) Ping(c context.Context, userID, webhookID string) (*repository.WebhookDelivery, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	w, err := s.getWebhook(c, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := s.webhookRepo.EnqueueFor(c, uuid.MustParse(w.ID), eventPing, map[string]string{"webhook_id": w.ID})
+	if err != nil {
+		log.Printf("WebhookService.Ping - Database error: %v", err)
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (s *WebhookService) Name() string {
+	return "webhooks"
+}
+
+// Publish queues ev for the webhooks subscribed to it, which makes the
+// service an outbox sink.
+func (s *WebhookService) Publish(c context.Context, ev events.Event) error {
+	_, err := s.webhookRepo.Enqueue(c, ev)
+	return err
+}
+
+// RunDispatcher sends due deliveries every interval until c is done.
+func (s *WebhookService) RunDispatcher(c context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatch(repository.WithSystem(c))
+
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch claims a batch of due deliveries and sends them in parallel,
+// carrying on while full batches keep coming.
+func (s *WebhookService) dispatch(c context.Context) {
+	for {
+		dc, cancel := context.WithTimeout(c, s.timeout)
+		claimed, err := s.webhookRepo.ClaimDue(dc, webhookBatchSize, webhookLease)
+		cancel()
+		if err != nil {
+			log.Printf("WebhookService.dispatch - Database error: %v", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, d := range claimed {
+			wg.Add(1)
+			go func(d repository.PendingDelivery) {
+				defer wg.Done()
+				s.deliver(c, d)
+			}(d)
+		}
+		wg.Wait()
+
+		if len(claimed) < webhookBatchSize || c.Err() != nil {
+			return
+		}
+	}
+}
+
+func (s *WebhookService) deliver(c context.Context, d repository.PendingDelivery) {
+	a, ok := s.send(c, d)
+
+	dc, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if ok {
+		if err := s.webhookRepo.RecordSuccess(dc, d, a); err != nil {
+			log.Printf("WebhookService.deliver - Database error: %v", err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if d.Attempts+1 < webhookMaxAttempts {
+		t := time.Now().Add(webhookBackoff(d.Attempts))
+		retryAt = &t
+	}
+
+	log.Printf("WebhookService.deliver - Delivery %s of %s failed (attempt %d)", d.ID, d.Event, d.Attempts+1)
+	if err := s.webhookRepo.RecordFailure(dc, d, a, retryAt, webhookDisableAfter); err != nil {
+		log.Printf("WebhookService.deliver - Database error: %v", err)
+	}
+}
+
+// send makes one attempt at a delivery and reports whether it got a 2xx.
+func (s *WebhookService) send(c context.Context, d repository.PendingDelivery) (repository.DeliveryAttempt, bool) {
+	var a repository.DeliveryAttempt
+
+	failed := func(err error) (repository.DeliveryAttempt, bool) {
+		msg := err.Error()
+		a.Error = &msg
+		return a, false
+	}
+
+	req, err := http.NewRequestWithContext(c, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return failed(err)
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tasktracker-webhooks")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", d.ID)
+	req.Header.Set("X-Webhook-Signature", "t="+ts+",v1="+signWebhook(d.Secret, ts, d.Payload))
+
+	start := time.Now()
+	res, err := s.client.Do(req)
+	a.Duration = time.Since(start)
+	if err != nil {
+		return failed(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, webhookResponseLimit))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return failed(err)
+	}
+
+	status, text := res.StatusCode, string(body)
+	a.Status, a.Body = &status, &text
+	return a, status >= 200 && status < 300
+}
+
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is how long to wait after the given number of earlier
+// attempts: 30s, 1m, 2m, ... up to 6h.
+func webhookBackoff(attempts int) time.Duration {
+	d := webhookRetryBase << attempts
+	if d <= 0 || d > webhookRetryMax {
+		return webhookRetryMax
+	}
+	return d
+}
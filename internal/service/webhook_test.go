@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+// receiver is a local HTTP endpoint that records what it was sent and
+// answers with status.
+type receiver struct {
+	*httptest.Server
+	hits atomic.Int32
+
+	mu     sync.Mutex
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(t *testing.T, status int, response string) *receiver {
+	t.Helper()
+
+	rc := &receiver{}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc.hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+
+		rc.mu.Lock()
+		rc.header, rc.body = r.Header.Clone(), body
+		rc.mu.Unlock()
+
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+// loopbackWebhookService may deliver to receivers on this machine.
+func loopbackWebhookService(t *testing.T, webhookRepo *repository.WebhookRepository) *WebhookService {
+	t.Helper()
+
+	networks, err := ParseWebhookNetworks("127.0.0.0/8, ::1/128")
+	if err != nil {
+		t.Fatalf("parse networks: %v", err)
+	}
+	return NewWebhookService(webhookRepo, nil, networks)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	rc := newReceiver(t, http.StatusNoContent, "")
+	s := loopbackWebhookService(t, nil)
+
+	d := repository.PendingDelivery{
+		ID:      uuid.NewString(),
+		Event:   EventTaskCreated,
+		Payload: []byte(`{"event":"task.created","data":{"id":"1"}}`),
+		URL:     rc.URL,
+		Secret:  "whsec_test",
+	}
+
+	a, ok := s.send(context.Background(), d)
+	if !ok {
+		t.Fatalf("send failed: status %v, error %v", a.Status, a.Error)
+	}
+	if a.Status == nil || *a.Status != http.StatusNoContent {
+		t.Errorf("recorded status = %v, want %d", a.Status, http.StatusNoContent)
+	}
+
+	if got := rc.header.Get("X-Webhook-Event"); got != d.Event {
+		t.Errorf("X-Webhook-Event = %q, want %q", got, d.Event)
+	}
+	if got := rc.header.Get("X-Webhook-Delivery"); got != d.ID {
+		t.Errorf("X-Webhook-Delivery = %q, want %q", got, d.ID)
+	}
+	if string(rc.body) != string(d.Payload) {
+		t.Errorf("body = %s, want %s", rc.body, d.Payload)
+	}
+
+	// Verify the way a receiver would: recompute the HMAC over "<t>.<body>".
+	var ts, sig string
+	for _, part := range strings.Split(rc.header.Get("X-Webhook-Signature"), ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	if ts == "" || sig == "" {
+		t.Fatalf("X-Webhook-Signature = %q, want t=...,v1=...", rc.header.Get("X-Webhook-Signature"))
+	}
+
+	mac := hmac.New(sha256.New, []byte(d.Secret))
+	mac.Write([]byte(ts + "." + string(rc.body)))
+	want := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		t.Errorf("signature = %s, want %s", sig, want)
+	}
+	if got := signWebhook(d.Secret, ts, rc.body); got != want {
+		t.Errorf("signWebhook = %s, want %s", got, want)
+	}
+	if signWebhook("whsec_other", ts, rc.body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestWebhookSendFailure(t *testing.T) {
+	rc := newReceiver(t, http.StatusInternalServerError, strings.Repeat("x", 2*webhookResponseLimit))
+	s := loopbackWebhookService(t, nil)
+
+	a, ok := s.send(context.Background(), repository.PendingDelivery{ID: uuid.NewString(), Payload: []byte("{}"), URL: rc.URL})
+	if ok {
+		t.Fatal("a 500 counted as delivered")
+	}
+	if a.Status == nil || *a.Status != http.StatusInternalServerError {
+		t.Errorf("recorded status = %v, want 500", a.Status)
+	}
+	if a.Body == nil || len(*a.Body) != webhookResponseLimit {
+		t.Errorf("recorded %d bytes of the response, want %d", len(*a.Body), webhookResponseLimit)
+	}
+}
+
+func TestWebhookInternalAddresses(t *testing.T) {
+	rc := newReceiver(t, http.StatusOK, "internal")
+	s := NewWebhookService(nil, nil, nil)
+
+	a, ok := s.send(context.Background(), repository.PendingDelivery{ID: uuid.NewString(), Payload: []byte("{}"), URL: rc.URL})
+	if ok || a.Error == nil || a.Body != nil {
+		t.Errorf("send to %s = %v, error %v, body %v; want it refused", rc.URL, ok, a.Error, a.Body)
+	}
+	if rc.hits.Load() != 0 {
+		t.Error("receiver on a loopback address was reached")
+	}
+
+	for _, u := range []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:5432",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+	} {
+		if err := s.checkURL(u); err == nil {
+			t.Errorf("checkURL(%q) = nil, want an error", u)
+		}
+	}
+	if err := s.checkURL("https://hooks.example.com/tasks"); err != nil {
+		t.Errorf("checkURL of a public URL = %v", err)
+	}
+}
+
+func TestWebhookRedirectsNotFollowed(t *testing.T) {
+	target := newReceiver(t, http.StatusOK, "secret")
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	t.Cleanup(redirect.Close)
+	s := loopbackWebhookService(t, nil)
+
+	a, ok := s.send(context.Background(), repository.PendingDelivery{ID: uuid.NewString(), Payload: []byte("{}"), URL: redirect.URL})
+	if ok || a.Status == nil || *a.Status != http.StatusFound {
+		t.Errorf("send = %v, status %v; want a failed 302", ok, a.Status)
+	}
+	if target.hits.Load() != 0 {
+		t.Error("redirect was followed")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, webhookRetryMax},
+		{webhookMaxAttempts, webhookRetryMax},
+		{80, webhookRetryMax},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestWebhookRetryAndDisable runs deliveries against a receiver that keeps
+// failing, through the database. It needs TEST_CONNECTION_STRING to name a
+// migrated database of its own, since the dispatcher claims whatever is due.
+func TestWebhookRetryAndDisable(t *testing.T) {
+	dsn := os.Getenv("TEST_CONNECTION_STRING")
+	if dsn == "" {
+		t.Skip("TEST_CONNECTION_STRING is not set")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	rc := newReceiver(t, http.StatusServiceUnavailable, "down")
+	webhookRepo := repository.NewWebhookRepository(db)
+	s := loopbackWebhookService(t, webhookRepo)
+
+	var uid uuid.UUID
+	suffix := uuid.NewString()
+	if err := db.QueryRow(
+		"INSERT INTO users (username, email) VALUES ($1, $2) RETURNING id",
+		"webhook-"+suffix, "webhook-"+suffix+"@example.com",
+	).Scan(&uid); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM users WHERE id = $1", uid) })
+
+	c := repository.WithUser(context.Background(), uid)
+	w, err := webhookRepo.CreateWebhook(c, &repository.Webhook{
+		UserID: uid.String(),
+		URL:    rc.URL,
+		Secret: "whsec_test",
+		Events: []string{EventTaskCreated},
+	})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	wid := uuid.MustParse(w.ID)
+
+	enqueue := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			if _, err := webhookRepo.EnqueueFor(c, wid, eventPing, map[string]int{"n": i}); err != nil {
+				t.Fatalf("enqueue: %v", err)
+			}
+		}
+	}
+
+	// One failure short of the limit the webhook stays on, and every delivery
+	// is put back in the queue for after the first backoff.
+	enqueue(webhookDisableAfter - 1)
+	start := time.Now()
+	s.dispatch(repository.WithSystem(context.Background()))
+
+	if got := int(rc.hits.Load()); got != webhookDisableAfter-1 {
+		t.Fatalf("receiver got %d requests, want %d", got, webhookDisableAfter-1)
+	}
+
+	w, err = webhookRepo.GetWebhookByID(c, wid, uid)
+	if err != nil {
+		t.Fatalf("get webhook: %v", err)
+	}
+	if !w.Active || w.ConsecutiveFailures != webhookDisableAfter-1 {
+		t.Fatalf("webhook active = %v with %d failures, want active with %d", w.Active, w.ConsecutiveFailures, webhookDisableAfter-1)
+	}
+
+	deliveries, err := webhookRepo.GetDeliveries(c, wid, webhookDeliveryLimit)
+	if err != nil {
+		t.Fatalf("get deliveries: %v", err)
+	}
+	for _, d := range deliveries {
+		if d.Status != "pending" || d.Attempts != 1 {
+			t.Errorf("delivery %s is %s after %d attempts, want pending after 1", d.ID, d.Status, d.Attempts)
+		}
+		// Allow for the clocks of the database and the test drifting apart.
+		retry := d.NextAttemptAt.Sub(start)
+		if retry < webhookBackoff(0)-5*time.Second || retry > webhookBackoff(0)+5*time.Second {
+			t.Errorf("delivery %s is retried after %v, want about %v", d.ID, retry, webhookBackoff(0))
+		}
+	}
+
+	// The next failure switches it off, and nothing more is sent.
+	enqueue(1)
+	s.dispatch(repository.WithSystem(context.Background()))
+
+	w, err = webhookRepo.GetWebhookByID(c, wid, uid)
+	if err != nil {
+		t.Fatalf("get webhook: %v", err)
+	}
+	if w.Active || w.DisabledAt == nil || w.ConsecutiveFailures != webhookDisableAfter {
+		t.Fatalf("webhook active = %v, disabled at %v with %d failures, want disabled after %d",
+			w.Active, w.DisabledAt, w.ConsecutiveFailures, webhookDisableAfter)
+	}
+
+	hits := rc.hits.Load()
+	enqueue(1)
+	s.dispatch(repository.WithSystem(context.Background()))
+	if rc.hits.Load() != hits {
+		t.Error("a disabled webhook was still sent deliveries")
+	}
+}
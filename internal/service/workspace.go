@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/0xrishabk/tasktracker/internal/mailer"
+	"github.com/0xrishabk/tasktracker/internal/model"
+	"github.com/0xrishabk/tasktracker/internal/repository"
+)
+
+const (
+	roleOwner  = "owner"
+	roleAdmin  = "admin"
+	roleMember = "member"
+	roleGuest  = "guest"
+
+	invitationTTL = 7 * 24 * time.Hour
+)
+
+var roleRank = map[string]int{
+	roleGuest:  1,
+	roleMember: 2,
+	roleAdmin:  3,
+	roleOwner:  4,
+}
+
+type WorkspaceService struct {
+	workspaceRepo  *repository.WorkspaceRepository
+	invitationRepo *repository.InvitationRepository
+	userRepo       *repository.UserRepository
+	mailer         mailer.Mailer
+	appURL         string
+	timeout        time.Duration
+}
+
+func NewWorkspaceService(workspaceRepo *repository.WorkspaceRepository, invitationRepo *repository.InvitationRepository, userRepo *repository.UserRepository, m mailer.Mailer, appURL string) *WorkspaceService {
+	return &WorkspaceService{
+		workspaceRepo:  workspaceRepo,
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		mailer:         m,
+		appURL:         strings.TrimRight(appURL, "/"),
+		timeout:        time.Duration(2) * time.Second,
+	}
+}
+
+// Membership checks that userID belongs to workspaceID. Non-members get
+// ErrWorkspaceNotFound so workspace IDs can't be probed.
+func (s *WorkspaceService) Membership(c context.Context, userID, workspaceID string) (uuid.UUID, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, _, err := s.authorize(c, userID, workspaceID, roleGuest)
+	return wid, err
+}
+
+// authorize resolves the workspace and checks that userID holds at least the
+// needed role in it, returning the parsed workspace ID and the user's role.
+func (s *WorkspaceService) authorize(c context.Context, userID, workspaceID, need string) (uuid.UUID, string, error) {
+	wid, err := uuid.Parse(workspaceID)
+	if err != nil {
+		return uuid.Nil, "", repository.ErrWorkspaceNotFound
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, "", ErrWorkspaceForbidden
+	}
+
+	role, err := s.workspaceRepo.GetRole(c, wid, uid)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	if role == "" {
+		return uuid.Nil, "", repository.ErrWorkspaceNotFound
+	}
+
+	if roleRank[role] < roleRank[need] {
+		return uuid.Nil, "", ErrWorkspaceForbidden
+	}
+
+	return wid, role, nil
+}
+
+func (s *WorkspaceService) CreateWorkspace(c context.Context, userID string, req model.RequestCreateWorkspace) (*repository.Workspace, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("WorkspaceService.CreateWorkspace - Starting workspace creation for: %s", req.Name)
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("WorkspaceService.CreateWorkspace - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	w, err := s.workspaceRepo.CreateWorkspace(c, name, uid)
+	if err != nil {
+		log.Printf("WorkspaceService.CreateWorkspace - Database error: %v", err)
+		return nil, err
+	}
+
+	log.Printf("WorkspaceService.CreateWorkspace - Workspace creation was successful: %s", w.ID)
+	return w, nil
+}
+
+func (s *WorkspaceService) GetWorkspaces(c context.Context, userID string) ([]repository.Workspace, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("WorkspaceService.GetWorkspaces - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	return s.workspaceRepo.GetWorkspacesByUserID(c, uid)
+}
+
+func (s *WorkspaceService) GetWorkspace(c context.Context, userID, workspaceID string) (*repository.Workspace, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, role, err := s.authorize(c, userID, workspaceID, roleGuest)
+	if err != nil {
+		log.Printf("WorkspaceService.GetWorkspace - Access check failed: %v", err)
+		return nil, err
+	}
+
+	w, err := s.workspaceRepo.GetWorkspaceByID(c, wid)
+	if err != nil {
+		log.Printf("WorkspaceService.GetWorkspace - Database error: %v", err)
+		return nil, err
+	}
+
+	w.Role = role
+	return w, nil
+}
+
+func (s *WorkspaceService) UpdateWorkspace(c context.Context, userID, workspaceID string, req model.RequestUpdateWorkspace) (*repository.Workspace, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+
+	wid, role, err := s.authorize(c, userID, workspaceID, roleAdmin)
+	if err != nil {
+		log.Printf("WorkspaceService.UpdateWorkspace - Access check failed: %v", err)
+		return nil, err
+	}
+
+	w, err := s.workspaceRepo.UpdateWorkspaceName(c, wid, name)
+	if err != nil {
+		log.Printf("WorkspaceService.UpdateWorkspace - Database error: %v", err)
+		return nil, err
+	}
+
+	w.Role = role
+	return w, nil
+}
+
+// DeleteWorkspace removes a workspace along with its projects and tasks.
+// Personal workspaces can't be deleted.
+func (s *WorkspaceService) DeleteWorkspace(c context.Context, userID, workspaceID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("WorkspaceService.DeleteWorkspace - Starting attempt to delete workspace: %s", workspaceID)
+
+	wid, _, err := s.authorize(c, userID, workspaceID, roleOwner)
+	if err != nil {
+		log.Printf("WorkspaceService.DeleteWorkspace - Access check failed: %v", err)
+		return err
+	}
+
+	w, err := s.workspaceRepo.GetWorkspaceByID(c, wid)
+	if err != nil {
+		log.Printf("WorkspaceService.DeleteWorkspace - Database error: %v", err)
+		return err
+	}
+
+	if w.Personal {
+		return fmt.Errorf("%w: a personal workspace cannot be deleted", ErrInvalidRequest)
+	}
+
+	if err := s.workspaceRepo.DeleteWorkspace(c, wid); err != nil {
+		log.Printf("WorkspaceService.DeleteWorkspace - Database error: %v", err)
+		return err
+	}
+
+	log.Printf("WorkspaceService.DeleteWorkspace - Successfully deleted workspace: %s", workspaceID)
+	return nil
+}
+
+func (s *WorkspaceService) GetMembers(c context.Context, userID, workspaceID string) ([]repository.WorkspaceMember, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, _, err := s.authorize(c, userID, workspaceID, roleGuest)
+	if err != nil {
+		log.Printf("WorkspaceService.GetMembers - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.workspaceRepo.GetMembers(c, wid)
+}
+
+// UpdateMemberRole changes a member's role. Admins manage everyone below
+// owner; only owners can promote to or demote from owner, and the last owner
+// can't be demoted.
+func (s *WorkspaceService) UpdateMemberRole(c context.Context, actorID, workspaceID, userID string, req model.RequestUpdateMemberRole) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("WorkspaceService.UpdateMemberRole - Starting attempt to make %s %s in workspace: %s", userID, req.Role, workspaceID)
+
+	if _, ok := roleRank[req.Role]; !ok {
+		return fmt.Errorf("%w: role must be one of owner, admin, member, guest", ErrInvalidRequest)
+	}
+
+	wid, actorRole, err := s.authorize(c, actorID, workspaceID, roleAdmin)
+	if err != nil {
+		log.Printf("WorkspaceService.UpdateMemberRole - Access check failed: %v", err)
+		return err
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return repository.ErrMemberNotFound
+	}
+
+	current, err := s.workspaceRepo.GetRole(c, wid, uid)
+	if err != nil {
+		log.Printf("WorkspaceService.UpdateMemberRole - Database error: %v", err)
+		return err
+	}
+
+	if current == "" {
+		return repository.ErrMemberNotFound
+	}
+
+	if (current == roleOwner || req.Role == roleOwner) && actorRole != roleOwner {
+		return ErrWorkspaceForbidden
+	}
+
+	if current == roleOwner && req.Role != roleOwner {
+		if err := s.checkNotLastOwner(c, wid); err != nil {
+			return err
+		}
+	}
+
+	if err := s.workspaceRepo.UpdateMemberRole(c, wid, uid, req.Role); err != nil {
+		log.Printf("WorkspaceService.UpdateMemberRole - Database error: %v", err)
+		return err
+	}
+
+	log.Printf("WorkspaceService.UpdateMemberRole - Successfully updated role of %s in workspace: %s", userID, workspaceID)
+	return nil
+}
+
+// RemoveMember takes a user out of the workspace. Any member can leave on
+// their own; removing someone else takes an admin, or an owner if the target
+// is an owner.
+func (s *WorkspaceService) RemoveMember(c context.Context, actorID, workspaceID, userID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("WorkspaceService.RemoveMember - Starting attempt to remove %s from workspace: %s", userID, workspaceID)
+
+	need := roleAdmin
+	if actorID == userID {
+		need = roleGuest
+	}
+
+	wid, actorRole, err := s.authorize(c, actorID, workspaceID, need)
+	if err != nil {
+		log.Printf("WorkspaceService.RemoveMember - Access check failed: %v", err)
+		return err
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return repository.ErrMemberNotFound
+	}
+
+	role, err := s.workspaceRepo.GetRole(c, wid, uid)
+	if err != nil {
+		log.Printf("WorkspaceService.RemoveMember - Database error: %v", err)
+		return err
+	}
+
+	if role == "" {
+		return repository.ErrMemberNotFound
+	}
+
+	if role == roleOwner {
+		if actorID != userID && actorRole != roleOwner {
+			return ErrWorkspaceForbidden
+		}
+		if err := s.checkNotLastOwner(c, wid); err != nil {
+			return err
+		}
+	}
+
+	if err := s.workspaceRepo.RemoveMember(c, wid, uid); err != nil {
+		log.Printf("WorkspaceService.RemoveMember - Database error: %v", err)
+		return err
+	}
+
+	log.Printf("WorkspaceService.RemoveMember - Successfully removed %s from workspace: %s", userID, workspaceID)
+	return nil
+}
+
+func (s *WorkspaceService) checkNotLastOwner(c context.Context, workspaceID uuid.UUID) error {
+	owners, err := s.workspaceRepo.CountOwners(c, workspaceID)
+	if err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
+
+// Invite emails a single-use link that lets the recipient join the workspace
+// with the given role. Only the hash of the token is stored.
+func (s *WorkspaceService) Invite(c context.Context, actorID, workspaceID string, req model.RequestInviteMember) (*repository.Invitation, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("WorkspaceService.Invite - Starting attempt to invite %s to workspace: %s", req.Email, workspaceID)
+
+	addr, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("%w: email is invalid", ErrInvalidRequest)
+	}
+
+	if req.Role == "" {
+		req.Role = roleMember
+	}
+	if req.Role == roleOwner || roleRank[req.Role] == 0 {
+		return nil, fmt.Errorf("%w: role must be one of admin, member, guest", ErrInvalidRequest)
+	}
+
+	wid, _, err := s.authorize(c, actorID, workspaceID, roleAdmin)
+	if err != nil {
+		log.Printf("WorkspaceService.Invite - Access check failed: %v", err)
+		return nil, err
+	}
+
+	w, err := s.workspaceRepo.GetWorkspaceByID(c, wid)
+	if err != nil {
+		log.Printf("WorkspaceService.Invite - Database error: %v", err)
+		return nil, err
+	}
+
+	if w.Personal {
+		return nil, fmt.Errorf("%w: nobody can be invited to a personal workspace", ErrInvalidRequest)
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		log.Printf("WorkspaceService.Invite - Token generation failed: %v", err)
+		return nil, err
+	}
+
+	invitation, err := s.invitationRepo.CreateInvitation(c, &repository.Invitation{
+		WorkspaceID: w.ID,
+		Email:       addr.Address,
+		Role:        req.Role,
+		InvitedBy:   &actorID,
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	}, tokenHash)
+	if err != nil {
+		log.Printf("WorkspaceService.Invite - Database error: %v", err)
+		return nil, err
+	}
+
+	msg := mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You've been invited to %s", w.Name),
+		Body: fmt.Sprintf(
+			"You've been invited to join the %s workspace as %s.\n\nAccept the invitation here: %s/invitations/accept?token=%s\n\nThis link expires on %s.\n",
+			w.Name, invitation.Role, s.appURL, url.QueryEscape(token), invitation.ExpiresAt.Format(time.RFC1123),
+		),
+	}
+	if err := s.mailer.Send(c, msg); err != nil {
+		log.Printf("WorkspaceService.Invite - Mailer error: %v", err)
+		return nil, fmt.Errorf("failed to send invitation: %v", err)
+	}
+
+	log.Printf("WorkspaceService.Invite - Invitation was successful: %s", invitation.ID)
+	return invitation, nil
+}
+
+func (s *WorkspaceService) GetInvitations(c context.Context, actorID, workspaceID string) ([]repository.Invitation, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, _, err := s.authorize(c, actorID, workspaceID, roleAdmin)
+	if err != nil {
+		log.Printf("WorkspaceService.GetInvitations - Access check failed: %v", err)
+		return nil, err
+	}
+
+	return s.invitationRepo.GetPendingInvitations(c, wid)
+}
+
+func (s *WorkspaceService) RevokeInvitation(c context.Context, actorID, workspaceID, invitationID string) error {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	wid, _, err := s.authorize(c, actorID, workspaceID, roleAdmin)
+	if err != nil {
+		log.Printf("WorkspaceService.RevokeInvitation - Access check failed: %v", err)
+		return err
+	}
+
+	iid, err := uuid.Parse(invitationID)
+	if err != nil {
+		return repository.ErrInvitationNotFound
+	}
+
+	return s.invitationRepo.RevokeInvitation(c, wid, iid)
+}
+
+// AcceptInvitation redeems an invitation token for the signed-in user, whose
+// email must match the one the invitation was sent to.
+func (s *WorkspaceService) AcceptInvitation(c context.Context, userID string, req model.RequestAcceptInvitation) (*repository.Workspace, error) {
+	c, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	log.Printf("WorkspaceService.AcceptInvitation - Starting attempt to accept invitation for user: %s", userID)
+
+	if req.Token == "" {
+		return nil, fmt.Errorf("%w: token is required", ErrInvalidRequest)
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		log.Printf("WorkspaceService.AcceptInvitation - UUID parsing error: %v", err)
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(c, uid)
+	if err != nil {
+		log.Printf("WorkspaceService.AcceptInvitation - Database error: %v", err)
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	invitation, err := s.invitationRepo.AcceptInvitation(c, hashToken(req.Token), uid, user.Email)
+	if err != nil {
+		log.Printf("WorkspaceService.AcceptInvitation - Database error: %v", err)
+		return nil, err
+	}
+
+	w, err := s.workspaceRepo.GetWorkspaceByID(c, uuid.MustParse(invitation.WorkspaceID))
+	if err != nil {
+		log.Printf("WorkspaceService.AcceptInvitation - Database error: %v", err)
+		return nil, err
+	}
+
+	w.Role = invitation.Role
+
+	log.Printf("WorkspaceService.AcceptInvitation - User %s joined workspace: %s", userID, w.ID)
+	return w, nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+type BlobStore interface {
+	Put(c context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(c context.Context, key string, size int64) (io.ReadSeekCloser, error)
+	Delete(c context.Context, key string) error
+}
+
+func NewBlobStore() (BlobStore, error) {
+	switch driver := os.Getenv("BLOB_STORE"); driver {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/attachments"
+		}
+		return NewLocalStore(dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store: %s", driver)
+	}
+}